@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vendelin8/firemage/internal/api"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/console"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var fixturesPath string
+
+// newEmuCmd creates the group of commands working with the Firebase emulators.
+func newEmuCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "emu",
+		Short: lang.DescEmu,
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newSeedCmd())
+	return cmd
+}
+
+// newSeedCmd creates the command creating the users of a fixtures file in the emulators, always in
+// emulator mode.
+func newSeedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "seed",
+		Short:        lang.DescSeed,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			conf.UseEmu = true
+			if err := initBackend(); err != nil {
+				return err
+			}
+
+			common.Fe = console.New(os.Stdin, os.Stdout)
+			return api.Seed(fixturesPath)
+		},
+	}
+
+	cmd.Flags().StringVarP(&fixturesPath, "file", "f", "", lang.DescFixtures)
+	_ = cmd.MarkFlagRequired("file")
+	return cmd
+}
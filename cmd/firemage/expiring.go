@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vendelin8/firemage/internal/api"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/console"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var (
+	expiringWithin string
+	expiringJSON   bool
+)
+
+// newExpiringCmd creates the command listing the timed permissions expiring soon from the command
+// line. Messages go to the standard error, so the list can be piped.
+func newExpiringCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "expiring",
+		Short:        lang.DescExpiring,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := initBackend(); err != nil {
+				return err
+			}
+
+			common.Fe = console.New(os.Stdin, cmd.ErrOrStderr())
+			if err := conf.InitConf(func(string, string, string, bool) {}); err != nil {
+				return common.Classify(common.ExitConfig, err)
+			}
+
+			return api.Expiring(session, expiringWithin, expiringJSON, cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().StringVar(&expiringWithin, "within", "30d", lang.DescWithin)
+	cmd.Flags().BoolVar(&expiringJSON, "json", false, lang.DescJSON)
+	return cmd
+}
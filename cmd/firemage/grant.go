@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vendelin8/firemage/internal/api"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/console"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var grantRole, grantFor string
+
+// newGrantCmd creates the command applying a role template to users from the command line.
+func newGrantCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "grant <email|uid>...",
+		Short:        lang.DescGrant,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if err := initBackend(); err != nil {
+				return err
+			}
+
+			common.Fe = console.New(os.Stdin, os.Stdout)
+			if err := conf.InitConf(func(string, string, string, bool) {}); err != nil {
+				return common.Classify(common.ExitConfig, err)
+			}
+			if err := firebase.ResolveOperator(); err != nil {
+				return err
+			}
+
+			return api.Grant(session, grantRole, grantFor, args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&grantRole, "role", "r", "", lang.DescRole)
+	_ = cmd.MarkFlagRequired("role")
+	cmd.Flags().StringVar(&grantFor, "for", "", lang.DescFor)
+	return cmd
+}
@@ -1,42 +1,190 @@
 package main
 
 import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/vendelin8/firemage/internal/api"
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/conf"
 	"github.com/vendelin8/firemage/internal/firebase"
 	"github.com/vendelin8/firemage/internal/frontend"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/lang"
 	"github.com/vendelin8/firemage/internal/log"
 	"github.com/vendelin8/firemage/internal/util"
 )
 
-// rootCmd represents the base command when called without any subcommands
-var rootCmd = &cobra.Command{
-	Use:   "firemage",
-	Short: lang.ShortDesc,
-	Long:  lang.LongDesc,
+var (
+	// session is the state of the app, shared by all commands.
+	session = global.NewSession()
+
+	// logSync flushes and closes the log, nil until it's opened.
+	logSync func()
+
+	// started is set once flags and arguments are checked, earlier errors are usage errors.
+	started bool
+)
+
+// newRootCmd creates the base command, that runs the GUI when called without any subcommands.
+func newRootCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "firemage",
+		Short:             lang.ShortDesc,
+		Long:              lang.LongDesc,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		PersistentPreRunE: initApp,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := initBackend(); err != nil {
+				return err
+			}
+
+			if err := conf.LoadTheme(); err != nil {
+				return common.Classify(common.ExitConfig, err)
+			}
+
+			gui := frontend.CreateGUI(session)
+			common.Fe = gui
+			if firebase.HasJournal() {
+				gui.QueueUpdateDraw(func() { window.ShowWarn(lang.WarnJournalS) })
+			}
+			return gui.Run()
+		},
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVarP(&conf.KeyPath, "key", "k", "", lang.DescKey)
+	flags.StringVar(&conf.Credentials, "credentials", "", lang.DescCredentials)
+	flags.StringVar(&conf.Impersonate, "impersonate", "", lang.DescImpersonate)
+	flags.StringVar(&conf.Project, "project", "", lang.DescProject)
+	flags.StringVarP(&conf.ConfPath, "conf", "c", "conf.yml", lang.DescConf)
+	flags.StringVarP(&log.LogPath, "log", "l", "log.txt", lang.DescLog)
+	flags.BoolVarP(&log.Verbose, "verbose", "v", false, lang.DescDebug)
+	flags.BoolVarP(&conf.UseEmu, "emulator", "e", false, lang.DescEmul)
+	flags.StringVar(&conf.FirestoreEmuHost, "firestore-emulator", conf.FirestoreEmuHost, lang.DescFirestoreEmu)
+	flags.StringVar(&conf.AuthEmuHost, "auth-emulator", conf.AuthEmuHost, lang.DescAuthEmu)
+	flags.BoolVarP(&conf.Watch, "watch", "w", false, lang.DescWatch)
+	flags.StringVarP(&conf.Operator, "operator", "o",
+		cmp.Or(os.Getenv("FIREMAGE_OPERATOR"), os.Getenv("USER")), lang.DescOperator)
+	flags.BoolVar(&conf.DryRun, "dry-run", false, lang.DescDryRun)
+	flags.BoolVar(&conf.ReadOnly, "read-only", false, lang.DescReadOnly)
+	flags.StringVarP(&conf.Profile, "profile", "p", "", lang.DescProfile)
+	flags.StringVar(&conf.Lang, "lang", conf.Lang, lang.DescLang)
+	flags.DurationVar(&conf.RefreshTimeout, "refresh-timeout", conf.RefreshTimeout, lang.DescRefreshTimeout)
+	flags.StringVar(&conf.CheckpointPath, "checkpoint", conf.CheckpointPath, lang.DescCheckpoint)
+	flags.StringVar(&conf.JournalPath, "journal", conf.JournalPath, lang.DescJournal)
+	flags.DurationVar(&conf.Timeout, "timeout", conf.Timeout, lang.DescTimeout)
+	flags.IntVar(&conf.BatchSize, "batch-size", conf.BatchSize, lang.DescBatchSize)
+	flags.IntVar(&conf.Retries, "retries", conf.Retries, lang.DescRetries)
+	flags.DurationVar(&conf.Backoff, "backoff", conf.Backoff, lang.DescBackoff)
+
+	cmd.AddCommand(newGrantCmd(), newExpiringCmd(), newRepairCmd(), newEmuCmd())
+	return cmd
 }
 
 func init() {
 	cobra.MousetrapHelpText = ""
-	rootCmd.Flags().StringVarP(&conf.KeyPath, "key", "k", "service-account.json", lang.DescKey)
-	rootCmd.Flags().StringVarP(&conf.ConfPath, "conf", "c", "conf.yml", lang.DescConf)
-	rootCmd.Flags().StringVarP(&log.LogPath, "log", "l", "log.txt", lang.DescLog)
-	rootCmd.Flags().BoolVarP(&log.Verbose, "verbose", "v", false, lang.DescDebug)
-	rootCmd.Flags().BoolVarP(&conf.UseEmu, "emulator", "e", false, lang.DescEmul)
 }
 
-func main() {
-	api.InitMenu()
-	log.Must("initialize timed buttons map from custom/custom.txt", util.InitializeTimedButtonsMap())
-	log.Must("execute command", rootCmd.Execute())
+// selectLanguage loads the language given with --lang, set in the config file, or of the
+// environment, English by default. It runs before the commands are created, so their help is
+// translated too.
+func selectLanguage(args []string) error {
+	fs := pflag.NewFlagSet("firemage", pflag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.ParseErrorsAllowlist.UnknownFlags = true
+	fs.StringVar(&conf.Lang, "lang", "", "")
+	fs.StringVarP(&conf.ConfPath, "conf", "c", "conf.yml", "")
+	fs.BoolP("help", "h", false, "")
+	_ = fs.Parse(args) // other errors are reported by the commands
+
+	if len(conf.Lang) > 0 {
+		return common.Classify(common.ExitUsage, lang.Load(conf.Lang))
+	}
+
+	code, err := conf.ConfLanguage()
+	if err != nil {
+		return common.Classify(common.ExitConfig, err)
+	}
+	if len(code) > 0 {
+		return common.Classify(common.ExitConfig, lang.Load(code))
+	}
+
+	return lang.Load(lang.FromEnv())
+}
+
+// initApp opens the log, and parses the settings built into the binary, checked against the config
+// file by conf.InitConf. It runs for every command after flags and arguments are checked, so not for
+// help.
+func initApp(_ *cobra.Command, _ []string) error {
+	started = true
+
+	var err error
+	if logSync, err = log.Init(); err != nil {
+		return err
+	}
 
-	if logSync := log.Init(); logSync != nil {
-		defer logSync()
+	api.InitMenu(session)
+	return common.Classify(common.ExitConfig, util.InitializeTimedButtonsMap())
+}
+
+// initBackend checks the flags, applies the profile, and connects to Firebase.
+func initBackend() error {
+	if err := conf.CheckFlags(); err != nil {
+		return common.Classify(common.ExitUsage, err)
+	}
+
+	if err := conf.LoadProfile(); err != nil {
+		return common.Classify(common.ExitConfig, err)
+	}
+
+	fb, err := firebase.New(session)
+	if err != nil {
+		return err
+	}
+
+	common.Fb = firebase.NewDryRun(firebase.NewRetry(firebase.NewReadOnly(fb)))
+	firebase.SetDryRun(conf.DryRun)
+	return nil
+}
+
+// run executes the command of the given arguments, prints the error if any with a hint on how to
+// fix it, and returns the exit code. Help is printed to stdout.
+func run(args []string, stdout, stderr io.Writer) int {
+	err := selectLanguage(args)
+	if err == nil {
+		cmd := newRootCmd()
+		cmd.SetArgs(args)
+		cmd.SetOut(stdout)
+		cmd.SetErr(stderr)
+		err = cmd.Execute()
+	}
+	if logSync != nil {
+		logSync()
 	}
-	common.Fb = firebase.New()
-	common.Fe = frontend.CreateGUI()
-	common.Fe.Run()
+
+	if err == nil {
+		return common.ExitOK
+	}
+
+	if !started {
+		err = common.Classify(common.ExitUsage, err) // keeps the class of language errors
+	}
+
+	fmt.Fprintf(stderr, "%s: %v\n", lang.SError, err)
+	if hint := common.Hint(err); len(hint) > 0 {
+		fmt.Fprintln(stderr, hint)
+	}
+
+	return common.ExitCode(err)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
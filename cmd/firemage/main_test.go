@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+func TestRun(t *testing.T) {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		t.Setenv(name, "")
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "help doesn't start the app",
+			args:       []string{"-h"},
+			wantCode:   common.ExitOK,
+			wantStdout: "firemage is a CLI tool",
+		},
+		{
+			name:       "translated help",
+			args:       []string{"--lang", "hu", "-h"},
+			wantCode:   common.ExitOK,
+			wantStdout: "firemage egy Go nyelven",
+		},
+		{
+			name:       "unknown language",
+			args:       []string{"--lang=xx", "-h"},
+			wantCode:   common.ExitUsage,
+			wantStderr: "xx",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"--no-such-flag"},
+			wantCode:   common.ExitUsage,
+			wantStderr: lang.HintUsage,
+		},
+		{
+			name:       "translated hint",
+			args:       []string{"--lang", "hu", "--no-such-flag"},
+			wantCode:   common.ExitUsage,
+			wantStderr: "Futtasd -h kapcsolóval",
+		},
+		{
+			name:       "missing arguments",
+			args:       []string{"grant"},
+			wantCode:   common.ExitUsage,
+			wantStderr: lang.HintUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { started = false }()
+			defer func() { require.NoError(t, lang.Load(lang.Default)) }()
+			var stdout, stderr bytes.Buffer
+
+			assert.Equal(t, tt.wantCode, run(tt.args, &stdout, &stderr))
+			assert.False(t, started)
+			assert.Contains(t, stdout.String(), tt.wantStdout)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/vendelin8/firemage/internal/api"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/console"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// newRepairCmd creates the command finishing the permission writes of an interrupted save from the
+// command line.
+func newRepairCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:          "repair",
+		Short:        lang.DescRepair,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := initBackend(); err != nil {
+				return err
+			}
+
+			common.Fe = console.New(os.Stdin, os.Stdout)
+			if err := conf.InitConf(func(string, string, string, bool) {}); err != nil {
+				return common.Classify(common.ExitConfig, err)
+			}
+			if err := firebase.ResolveOperator(); err != nil {
+				return err
+			}
+
+			return api.Repair(session)
+		},
+	}
+}
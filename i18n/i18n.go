@@ -0,0 +1,10 @@
+// Package i18n embeds the message catalogs and the example configs of the supported languages,
+// each in the directory named by its language code.
+package i18n
+
+import "embed"
+
+// Catalogs holds <language code>/lang.yml for every supported language.
+//
+//go:embed */lang.yml
+var Catalogs embed.FS
@@ -1,8 +1,8 @@
 package api
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/vendelin8/firemage/internal/common"
@@ -15,73 +15,222 @@ import (
 )
 
 var (
-	ErrActions     = errors.New(lang.ErrActionsS)
-	ErrNoChanges   = errors.New(lang.ErrNoChangesS)
-	ErrCantRefresh = errors.New(lang.ErrCantRefreshS)
+	ErrActions     = common.ErrActions
+	ErrNoChanges   = lang.NewError(&lang.ErrNoChangesS)
+	ErrCantRefresh = lang.NewError(&lang.ErrCantRefreshS)
 )
 
-func InitMenu() {
+// InitMenu creates the menu items working on the given session.
+func InitMenu(s *global.Session) {
 	common.MenuItems = map[int]common.MenuItem{
-		conf.CmdCancel:  {Shortcut: "F8", Keys: []tcell.Key{tcell.KeyF8}, MenuKey: "", Text: lang.SCancel, Positive: false, IsDef: true, Function: cancel},
-		conf.CmdRefresh: {Shortcut: "F5", Keys: []tcell.Key{tcell.KeyF5}, MenuKey: "", Text: lang.MenuRefresh, Positive: true, IsDef: true, Function: refresh},
-		conf.CmdSearch:  {Shortcut: "F2", Keys: []tcell.Key{tcell.KeyF2}, MenuKey: lang.PageSearch, Text: lang.Titles[lang.PageSearch], Positive: false, IsDef: true, Function: showSearch},
-		conf.CmdList:    {Shortcut: "F3", Keys: []tcell.Key{tcell.KeyF3}, MenuKey: lang.PageList, Text: lang.Titles[lang.PageList], Positive: false, IsDef: true, Function: showList},
-		conf.CmdSave:    {Shortcut: "F6", Keys: []tcell.Key{tcell.KeyF6}, MenuKey: "", Text: lang.MenuSave, Positive: true, IsDef: true, Function: save},
-		conf.CmdQuit:    {Shortcut: "Esc", Keys: []tcell.Key{tcell.KeyEsc}, MenuKey: "", Text: lang.MenuQuit, Positive: false, IsDef: true, Function: window.Quit},
+		conf.CmdCancel:    {Shortcut: "F8", Keys: []common.Hotkey{{Key: tcell.KeyF8}}, MenuKey: "", Text: lang.SCancel, Positive: false, IsDef: true, Function: func() error { return cancel(s) }},
+		conf.CmdDryRun:    {Shortcut: "F9", Keys: []common.Hotkey{{Key: tcell.KeyF9}}, MenuKey: "", Text: lang.MenuDryRun, Positive: false, IsDef: true, Function: toggleDryRun},
+		conf.CmdRefresh:   {Shortcut: "F5", Keys: []common.Hotkey{{Key: tcell.KeyF5}}, MenuKey: "", Text: lang.MenuRefresh, Positive: true, IsDef: true, Function: func() error { return refresh(s, window.ShowErrorBuffer) }},
+		conf.CmdSearch:    {Shortcut: "F2", Keys: []common.Hotkey{{Key: tcell.KeyF2}}, MenuKey: lang.PageSearch, Text: lang.Titles[lang.PageSearch], Positive: false, IsDef: true, Function: func() error { return showSearch(s) }},
+		conf.CmdList:      {Shortcut: "F3", Keys: []common.Hotkey{{Key: tcell.KeyF3}}, MenuKey: lang.PageList, Text: lang.Titles[lang.PageList], Positive: false, IsDef: true, Function: func() error { return showList(s) }},
+		conf.CmdApprovals: {Shortcut: "F4", Keys: []common.Hotkey{{Key: tcell.KeyF4}}, MenuKey: lang.PageApprovals, Text: lang.Titles[lang.PageApprovals], Positive: false, IsDef: true, Function: func() error { return showApprovals(s) }},
+		conf.CmdExpiring:  {Shortcut: "F10", Keys: []common.Hotkey{{Key: tcell.KeyF10}}, MenuKey: lang.PageExpiring, Text: lang.Titles[lang.PageExpiring], Positive: false, IsDef: true, Function: func() error { return showExpiring(s) }},
+		conf.CmdSave:      {Shortcut: "F6", Keys: []common.Hotkey{{Key: tcell.KeyF6}}, MenuKey: "", Text: lang.MenuSave, Positive: true, IsDef: true, Function: func() error { return save(s, window.ShowErrorBuffer) }},
+		conf.CmdRole:      {Shortcut: "F7", Keys: []common.Hotkey{{Key: tcell.KeyF7}}, MenuKey: "", Text: lang.MenuRole, Positive: true, IsDef: true, Function: func() error { return frontend.ShowRoles(s) }},
+		conf.CmdSelect:    {Shortcut: "Insert", Keys: []common.Hotkey{{Key: tcell.KeyInsert}}, MenuKey: "", Text: lang.MenuSelect, Positive: false, IsDef: true, Function: func() error { return frontend.ToggleSelected(s) }},
+		conf.CmdExtend:    {Shortcut: "F11", Keys: []common.Hotkey{{Key: tcell.KeyF11}}, MenuKey: "", Text: lang.MenuExtend, Positive: true, IsDef: true, Function: func() error { return frontend.ShowExtend(s) }},
+		conf.CmdPalette:   {Shortcut: "Ctrl-K", Keys: []common.Hotkey{{Key: tcell.KeyCtrlK}}, MenuKey: "", Text: lang.MenuPalette, Positive: false, IsDef: true, Function: func() error { return frontend.ShowPalette() }},
+		conf.CmdQuit:      {Shortcut: "Esc", Keys: []common.Hotkey{{Key: tcell.KeyEsc}}, MenuKey: "", Text: lang.MenuQuit, Positive: false, IsDef: true, Function: func() error { return window.Quit(s) }},
+
+		conf.ActFocusSearch: {Shortcut: "Ctrl-F", Keys: []common.Hotkey{{Key: tcell.KeyCtrlF}}, Text: lang.ActFocusSearch, IsDef: true, Function: func() error { return frontend.FocusSearch(s) }},
+		conf.ActNextRow:     {Shortcut: "Ctrl-N", Keys: []common.Hotkey{{Key: tcell.KeyCtrlN}}, Text: lang.ActNextRow, IsDef: true, Function: func() error { return frontend.MoveRow(s, 1) }},
+		conf.ActPrevRow:     {Shortcut: "Ctrl-P", Keys: []common.Hotkey{{Key: tcell.KeyCtrlP}}, Text: lang.ActPrevRow, IsDef: true, Function: func() error { return frontend.MoveRow(s, -1) }},
+		conf.ActToggleClaim: {Shortcut: "Ctrl-T", Keys: []common.Hotkey{{Key: tcell.KeyCtrlT}}, Text: lang.ActToggleClaim, IsDef: true, Function: func() error { return frontend.ToggleClaim(s) }},
+		conf.ActOpenDetail:  {Shortcut: "Ctrl-O", Keys: []common.Hotkey{{Key: tcell.KeyCtrlO}}, Text: lang.ActOpenDetail, IsDef: true, Function: func() error { return frontend.OpenDetail(s) }},
+		conf.ActExport:      {Text: lang.ActExport, IsDef: true, Function: func() error { return export(s) }},
 	}
 }
 
-func showList() error {
-	return frontend.ShowPage(lang.PageList)
+func showList(s *global.Session) error {
+	return frontend.ShowPage(s, lang.PageList)
+}
+
+func showSearch(s *global.Session) error {
+	return frontend.ShowPage(s, lang.PageSearch)
 }
 
-func showSearch() error {
-	return frontend.ShowPage(lang.PageSearch)
+// showApprovals shows the change sets waiting for approval, downloading them every time in the
+// background.
+func showApprovals(s *global.Session) error {
+	if err := frontend.ShowPage(s, lang.PageApprovals); err != nil {
+		return err
+	}
+
+	return firebase.LoadPending(s, func(err error) {
+		if err != nil {
+			window.ShowErrorBuffer(err)
+			return
+		}
+
+		common.Fe.LayoutApprovals()
+	})
+}
+
+// showExpiring shows the timed permissions of the privileged users, downloading them the first time.
+func showExpiring(s *global.Session) error {
+	if err := frontend.ShowPage(s, lang.PageExpiring); err != nil {
+		return err
+	}
+
+	common.Fe.LayoutExpiring()
+	return nil
 }
 
 // cancel clears unsaved permission changes.
-func cancel() error {
-	if len(global.Actions) == 0 {
+func cancel(s *global.Session) error {
+	if len(s.Actions) == 0 {
 		return ErrNoChanges
 	}
-	global.Actions = map[string]common.ClaimsMap{}
+	s.Actions = map[string]common.ClaimsMap{}
 	common.Fe.LayoutUsers()
 	return nil
 }
 
-// refresh refreshes GUI and firestore cache from iterating all firebase auth users.
-func refresh() error {
+// refresh refreshes GUI and firestore cache from iterating all firebase auth users in the background.
+// Errors of the checks are returned, otherwise done is called with the result.
+func refresh(s *global.Session, done func(error)) error {
 	if common.Fe.CurrentPage() != lang.PageList {
 		return ErrCantRefresh
 	}
-	if len(global.Actions) > 0 {
+	if len(s.Actions) > 0 {
 		return ErrActions
 	}
 
-	// Run refresh operation asynchronously so UI can redraw the progress popup
-	if err := firebase.DoRefresh(); err != nil {
-		return fmt.Errorf(lang.ErrRefresh, err)
-	}
+	ws := firebase.DrySession(s)
+	firebase.DoRefresh(ws, func(err error) {
+		writeDryRun()
+		if err != nil {
+			done(fmt.Errorf(lang.ErrRefresh, err))
+			return
+		}
 
-	if len(global.CrntUsers) == 0 {
-		window.ShowErrorBuffer(common.ErrNoUsers)
-	}
+		if len(ws.CrntUsers) == 0 {
+			done(common.ErrNoUsers)
+			return
+		}
+
+		done(nil)
+	})
 
 	return nil
 }
 
-// save saves user changes if any.
-func save() error {
-	if len(global.Actions) == 0 {
+// save saves user changes if any. Errors of the checks are returned, otherwise done is called exactly
+// once, after the background save or when the user backs out of a confirmation.
+func save(s *global.Session, done func(error)) error {
+	if len(s.Actions) == 0 {
 		return ErrNoChanges
 	}
 
-	// Run save operation asynchronously so UI can redraw the progress popup
-	if err := firebase.DoSave(); err != nil {
-		return fmt.Errorf(lang.ErrSave, err)
+	if conflicts := firebase.Conflicts(s); len(conflicts) > 0 {
+		window.UseConfirm()
+		window.WriteErrorStr(fmt.Sprintf(lang.WarnConflictS, strings.Join(conflicts, ", ")))
+		window.WriteErrorStr(lang.ConfirmReloadS)
+		window.ShowConfirm(func() {
+			if err := reload(s, done); err != nil {
+				done(err)
+			}
+		}, func() {
+			if err := checkSave(s, done); err != nil {
+				done(err)
+			}
+		})
+		return nil
+	}
+
+	return checkSave(s, done)
+}
+
+// reload downloads users changed by others in the background, so the pending actions can be
+// reviewed before saving. Errors of starting are returned, otherwise done is called with the result.
+func reload(s *global.Session, done func(error)) error {
+	return firebase.Reload(s, func(err error) {
+		if err == nil {
+			common.Fe.LayoutUsers()
+		}
+		done(err)
+	})
+}
+
+// checkSave saves pending actions if they don't break permission rules, or the user confirms it.
+func checkSave(s *global.Session, done func(error)) error {
+	if firebase.WriteViolations(s) {
+		window.WriteErrorStr(lang.ConfirmRulesS)
+		window.ShowConfirm(func() {
+			if err := doSave(s, done); err != nil {
+				done(err)
+			}
+		}, func() { done(nil) })
+		return nil
+	}
+
+	return doSave(s, done)
+}
+
+// doSave saves pending actions without any further checks in the background, or proposes them if
+// they need approval.
+func doSave(s *global.Session, done func(error)) error {
+	ws := firebase.DrySession(s)
+	if conf.NeedsApproval(s.Actions) {
+		return propose(ws, done)
 	}
 
-	common.Fe.ShowMsg(lang.SSaved)
+	firebase.DoSave(ws, func(err error) {
+		dry := writeDryRun()
+		if err != nil {
+			done(fmt.Errorf(lang.ErrSave, err))
+			return
+		}
+
+		if !dry {
+			common.Fe.ShowMsg(lang.SSaved)
+		}
+		done(nil)
+	})
 
 	return nil
 }
+
+// propose stores pending actions as a change set waiting for approval in the background.
+func propose(s *global.Session, done func(error)) error {
+	err := firebase.Propose(s, func(err error) {
+		dry := writeDryRun()
+		if err != nil {
+			done(fmt.Errorf(lang.ErrPropose, err))
+			return
+		}
+
+		common.Fe.LayoutUsers()
+		if !dry {
+			common.Fe.ShowMsg(lang.SProposed)
+		}
+		done(nil)
+	})
+	if err != nil {
+		return fmt.Errorf(lang.ErrPropose, err)
+	}
+
+	return nil
+}
+
+// toggleDryRun turns dry-run mode on or off. In dry-run mode writes are reported instead of executed.
+func toggleDryRun() error {
+	firebase.SetDryRun(!firebase.IsDryRun())
+	common.Fe.UpdateHeader()
+	return nil
+}
+
+// writeDryRun adds the captured writes to the error buffer in dry-run mode, and returns if it's on.
+func writeDryRun() bool {
+	if !firebase.IsDryRun() {
+		return false
+	}
+
+	window.WriteErrorStr(firebase.DryRunReport())
+	return true
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
 	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
 	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/lang"
 	"github.com/vendelin8/firemage/internal/mock"
@@ -14,6 +21,7 @@ import (
 )
 
 func TestCancel(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
@@ -45,15 +53,15 @@ func TestCancel(t *testing.T) {
 			mockFe := mock.NewMockFeIf(ctrl)
 			common.Fe = mockFe
 
-			global.Actions = testutil.BuildActionsMap(tt.setupActions)
+			s.Actions = testutil.BuildActionsMap(tt.setupActions)
 
 			if tt.wantError == nil {
 				mockFe.EXPECT().LayoutUsers().Times(1)
 			}
 
-			err := cancel()
+			err := cancel(s)
 			assert.Equal(t, tt.wantError, err)
-			assert.Equal(t, 0, len(global.Actions))
+			assert.Equal(t, 0, len(s.Actions))
 		})
 	}
 }
@@ -62,12 +70,16 @@ func TestRefresh(t *testing.T) {
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
+	conf.RefreshTimeout = time.Minute
+	conf.CheckpointPath = filepath.Join(t.TempDir(), "refresh.json")
+
 	tests := []struct {
 		name            string
 		setupUsers      []string
 		setupActions    map[string]map[string]any
 		wantCurrentPage string
 		wantError       error
+		wantDone        error
 	}{
 		{
 			name:            "refresh on list page with users and no actions",
@@ -92,6 +104,7 @@ func TestRefresh(t *testing.T) {
 			name:            "refresh with no users",
 			wantCurrentPage: lang.PageList,
 			wantError:       nil,
+			wantDone:        common.ErrNoUsers,
 		},
 	}
 
@@ -106,27 +119,57 @@ func TestRefresh(t *testing.T) {
 			mockFb := mock.NewMockFbIf(ctrl)
 			common.Fb = mockFb
 
-			mockFe.EXPECT().CurrentPage().Return(tt.wantCurrentPage).Times(1)
+			s := global.NewSession()
+			for _, uid := range tt.setupUsers {
+				claims := *common.NewClaimsMap()
+				claims[common.Admin] = &common.Claim{Checked: true}
+				s.LocalUsers[uid] = &global.User{UID: uid, Email: uid + "@example.com", Claims: claims}
+			}
+			s.Actions = testutil.BuildActionsMap(tt.setupActions)
 
 			if tt.wantError == nil {
-				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).Return(nil).Times(1)
-
-				if len(tt.setupUsers) == 0 {
-					mockFe.EXPECT().ShowMsg(gomock.Any()).Times(1)
+				mockFe.EXPECT().CurrentPage().Return(tt.wantCurrentPage).Times(2)
+				mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+				mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(2)
+				mockFe.EXPECT().SetProgress(gomock.Any()).Times(1)
+				mockFe.EXPECT().LayoutUsers().Times(1)
+				mockFb.EXPECT().ProjectID().Return("demo-test").Times(1)
+				privileged := map[string]any{}
+				for _, uid := range tt.setupUsers {
+					privileged[uid] = uid + "@example.com"
 				}
+				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+						return cb(nil, privileged)
+					}).Times(1)
+				mockFb.EXPECT().IterUsers(gomock.Any(), "", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ string, cb func(*auth.UserRecord) error, onPage func(string) error) error {
+						for _, uid := range tt.setupUsers {
+							if err := cb(&auth.UserRecord{UserInfo: &auth.UserInfo{UID: uid, Email: uid + "@example.com"},
+								CustomClaims: map[string]any{common.Admin: true}}); err != nil {
+								return err
+							}
+						}
+						return onPage("")
+					}).Times(1)
+			} else {
+				mockFe.EXPECT().CurrentPage().Return(tt.wantCurrentPage).Times(1)
 			}
 
-			global.CrntUsers = tt.setupUsers
-			global.Actions = testutil.BuildActionsMap(tt.setupActions)
-
-			err := refresh()
+			done := make(chan error, 1)
+			err := refresh(s, func(err error) { done <- err })
 			assert.Equal(t, tt.wantError, err)
-			global.Actions = map[string]common.ClaimsMap{}
+
+			if tt.wantError == nil {
+				assert.Equal(t, tt.wantDone, <-done)
+				assert.ElementsMatch(t, tt.setupUsers, s.CrntUsers)
+			}
 		})
 	}
 }
 
 func TestSave(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
@@ -163,6 +206,8 @@ func TestSave(t *testing.T) {
 
 			if !tt.wantError {
 				mockFe.EXPECT().ShowMsg(gomock.Any()).Times(1)
+				mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+				mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
 				// Mock RunTransaction for successful save
 				mockFb.EXPECT().
 					RunTransaction(gomock.Any(), gomock.Any()).
@@ -170,22 +215,109 @@ func TestSave(t *testing.T) {
 					Times(1)
 			}
 
-			global.Actions = testutil.BuildActionsMap(tt.setupActions)
-			err := save()
+			s.Actions = testutil.BuildActionsMap(tt.setupActions)
+			done := make(chan error, 1)
+			err := save(s, func(err error) { done <- err })
 
 			if tt.wantError {
 				assert.Error(t, err)
 				assert.Equal(t, ErrNoChanges, err)
 			} else {
 				assert.NoError(t, err)
+				assert.NoError(t, <-done)
+				assert.Empty(t, s.Actions)
+			}
+		})
+	}
+}
+
+func TestSaveViolations(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	tests := []struct {
+		name     string
+		override bool
+	}{
+		{name: "violations block save"},
+		{name: "violations overridden", override: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+
+			s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()}}
+			s.Actions = testutil.BuildActionsMap(map[string]map[string]any{"uid1": {common.SuperAdmin: true}})
+
+			var msg string
+			mockFe.EXPECT().ShowConfirm(gomock.Any(), gomock.Any()).DoAndReturn(func(onYes, onNo func(), _ ...string) {
+				msg = window.PopBuffer()
+				window.ConfirmDoneFunc(onYes, onNo)(0, map[bool]string{true: lang.SYes, false: lang.SNo}[tt.override])
+			}).Times(1)
+			mockFe.EXPECT().HidePopup(lang.PopupConfirm).Times(1)
+
+			if tt.override {
+				mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+				mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockFe.EXPECT().ShowMsg(lang.SSaved).Times(1)
 			}
 
-			global.Actions = map[string]common.ClaimsMap{}
+			done := make(chan error, 1)
+			assert.NoError(t, save(s, func(err error) { done <- err }))
+			assert.NoError(t, <-done)
+			assert.Equal(t, "Permission rules are violated for user1@example.com:\n"+
+				"- SuperAdmin can be granted only together with Admin\n"+
+				lang.ConfirmRulesS, msg)
 		})
 	}
 }
 
+func TestSaveApproval(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	conf.ApprovalPerms = []string{common.SuperAdmin}
+	conf.Operator, conf.OperatorSource, conf.OperatorPerms = "alice", conf.OperatorsConfig, map[string][]string{"alice": nil}
+	saved := *common.NewClaimsMap()
+	saved[common.Admin] = &common.Claim{Checked: true}
+	s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: saved}}
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.SuperAdmin: {Checked: true}}}
+
+	mockFb.EXPECT().AddPending(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+	mockFe.EXPECT().LayoutUsers().Times(1)
+	mockFe.EXPECT().ShowMsg(lang.SProposed).Times(1)
+
+	done := make(chan error, 1)
+	assert.NoError(t, save(s, func(err error) { done <- err }))
+	assert.NoError(t, <-done)
+	assert.Empty(t, s.Actions)
+
+	conf.ApprovalPerms = nil
+	conf.Operator, conf.OperatorSource, conf.OperatorPerms = "", "", nil
+}
+
 func TestShowListAndSearch(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
@@ -262,10 +394,10 @@ func TestShowListAndSearch(t *testing.T) {
 				tt.setup(mockFb)
 			}
 
-			global.CrntUsers = tt.crntUsers
-			global.SavedUsers = tt.savedUsers
-			global.LocalPrivileged = make(map[string]struct{})
-			global.LocalUsers = make(map[string]*global.User)
+			s.CrntUsers = tt.crntUsers
+			s.SavedUsers = tt.savedUsers
+			s.LocalPrivileged = make(map[string]struct{})
+			s.LocalUsers = make(map[string]*global.User)
 
 			// LayoutUsers should only be called on success
 			if tt.wantError == nil {
@@ -274,9 +406,9 @@ func TestShowListAndSearch(t *testing.T) {
 
 			var err error
 			if tt.page == lang.PageList {
-				err = showList()
+				err = showList(s)
 			} else {
-				err = showSearch()
+				err = showSearch(s)
 			}
 
 			assert.ErrorIs(t, err, tt.wantError)
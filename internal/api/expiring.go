@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/util"
+)
+
+// expiringJSON is a timed permission in the JSON output of Expiring.
+type expiringJSON struct {
+	UID        string `json:"uid"`
+	Email      string `json:"email"`
+	Name       string `json:"name,omitempty"`
+	Permission string `json:"permission"`
+	Expires    string `json:"expires"`
+	Expired    bool   `json:"expired"`
+}
+
+// Expiring downloads the privileged users, and writes their timed permissions expiring within the
+// given duration from now to w, the expired ones included. It's JSON, or a tab separated line for each
+// permission with the expiry, the time left, the email address and the permission.
+func Expiring(s *global.Session, within string, asJSON bool, w io.Writer) error {
+	now := time.Now()
+	limit, err := util.AddTimed(now, within)
+	if err != nil {
+		return common.Classify(common.ExitUsage, err)
+	}
+
+	if err = firebase.ListPrivileged(s); err != nil {
+		return err
+	}
+
+	var tcs []util.TimedClaim
+	for _, tc := range util.TimedClaims(s) {
+		if tc.Date.After(limit) {
+			break // sorted by expiry
+		}
+		tcs = append(tcs, tc)
+	}
+
+	if asJSON {
+		out := make([]expiringJSON, len(tcs))
+		for i, tc := range tcs {
+			out[i] = expiringJSON{UID: tc.UID, Email: tc.Email, Name: tc.Name, Permission: tc.Perm,
+				Expires: tc.Date.Format(time.RFC3339), Expired: !tc.Date.After(now)}
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	for _, tc := range tcs {
+		c := common.Claim{Date: &tc.Date}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.FormatDate(), c.Remaining(now), tc.Email, common.PermsMap[tc.Perm])
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestExpiring(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	today := common.EndOfToday()
+	soon, later := today.AddDate(0, 0, 3), today.AddDate(0, 2, 0)
+	expired := today.AddDate(0, 0, -2)
+	users := []*auth.UserRecord{
+		{
+			UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"},
+			CustomClaims: map[string]any{
+				common.Admin:      true,
+				common.Consultant: soon.Format(common.StoreDateFormat),
+			},
+		},
+		{
+			UserInfo: &auth.UserInfo{UID: "uid2", Email: "user2@example.com", DisplayName: "User Two"},
+			CustomClaims: map[string]any{
+				common.Consultant: expired.Format(common.StoreDateFormat),
+				common.SuperAdmin: later.Format(common.StoreDateFormat),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		within   string
+		asJSON   bool
+		want     string
+		wantJSON []expiringJSON
+		wantExit int
+	}{
+		{
+			name:   "plain",
+			within: "1w",
+			want: fmt.Sprintf("%s\texpired\tuser2@example.com\tConsultant\n%s\t3d\tuser1@example.com\tConsultant\n",
+				expired.Format(common.DateFormat), soon.Format(common.DateFormat)),
+		},
+		{
+			name:   "json",
+			within: "3m",
+			asJSON: true,
+			wantJSON: []expiringJSON{
+				{UID: "uid2", Email: "user2@example.com", Name: "User Two", Permission: common.Consultant,
+					Expires: expired.Format(time.RFC3339), Expired: true},
+				{UID: "uid1", Email: "user1@example.com", Permission: common.Consultant,
+					Expires: soon.Format(time.RFC3339)},
+				{UID: "uid2", Email: "user2@example.com", Name: "User Two", Permission: common.SuperAdmin,
+					Expires: later.Format(time.RFC3339)},
+			},
+		},
+		{
+			name:     "invalid duration",
+			within:   "2x",
+			wantExit: common.ExitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+
+			if tt.wantExit == 0 {
+				mockFb.EXPECT().GetSpecs(gomock.Any()).
+					Return(map[string]any{"uid1": true, "uid2": true}, nil).Times(1)
+				mockFb.EXPECT().GetUsers(gomock.Any(), gomock.Any()).
+					Return(&auth.GetUsersResult{Users: users}, nil).Times(1)
+				mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+				mockFe.EXPECT().SetProgress(gomock.Any()).Times(1)
+			}
+
+			var out bytes.Buffer
+			err := Expiring(global.NewSession(), tt.within, tt.asJSON, &out)
+
+			if tt.wantExit != 0 {
+				assert.Equal(t, tt.wantExit, common.ExitCode(err))
+				assert.Empty(t, out.String())
+				return
+			}
+
+			assert.NoError(t, err)
+			if !tt.asJSON {
+				assert.Equal(t, tt.want, out.String())
+				return
+			}
+
+			var got []expiringJSON
+			assert.NoError(t, json.Unmarshal(out.Bytes(), &got))
+			assert.Equal(t, tt.wantJSON, got)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// exportPattern is the name of the file users are exported to, with the time of the export.
+const exportPattern = "firemage-users-%s.json"
+
+// userJSON is a user in the JSON export of Export.
+type userJSON struct {
+	UID    string         `json:"uid"`
+	Email  string         `json:"email"`
+	Name   string         `json:"name,omitempty"`
+	Claims map[string]any `json:"claims"`
+}
+
+// export asks for a confirmation to write the users shown on the current page with their saved
+// permissions, as stored in Firebase auth, to a JSON file in the working directory. Unsaved changes
+// aren't included.
+func export(s *global.Session) error {
+	if len(s.CrntUsers) == 0 {
+		return common.ErrNoUsers
+	}
+
+	path, err := filepath.Abs(fmt.Sprintf(exportPattern, time.Now().Format("20060102-150405")))
+	if err != nil {
+		return fmt.Errorf(lang.ErrExport, err)
+	}
+
+	window.ShowConfirm(func() {
+		if err := writeExport(s, path); err != nil {
+			window.ShowErrorBuffer(err)
+		}
+	}, nil, fmt.Sprintf(lang.ConfirmExportS, len(s.CrntUsers), path))
+	return nil
+}
+
+// writeExport writes the users of the current page to the given path.
+func writeExport(s *global.Session, path string) error {
+	out := make([]userJSON, 0, len(s.CrntUsers))
+	for _, uid := range s.CrntUsers {
+		u, ok := s.LocalUsers[uid]
+		if !ok {
+			continue
+		}
+
+		claims := map[string]any{}
+		for _, perm := range common.AllPerms {
+			if c := u.Claims[perm]; c != nil && (c.Checked || c.Date != nil) {
+				claims[perm] = c.ToAny()
+			}
+		}
+		out = append(out, userJSON{UID: uid, Email: u.Email, Name: u.Name, Claims: claims})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0o600)
+	}
+	if err != nil {
+		return fmt.Errorf(lang.ErrExport, err)
+	}
+
+	common.Fe.ShowMsg(fmt.Sprintf(lang.SExported, len(out), path))
+	return nil
+}
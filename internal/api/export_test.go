@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestExport(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	soon := common.EndOfToday().AddDate(0, 0, 3)
+
+	tests := []struct {
+		name      string
+		crntUsers []string
+		confirm   bool
+		want      []userJSON
+		wantErr   error
+	}{
+		{
+			name:    "no users",
+			wantErr: common.ErrNoUsers,
+		},
+		{
+			name:      "not confirmed",
+			crntUsers: []string{"uid1"},
+		},
+		{
+			name:      "granted claims of the current page",
+			crntUsers: []string{"uid1", "uid2"},
+			confirm:   true,
+			want: []userJSON{
+				{UID: "uid1", Email: "user1@example.com", Claims: map[string]any{
+					common.Consultant: soon.Format(common.StoreDateFormat),
+					common.Admin:      true,
+				}},
+				{UID: "uid2", Email: "user2@example.com", Name: "User Two", Claims: map[string]any{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			t.Chdir(t.TempDir())
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+
+			s := global.NewSession()
+			s.CrntUsers = tt.crntUsers
+			s.LocalUsers = map[string]*global.User{
+				"uid1": {UID: "uid1", Email: "user1@example.com", Claims: common.ClaimsMap{
+					common.Consultant: {Date: &soon},
+					common.Admin:      {Checked: true},
+					common.SuperAdmin: {},
+				}},
+				"uid2": {UID: "uid2", Email: "user2@example.com", Name: "User Two", Claims: common.ClaimsMap{}},
+			}
+
+			var question, msg string
+			if tt.wantErr == nil {
+				mockFe.EXPECT().ShowConfirm(gomock.Any(), gomock.Any(), gomock.Any()).
+					Do(func(onYes, _ func(), ms ...string) {
+						question = ms[0]
+						if tt.confirm {
+							onYes()
+						}
+					}).Times(1)
+			}
+			if tt.confirm {
+				mockFe.EXPECT().ShowMsg(gomock.Any()).Do(func(args ...string) { msg = args[0] }).Times(1)
+			}
+
+			err := export(s)
+			window.SetPopups()
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				return
+			}
+
+			paths, _ := filepath.Glob(fmt.Sprintf(exportPattern, "*"))
+			if !tt.confirm {
+				assert.Empty(t, paths, "nothing is written without confirmation")
+				return
+			}
+			if !assert.Len(t, paths, 1) {
+				return
+			}
+			path, _ := filepath.Abs(paths[0])
+			assert.Equal(t, fmt.Sprintf(lang.ConfirmExportS, len(tt.want), path), question)
+			assert.Equal(t, fmt.Sprintf(lang.SExported, len(tt.want), path), msg)
+
+			data, err := os.ReadFile(paths[0])
+			assert.NoError(t, err)
+			var got []userJSON
+			assert.NoError(t, json.Unmarshal(data, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
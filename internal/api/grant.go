@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+)
+
+// Grant applies a role template to the given users identified by email address or uid, and saves it.
+// A non-empty duration like "12h" overrides the ones of the role, making all its permissions timed.
+// It waits for the background save to finish.
+func Grant(s *global.Session, roleName, duration string, users []string) error {
+	role, ok := common.Roles[roleName]
+	if !ok {
+		return fmt.Errorf(lang.ErrRoleNotFound, roleName)
+	}
+
+	if len(duration) > 0 {
+		timed := make(common.Role, len(role))
+		for perm := range role {
+			timed[perm] = duration
+		}
+		role = timed
+	}
+
+	claims, err := util.RoleClaims(role, time.Now())
+	if err != nil { // the durations of roles are checked when loading them
+		return common.Classify(common.ExitUsage, err)
+	}
+
+	uids, err := fetchUsers(s, users)
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		for key, c := range claims {
+			util.StageClaim(s, uid, key, *c)
+		}
+	}
+
+	if len(s.Actions) == 0 {
+		return ErrNoChanges
+	}
+
+	errc := make(chan error, 1)
+	if err := save(s, func(err error) { errc <- err }); err != nil {
+		return err
+	}
+
+	err = <-errc
+	if firebase.IsDryRun() {
+		common.Fe.ShowMsg(window.GetErrorStr())
+	}
+
+	return err
+}
+
+// fetchUsers downloads the given users in the background, and waits for it.
+func fetchUsers(s *global.Session, users []string) ([]string, error) {
+	var uids []string
+	errc := make(chan error, 1)
+	if err := firebase.FetchUsers(s, users, func(fetched []string, err error) {
+		uids = fetched
+		errc <- err
+	}); err != nil {
+		return nil, err
+	}
+
+	return uids, <-errc
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"testing"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestGrant(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	user := &auth.UserRecord{
+		UserInfo:     &auth.UserInfo{UID: "uid1", Email: "user1@example.com"},
+		CustomClaims: map[string]any{common.Admin: true},
+	}
+
+	tests := []struct {
+		name      string
+		role      string
+		duration  string
+		found     []*auth.UserRecord
+		notFound  []auth.UserIdentifier
+		timestamp bool
+		wantSave  bool
+		wantError error
+		wantMsg   string
+	}{
+		{
+			name:    "unknown role",
+			role:    "janitor",
+			wantMsg: "role template not found: janitor",
+		},
+		{
+			name:      "user not found",
+			role:      "auditor",
+			notFound:  []auth.UserIdentifier{auth.EmailIdentifier{Email: "user1@example.com"}},
+			wantError: common.ErrNoUsers,
+		},
+		{
+			name:      "role already granted",
+			role:      "admin",
+			found:     []*auth.UserRecord{user},
+			wantError: ErrNoChanges,
+		},
+		{
+			name:     "role granted and saved",
+			role:     "auditor",
+			found:    []*auth.UserRecord{user},
+			wantSave: true,
+		},
+		{
+			name:      "permanent role granted for a duration",
+			role:      "admin",
+			duration:  "1w12h",
+			found:     []*auth.UserRecord{user},
+			wantSave:  true,
+			timestamp: true,
+		},
+		{
+			name:     "hours stored as a day",
+			role:     "admin",
+			duration: "12h",
+			wantMsg:  lang.ErrTimeHours,
+		},
+		{
+			name:     "invalid duration",
+			role:     "admin",
+			duration: "1x",
+			wantMsg:  "invalid unit: x (expected 'h', 'd', 'w', 'm', or 'y')",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+
+			common.Roles = map[string]common.Role{
+				"admin":   {common.Admin: ""},
+				"auditor": {common.Admin: "", common.Consultant: "3m"},
+			}
+			s.LocalUsers = map[string]*global.User{}
+			s.Actions = map[string]common.ClaimsMap{}
+			common.StoreTimestamps = tt.timestamp
+			defer func() { common.StoreTimestamps = false }()
+
+			jobs := 0
+			if _, ok := common.Roles[tt.role]; ok && len(tt.wantMsg) == 0 {
+				jobs++
+				mockFb.EXPECT().GetUsers(gomock.Any(), []auth.UserIdentifier{auth.EmailIdentifier{Email: "user1@example.com"}}).
+					Return(&auth.GetUsersResult{Users: tt.found, NotFound: tt.notFound}, nil).Times(1)
+			}
+
+			if len(tt.notFound) > 0 {
+				mockFe.EXPECT().ShowMsg().Times(1)
+			}
+
+			if tt.wantSave {
+				jobs++
+				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockFe.EXPECT().ShowMsg(lang.SSaved).Times(1)
+			}
+			mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(jobs)
+			mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(jobs)
+
+			err := Grant(s, tt.role, tt.duration, []string{"user1@example.com"})
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+			} else {
+				assert.ErrorIs(t, err, tt.wantError)
+			}
+
+			common.Roles = map[string]common.Role{}
+			window.SetPopups()
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// Repair finishes the permission writes of an interrupted save, and reports the result of every user.
+// The writes still missing are saved again like any other change, so the operator needs to be allowed
+// to grant them, broken rules need a confirmation, and the ones needing approval are proposed. It
+// waits for the background save to finish.
+func Repair(s *global.Session) error {
+	err := firebase.Repair(s, func(u firebase.UserStatus) { common.Fe.ShowMsg(u.String()) })
+	if err != nil && !errors.Is(err, firebase.ErrUsersFailed) {
+		return err
+	}
+
+	if len(s.Actions) > 0 {
+		staged := slices.Collect(maps.Keys(s.Actions))
+		errc := make(chan error, 1)
+		if err := save(s, func(err error) { errc <- err }); err != nil {
+			return err
+		}
+
+		errSave := <-errc
+		if firebase.IsDryRun() {
+			common.Fe.ShowMsg(window.GetErrorStr())
+		}
+		if errSave != nil {
+			return errSave
+		}
+
+		// saved ones are removed by the save, proposed ones are left to the approval
+		if err := firebase.ForgetJournal(slices.DeleteFunc(staged, func(uid string) bool {
+			_, ok := s.Actions[uid]
+			return ok
+		})); err != nil {
+			return err
+		}
+
+		if len(s.Actions) > 0 { // not confirmed
+			return firebase.ErrUsersFailed
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	common.Fe.ShowMsg(lang.SRepaired)
+	return nil
+}
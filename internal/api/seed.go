@@ -0,0 +1,25 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// Seed creates the users of the given fixtures file in the emulators.
+func Seed(path string) error {
+	n, err := firebase.Seed(path)
+	if err != nil {
+		return err
+	}
+
+	if firebase.IsDryRun() {
+		common.Fe.ShowMsg(firebase.DryRunReport())
+		return nil
+	}
+
+	common.Fe.ShowMsg(fmt.Sprintf(lang.SSeeded, n))
+	return nil
+}
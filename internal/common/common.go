@@ -1,21 +1,64 @@
 package common
 
 import (
-	"errors"
+	"cmp"
 	"fmt"
 	"maps"
 	"strings"
 	"time"
 
-	"github.com/gdamore/tcell/v2"
 	"github.com/vendelin8/firemage/internal/lang"
 )
 
 const d1 = time.Hour * 24
 
+// StoreDateFormat is the layout of the dates stored in claims. It's ISO, and must stay the same for
+// the apps reading the claims.
+const StoreDateFormat = time.DateOnly
+
+// TimeFormat is the layout of the time shown and typed in after DateFormat for expiries not at the
+// end of the day.
+const TimeFormat = "15:04"
+
+// BuiltDateFormat is the DateFormat set in custom.go, kept as DateFormat is replaced by the layout
+// of the dates shown and typed in when the config is loaded.
+var BuiltDateFormat = DateFormat
+
 var (
-	ErrNoUsers      = errors.New(lang.ErrNoUsersS)
-	ErrWrongDBClaim = errors.New(lang.ErrWrongDBClaimS)
+	// ExpiryLocation is the timezone of the days timed claims expire at the end of, from the config
+	// file. Date-only claims expire at the end of their day in it.
+	ExpiryLocation = time.UTC
+
+	// StoreTimestamps stores the expiry of timed claims as RFC3339 timestamps instead of days.
+	StoreTimestamps bool
+)
+
+// ExpiryOf returns when claims of the given day expire: its last second in ExpiryLocation. The day
+// is taken in the location of the given time.
+func ExpiryOf(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 59, 0, ExpiryLocation)
+}
+
+// EndOfToday returns when claims expiring today expire.
+func EndOfToday() time.Time {
+	return ExpiryOf(time.Now().In(ExpiryLocation))
+}
+
+// FormatExpiry returns the day of the given expiry in DateFormat, with the time if it's not the end
+// of the day.
+func FormatExpiry(date time.Time) string {
+	date = date.In(ExpiryLocation)
+	if date.Equal(ExpiryOf(date)) {
+		return date.Format(DateFormat)
+	}
+
+	return date.Format(DateFormat + " " + TimeFormat)
+}
+
+var (
+	ErrNoUsers      = lang.NewError(&lang.ErrNoUsersS)
+	ErrActions      = lang.NewError(&lang.ErrActionsS)
+	ErrWrongDBClaim = lang.NewError(&lang.ErrWrongDBClaimS)
 )
 
 var (
@@ -25,12 +68,21 @@ var (
 	Fb FbIf
 
 	MenuItems map[int]MenuItem
-	Shortcuts = make(map[tcell.Key]int)
+	Shortcuts = make(map[Hotkey]int)
+
+	// ScopedShortcuts has the shortcuts working only on a page or in a popup by its identifier,
+	// taking precedence over Shortcuts there.
+	ScopedShortcuts = map[string]map[Hotkey]int{}
+
+	// Roles has the role templates from the config file by their names.
+	Roles = map[string]Role{}
 
 	defaultClaims = ClaimsMap{}
 )
 
 func init() {
+	DateFormat = cmp.Or(DateFormat, StoreDateFormat) // until the config is loaded
+
 	for _, perm := range AllPerms {
 		defaultClaims[perm] = &Claim{}
 	}
@@ -39,7 +91,7 @@ func init() {
 // MenuItem defines default menu items' structure.
 type MenuItem struct {
 	Shortcut string
-	Keys     []tcell.Key
+	Keys     []Hotkey
 	MenuKey  string
 	Text     string
 	Positive bool
@@ -47,6 +99,38 @@ type MenuItem struct {
 	Function func() error
 }
 
+// Role is a named template of permissions to grant at once. Keys are permissions, values are
+// durations in TimedButtons format, eg. "3m", or empty for a permanent permission.
+type Role map[string]string
+
+// Rule kinds restricting permission combinations, see Rules.
+const (
+	RuleRequires = iota
+	RuleExcludes
+	RuleMaxDuration
+	RuleNotPermanent
+)
+
+// Rule restricts the granting of Perm. Other is the related permission of RuleRequires and
+// RuleExcludes, Value is the longest duration in TimedButtons format for RuleMaxDuration.
+type Rule struct {
+	Kind  int
+	Perm  string
+	Other string
+	Value string
+}
+
+// PendingChange is a change set of an operator waiting for the approval of another one. Before and
+// After have the claims of the changed permissions by uid, in the format stored in Firebase auth.
+type PendingChange struct {
+	ID       string                    `firestore:"-"`
+	Operator string                    `firestore:"operator"`
+	Created  time.Time                 `firestore:"created"`
+	Emails   map[string]string         `firestore:"emails"`
+	Before   map[string]map[string]any `firestore:"before"`
+	After    map[string]map[string]any `firestore:"after"`
+}
+
 type Claim struct {
 	Checked bool
 	Date    *time.Time
@@ -60,9 +144,12 @@ func NewClaimFrom(a any) (*Claim, error) {
 	}
 
 	if date, ok := a.(string); ok {
-		d, err := time.Parse(DateFormat, date)
-		if err != nil {
-			return nil, fmt.Errorf("%w: %w %s", ErrWrongDBClaim, err, date)
+		d, err := time.Parse(time.RFC3339, date)
+		if err != nil { // claims stored before timestamps are days
+			if d, err = time.Parse(StoreDateFormat, date); err != nil {
+				return nil, fmt.Errorf("%w: %w %s", ErrWrongDBClaim, err, date)
+			}
+			d = ExpiryOf(d)
 		}
 		c.Date = &d
 		return c, nil
@@ -71,20 +158,45 @@ func NewClaimFrom(a any) (*Claim, error) {
 	return nil, ErrWrongDBClaim
 }
 
+// ToAny returns the claim as stored in Firebase auth: true, or the expiry as a day or a timestamp.
 func (c *Claim) ToAny() any {
-	if c.Date != nil {
-		return c.Date.Format(DateFormat)
+	if c.Date == nil {
+		return c.Checked
+	}
+	if StoreTimestamps {
+		return c.Date.Format(time.RFC3339)
 	}
 
-	return c.Checked
+	return c.Date.In(ExpiryLocation).Format(StoreDateFormat)
 }
 
+// FormatDate returns the expiry of the claim, see FormatExpiry.
 func (c *Claim) FormatDate() string {
 	if c.Date == nil {
 		return "<???>"
 	}
 
-	return c.Date.Format(DateFormat)
+	return FormatExpiry(*c.Date)
+}
+
+// Remaining returns the time left until the claim expires from now in short form, like "12d".
+// It's empty for permanent claims.
+func (c *Claim) Remaining(now time.Time) string {
+	if c.Date == nil {
+		return ""
+	}
+
+	left := c.Date.Sub(now)
+	switch {
+	case left <= 0:
+		return lang.SExpired
+	case left >= d1:
+		return fmt.Sprintf(lang.SRemainingDays, int(left/d1))
+	case left >= time.Hour:
+		return fmt.Sprintf(lang.SRemainingHours, int(left/time.Hour))
+	default:
+		return lang.SRemainingLess
+	}
 }
 
 func (c *Claim) IsZero() bool {
@@ -104,7 +216,7 @@ func (c *Claim) Differs(d *Claim) bool {
 		return true
 	}
 
-	return d.Date != nil && !c.Date.Truncate(d1).Equal(d.Date.Truncate(d1))
+	return d.Date != nil && c.ToAny() != d.ToAny()
 }
 
 func (c *Claim) DiffersType(d *Claim) bool {
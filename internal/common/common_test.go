@@ -128,3 +128,94 @@ func TestClaimDiffers(t *testing.T) {
 		})
 	}
 }
+
+func TestClaimExpiry(t *testing.T) {
+	defer func(loc *time.Location, stamps bool) {
+		ExpiryLocation, StoreTimestamps = loc, stamps
+	}(ExpiryLocation, StoreTimestamps)
+	ExpiryLocation = time.FixedZone("CET", 3600)
+	endOfDay := time.Date(2026, 3, 15, 23, 59, 59, 0, ExpiryLocation)
+
+	tests := []struct {
+		name       string
+		stored     any
+		timestamps bool
+		wantDate   time.Time
+		wantStored any
+		wantShown  string
+	}{
+		{
+			name:       "day expires at its end in the configured timezone",
+			stored:     "2026-03-15",
+			wantDate:   endOfDay,
+			wantStored: "2026-03-15",
+			wantShown:  "2026-03-15",
+		},
+		{
+			name:       "day stored as timestamp",
+			stored:     "2026-03-15",
+			timestamps: true,
+			wantDate:   endOfDay,
+			wantStored: "2026-03-15T23:59:59+01:00",
+			wantShown:  "2026-03-15",
+		},
+		{
+			name:       "timestamp stored as its day",
+			stored:     "2026-03-15T11:00:00Z",
+			wantDate:   time.Date(2026, 3, 15, 12, 0, 0, 0, ExpiryLocation),
+			wantStored: "2026-03-15",
+			wantShown:  "2026-03-15 12:00",
+		},
+		{
+			name:       "timestamp in another timezone",
+			stored:     "2026-03-15T23:30:00Z",
+			timestamps: true,
+			wantDate:   time.Date(2026, 3, 16, 0, 30, 0, 0, ExpiryLocation),
+			wantStored: "2026-03-15T23:30:00Z",
+			wantShown:  "2026-03-16 00:30",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			StoreTimestamps = tt.timestamps
+
+			c, err := NewClaimFrom(tt.stored)
+
+			assert.NoError(t, err)
+			assert.True(t, tt.wantDate.Equal(*c.Date), "expires at %s", c.Date)
+			assert.Equal(t, tt.wantStored, c.ToAny())
+			assert.Equal(t, tt.wantShown, c.FormatDate())
+		})
+	}
+
+	_, err := NewClaimFrom("15/03/2026")
+	assert.ErrorIs(t, err, ErrWrongDBClaim)
+}
+
+func TestClaimRemaining(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	date := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		claim Claim
+		want  string
+	}{
+		{name: "permanent", claim: Claim{Checked: true}, want: ""},
+		{name: "days", claim: Claim{Date: date(30*d1 + time.Hour)}, want: "30d"},
+		{name: "hours", claim: Claim{Date: date(23 * time.Hour)}, want: "23h"},
+		{name: "less than an hour", claim: Claim{Date: date(time.Minute)}, want: "<1h"},
+		{name: "expired now", claim: Claim{Date: date(0)}, want: "expired"},
+		{name: "expired", claim: Claim{Date: date(-d1)}, want: "expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.claim.Remaining(now))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"errors"
+
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// Classes of failures, also used as exit codes of the commands.
+const (
+	ExitOK          = iota // success
+	ExitError              // any other failure
+	ExitUsage              // wrong flags or arguments
+	ExitConfig             // invalid config file or custom settings
+	ExitCredentials        // missing or unusable credentials
+	ExitConnection         // Firebase can't be reached
+	ExitDenied             // the operation is not allowed
+	ExitPartial            // some users failed
+)
+
+// ClassError is an error of a failure class, which decides the exit code and the hint shown.
+type ClassError struct {
+	Class int
+	Err   error
+}
+
+// Classify returns the given error with the given class, or nil if there's no error. Errors already
+// classified keep their class.
+func Classify(class int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var ce *ClassError
+	if errors.As(err, &ce) {
+		return err
+	}
+
+	return &ClassError{Class: class, Err: err}
+}
+
+func (e *ClassError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the given error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var ce *ClassError
+	if errors.As(err, &ce) {
+		return ce.Class
+	}
+
+	return ExitError
+}
+
+// hints are advices on how to fix the failures of a class, pointing to the strings of the language
+// loaded later.
+var hints = map[int]*string{
+	ExitUsage:       &lang.HintUsage,
+	ExitConfig:      &lang.HintConfig,
+	ExitCredentials: &lang.HintCredentials,
+	ExitConnection:  &lang.HintConnection,
+	ExitDenied:      &lang.HintDenied,
+	ExitPartial:     &lang.HintPartial,
+}
+
+// Hint returns an advice on how to fix the given error, empty if there's none.
+func Hint(err error) string {
+	if h, ok := hints[ExitCode(err)]; ok {
+		return *h
+	}
+
+	return ""
+}
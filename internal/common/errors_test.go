@@ -0,0 +1,53 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	errDenied := &ClassError{Class: ExitDenied, Err: errors.New("denied")}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantHint string
+	}{
+		{name: "no error", err: nil, wantCode: ExitOK},
+		{name: "unclassified", err: errors.New("any"), wantCode: ExitError},
+		{name: "classified", err: Classify(ExitConfig, errors.New("bad")), wantCode: ExitConfig,
+			wantHint: lang.HintConfig},
+		{name: "wrapped", err: fmt.Errorf("operator: %w", errDenied), wantCode: ExitDenied,
+			wantHint: lang.HintDenied},
+		{name: "keeps first class", err: Classify(ExitConnection, errDenied), wantCode: ExitDenied,
+			wantHint: lang.HintDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.wantCode, ExitCode(tt.err))
+			assert.Equal(t, tt.wantHint, Hint(tt.err))
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Classify(ExitUsage, nil))
+
+	err := errors.New("bad")
+	ce := Classify(ExitUsage, err)
+	assert.ErrorIs(t, ce, err)
+	assert.Equal(t, err.Error(), ce.Error())
+}
@@ -12,10 +12,20 @@ import (
 type FbIf interface {
 	Search(ctx context.Context, key, value string, cb func(uid string) error) error
 	StoreAuthClaims(ctx context.Context, uid string, newClaims map[string]any) error
-	IterUsers(cb func(*auth.UserRecord) error) error
+	IterUsers(ctx context.Context, token string, cb func(*auth.UserRecord) error, onPage func(next string) error) error
 	GetUsers(ctx context.Context, uids []auth.UserIdentifier) (*auth.GetUsersResult, error)
 	GetSpecs(ctx context.Context) (map[string]any, error)
 	UpdateSpecs(tr *firestore.Transaction, updates map[string]any) error
 	RunTransaction(ctx context.Context, cb func(tr *firestore.Transaction, privileged map[string]any) error) error
 	DoList() error
+	AddPending(ctx context.Context, p *PendingChange) error
+	GetPending(ctx context.Context) ([]*PendingChange, error)
+	DeletePending(ctx context.Context, id string) error
+	WatchSpecs(ctx context.Context, cb func(privileged map[string]any)) error
+	WatchChanges(ctx context.Context, cb func(revisions map[string]any)) error
+	GetOperator(ctx context.Context, name string) ([]string, error)
+	CreateUser(ctx context.Context, uid, email, name string) error
+	SetProfile(ctx context.Context, uid string, profile map[string]any) error
+	SetSpecs(ctx context.Context, privileged map[string]any) error
+	ProjectID() string
 }
@@ -10,17 +10,24 @@ import (
 
 // FeIf is an interface to be able to mock tview GUI functionality.
 type FeIf interface {
-	Run()
+	Run() error
 	CurrentPage() string
 	SetPage(string)
 	SetOnShow(string, func())
 	ShowMsg(ms ...string)
 	ShowConfirm(onYes, onNo func(), ms ...string)
 	ShowProgress(ctx context.Context, cancelFunc context.CancelFunc, ms ...string)
+	CancelProgress()
+	SetProgress(m string)
+	SetLastError(m string)
+	UpdateHeader()
 	ClaimButtonSetDisabled(index int, isDisabled bool)
 	HidePopup(popup string)
 	LayoutUsers()
+	LayoutApprovals()
+	LayoutExpiring()
 	Quit()
+	QueueUpdateDraw(f func())
 
 	ShowClaimChoser(i int, key string, c Claim)
 	CreateClaimChoser()
@@ -29,4 +36,10 @@ type FeIf interface {
 	ClaimsSetDate(time.Time)
 	ClaimsDate() *time.Time
 	ReplaceTableItem(i int, key string, p tview.Primitive)
+	ShowRoleChoser(rows []int)
+	ShowExtendChoser(rows []int)
+	ReplaceUserNames(i int)
+	FocusCell(i int, key string)
+	FocusSearch()
+	ShowPalette()
 }
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Hotkey is a keyboard shortcut: a special key like F2 or Ctrl-S, or a rune like x, with modifiers.
+// Shift is part of the rune, and Ctrl of the control keys like Ctrl-S, so they're not in Mod.
+type Hotkey struct {
+	Key  tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// modNames are the modifiers of hotkey names in the order they're written.
+var modNames = []struct {
+	name string
+	mod  tcell.ModMask
+}{
+	{"Ctrl", tcell.ModCtrl},
+	{"Alt", tcell.ModAlt},
+	{"Meta", tcell.ModMeta},
+	{"Shift", tcell.ModShift},
+}
+
+// spaceName is the name of the space rune, it can't be written in the config file itself.
+const spaceName = "Space"
+
+// keysByName has the special keys by their lowercase tcell names.
+var keysByName = map[string]tcell.Key{}
+
+func init() {
+	for key, name := range tcell.KeyNames {
+		keysByName[strings.ToLower(name)] = key
+	}
+}
+
+// KeyOf returns the hotkey of a special key without modifiers.
+func KeyOf(key tcell.Key) Hotkey {
+	return Hotkey{Key: key}
+}
+
+// HotkeyOf returns the hotkey pressed in the given event.
+func HotkeyOf(ev *tcell.EventKey) Hotkey {
+	key, mod := ev.Key(), ev.Modifiers()
+	if key == tcell.KeyRune {
+		return Hotkey{Key: key, Rune: ev.Rune(), Mod: mod &^ tcell.ModShift}
+	}
+	if key >= tcell.KeyCtrlSpace && key <= tcell.KeyCtrlUnderscore {
+		mod &^= tcell.ModCtrl
+	}
+
+	return Hotkey{Key: key, Mod: mod}
+}
+
+// ParseHotkey parses hotkey names like F2, Ctrl-S, Alt-x, Shift-Up or a plain rune like /. Key and
+// modifier names are case insensitive, runes are not.
+func ParseHotkey(name string) (Hotkey, bool) {
+	var mod tcell.ModMask
+	rest := name
+	for len(rest) > 0 {
+		if key, ok := keysByName[strings.ToLower(rest)]; ok {
+			return Hotkey{Key: key, Mod: mod}, true
+		}
+		if strings.EqualFold(rest, spaceName) {
+			return runeHotkey(' ', mod), true
+		}
+		if r, size := utf8.DecodeRuneInString(rest); size == len(rest) && r != utf8.RuneError {
+			return runeHotkey(r, mod), true
+		}
+
+		prefix, after, ok := strings.Cut(rest, "-")
+		if !ok {
+			break
+		}
+		m, ok := modByName(prefix)
+		if !ok {
+			break
+		}
+		mod |= m
+		rest = after
+	}
+
+	return Hotkey{}, false
+}
+
+// modByName returns the modifier of the given case insensitive name.
+func modByName(name string) (tcell.ModMask, bool) {
+	for _, m := range modNames {
+		if strings.EqualFold(m.name, name) {
+			return m.mod, true
+		}
+	}
+
+	return 0, false
+}
+
+// runeHotkey returns the hotkey of the given rune with modifiers. Ctrl with a letter is a control
+// key, Shift with a letter is the capital one, like tcell reports them.
+func runeHotkey(r rune, mod tcell.ModMask) Hotkey {
+	lower := unicode.ToLower(r)
+	if mod&tcell.ModCtrl != 0 && lower >= 'a' && lower <= 'z' {
+		return Hotkey{Key: tcell.KeyCtrlA + tcell.Key(lower-'a'), Mod: mod &^ (tcell.ModCtrl | tcell.ModShift)}
+	}
+	if mod&tcell.ModShift != 0 {
+		r = unicode.ToUpper(r)
+	}
+
+	return Hotkey{Key: tcell.KeyRune, Rune: r, Mod: mod &^ tcell.ModShift}
+}
+
+// String returns the name of the hotkey, parsed back by ParseHotkey.
+func (h Hotkey) String() string {
+	var b strings.Builder
+	for _, m := range modNames {
+		if h.Mod&m.mod != 0 {
+			b.WriteString(m.name)
+			b.WriteByte('-')
+		}
+	}
+
+	switch {
+	case h.Key != tcell.KeyRune:
+		b.WriteString(tcell.KeyNames[h.Key])
+	case h.Rune == ' ':
+		b.WriteString(spaceName)
+	default:
+		b.WriteRune(h.Rune)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHotkey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		want     Hotkey
+		wantName string
+		wantOK   bool
+	}{
+		{name: "function key", input: "F2", want: Hotkey{Key: tcell.KeyF2}, wantName: "F2", wantOK: true},
+		{name: "lowercase key name", input: "esc", want: Hotkey{Key: tcell.KeyEsc}, wantName: "Esc", wantOK: true},
+		{name: "control key", input: "Ctrl-S", want: Hotkey{Key: tcell.KeyCtrlS}, wantName: "Ctrl-S", wantOK: true},
+		{name: "control key lowercase", input: "ctrl-s", want: Hotkey{Key: tcell.KeyCtrlS}, wantName: "Ctrl-S",
+			wantOK: true},
+		{name: "alt rune", input: "Alt-x", want: Hotkey{Key: tcell.KeyRune, Rune: 'x', Mod: tcell.ModAlt},
+			wantName: "Alt-x", wantOK: true},
+		{name: "shift rune is the capital one", input: "Alt-Shift-x",
+			want: Hotkey{Key: tcell.KeyRune, Rune: 'X', Mod: tcell.ModAlt}, wantName: "Alt-X", wantOK: true},
+		{name: "plain rune", input: "/", want: Hotkey{Key: tcell.KeyRune, Rune: '/'}, wantName: "/", wantOK: true},
+		{name: "dash rune", input: "Alt--", want: Hotkey{Key: tcell.KeyRune, Rune: '-', Mod: tcell.ModAlt},
+			wantName: "Alt--", wantOK: true},
+		{name: "space", input: "Space", want: Hotkey{Key: tcell.KeyRune, Rune: ' '}, wantName: "Space",
+			wantOK: true},
+		{name: "modified special key", input: "Alt-Up", want: Hotkey{Key: tcell.KeyUp, Mod: tcell.ModAlt},
+			wantName: "Alt-Up", wantOK: true},
+		{name: "unknown modifier", input: "Super-x"},
+		{name: "unknown key", input: "invalidKey"},
+		{name: "missing key", input: "Ctrl-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := ParseHotkey(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			if !tt.wantOK {
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantName, got.String())
+
+			again, ok := ParseHotkey(got.String())
+			assert.True(t, ok)
+			assert.Equal(t, got, again, "names are parsed back")
+		})
+	}
+}
+
+func TestHotkeyOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		ev    *tcell.EventKey
+		input string
+	}{
+		{name: "function key", ev: tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), input: "F5"},
+		{name: "control key", ev: tcell.NewEventKey(tcell.KeyCtrlS, 's', tcell.ModCtrl), input: "Ctrl-S"},
+		{name: "alt rune", ev: tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), input: "Alt-x"},
+		{name: "shifted rune", ev: tcell.NewEventKey(tcell.KeyRune, 'X', tcell.ModShift), input: "X"},
+		{name: "plain rune", ev: tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone), input: "j"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want, ok := ParseHotkey(tt.input)
+			assert.True(t, ok)
+			assert.Equal(t, want, HotkeyOf(tt.ev))
+		})
+	}
+}
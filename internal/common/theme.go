@@ -0,0 +1,163 @@
+package common
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/vendelin8/tview"
+)
+
+// Theme is a color scheme of the GUI. Base is set as tview.Styles before the widgets are created,
+// the others color the users table, the menu and the header. Colors left at tcell.ColorDefault are
+// the terminal's own, selected rows are shown reversed then.
+type Theme struct {
+	Base tview.Theme
+
+	StripeBackground   tcell.Color // every second row of the users table
+	StripeText         tcell.Color
+	SelectedBackground tcell.Color // users selected for changing several at once
+	SelectedText       tcell.Color
+	Positive           tcell.Color // granted permissions, menu commands keeping changes
+	Negative           tcell.Color // revoked permissions, errors, menu commands discarding changes
+	Warning            tcell.Color // claims expiring soon, users edited by others, the dry-run badge
+	Info               tcell.Color // the read-only badge
+	Accent             tcell.Color // menu items of pages
+	BadgeText          tcell.Color
+}
+
+// Names of the built-in themes.
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+)
+
+// Themes are the built-in color schemes by their names.
+var Themes = map[string]Theme{
+	ThemeDark: {
+		Base:               tview.Styles,
+		StripeBackground:   tcell.ColorWhite,
+		StripeText:         tcell.ColorBlack,
+		SelectedBackground: tcell.ColorBlue,
+		SelectedText:       tcell.ColorWhite,
+		Positive:           tcell.ColorGreen,
+		Negative:           tcell.ColorRed,
+		Warning:            tcell.ColorYellow,
+		Info:               tcell.ColorBlue,
+		Accent:             tcell.ColorYellow,
+		BadgeText:          tcell.ColorBlack,
+	},
+	ThemeLight: {
+		Base: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorWhite,
+			ContrastBackgroundColor:     tcell.ColorLightSkyBlue,
+			MoreContrastBackgroundColor: tcell.ColorLightGreen,
+			BorderColor:                 tcell.ColorBlack,
+			TitleColor:                  tcell.ColorBlack,
+			GraphicsColor:               tcell.ColorBlack,
+			PrimaryTextColor:            tcell.ColorBlack,
+			SecondaryTextColor:          tcell.ColorNavy,
+			TertiaryTextColor:           tcell.ColorDarkGreen,
+			InverseTextColor:            tcell.ColorWhite,
+			ContrastSecondaryTextColor:  tcell.ColorNavy,
+		},
+		StripeBackground:   tcell.ColorGainsboro,
+		StripeText:         tcell.ColorBlack,
+		SelectedBackground: tcell.ColorBlue,
+		SelectedText:       tcell.ColorWhite,
+		Positive:           tcell.ColorGreen,
+		Negative:           tcell.ColorDarkRed,
+		Warning:            tcell.ColorDarkOrange,
+		Info:               tcell.ColorBlue,
+		Accent:             tcell.ColorNavy,
+		BadgeText:          tcell.ColorWhite,
+	},
+	ThemeHighContrast: {
+		Base: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorBlack,
+			ContrastBackgroundColor:     tcell.ColorBlue,
+			MoreContrastBackgroundColor: tcell.ColorPurple,
+			BorderColor:                 tcell.ColorWhite,
+			TitleColor:                  tcell.ColorWhite,
+			GraphicsColor:               tcell.ColorWhite,
+			PrimaryTextColor:            tcell.ColorWhite,
+			SecondaryTextColor:          tcell.ColorYellow,
+			TertiaryTextColor:           tcell.ColorAqua,
+			InverseTextColor:            tcell.ColorBlack,
+			ContrastSecondaryTextColor:  tcell.ColorYellow,
+		},
+		StripeBackground:   tcell.ColorWhite,
+		StripeText:         tcell.ColorBlack,
+		SelectedBackground: tcell.ColorYellow,
+		SelectedText:       tcell.ColorBlack,
+		Positive:           tcell.ColorLime,
+		Negative:           tcell.ColorOrangeRed,
+		Warning:            tcell.ColorYellow,
+		Info:               tcell.ColorAqua,
+		Accent:             tcell.ColorAqua,
+		BadgeText:          tcell.ColorBlack,
+	},
+	ThemeMonochrome: {
+		Base: tview.Theme{
+			PrimitiveBackgroundColor:    tcell.ColorDefault,
+			ContrastBackgroundColor:     tcell.ColorDefault,
+			MoreContrastBackgroundColor: tcell.ColorDefault,
+			BorderColor:                 tcell.ColorDefault,
+			TitleColor:                  tcell.ColorDefault,
+			GraphicsColor:               tcell.ColorDefault,
+			PrimaryTextColor:            tcell.ColorDefault,
+			SecondaryTextColor:          tcell.ColorDefault,
+			TertiaryTextColor:           tcell.ColorDefault,
+			InverseTextColor:            tcell.ColorDefault,
+			ContrastSecondaryTextColor:  tcell.ColorDefault,
+		},
+		StripeBackground:   tcell.ColorDefault,
+		StripeText:         tcell.ColorDefault,
+		SelectedBackground: tcell.ColorDefault,
+		SelectedText:       tcell.ColorDefault,
+		Positive:           tcell.ColorDefault,
+		Negative:           tcell.ColorDefault,
+		Warning:            tcell.ColorDefault,
+		Info:               tcell.ColorDefault,
+		Accent:             tcell.ColorDefault,
+		BadgeText:          tcell.ColorDefault,
+	},
+}
+
+// CurrentTheme is the color scheme of the GUI, set by the config file.
+var CurrentTheme = Themes[ThemeDark]
+
+// Colors returns the colors of the theme by their names in the config file, to set them.
+func (t *Theme) Colors() map[string]*tcell.Color {
+	return map[string]*tcell.Color{
+		"background":             &t.Base.PrimitiveBackgroundColor,
+		"contrastBackground":     &t.Base.ContrastBackgroundColor,
+		"moreContrastBackground": &t.Base.MoreContrastBackgroundColor,
+		"border":                 &t.Base.BorderColor,
+		"title":                  &t.Base.TitleColor,
+		"graphics":               &t.Base.GraphicsColor,
+		"text":                   &t.Base.PrimaryTextColor,
+		"secondaryText":          &t.Base.SecondaryTextColor,
+		"tertiaryText":           &t.Base.TertiaryTextColor,
+		"inverseText":            &t.Base.InverseTextColor,
+		"contrastSecondaryText":  &t.Base.ContrastSecondaryTextColor,
+		"stripeBackground":       &t.StripeBackground,
+		"stripeText":             &t.StripeText,
+		"selectedBackground":     &t.SelectedBackground,
+		"selectedText":           &t.SelectedText,
+		"positive":               &t.Positive,
+		"negative":               &t.Negative,
+		"warning":                &t.Warning,
+		"info":                   &t.Info,
+		"accent":                 &t.Accent,
+		"badgeText":              &t.BadgeText,
+	}
+}
+
+// ColorTag returns the name of the color in tview color tags, "-" for the terminal's own.
+func ColorTag(c tcell.Color) string {
+	if c == tcell.ColorDefault {
+		return "-"
+	}
+
+	return c.String()
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThemeColors(t *testing.T) {
+	t.Parallel()
+
+	theme := Themes[ThemeDark]
+	for _, color := range theme.Colors() {
+		*color = tcell.ColorDefault
+	}
+	assert.Equal(t, Themes[ThemeMonochrome], theme, "every color is named")
+}
+
+func TestColorTag(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "-", ColorTag(tcell.ColorDefault))
+	assert.Equal(t, "yellow", ColorTag(tcell.ColorYellow))
+	assert.Equal(t, "#FF8800", ColorTag(tcell.NewHexColor(0xff8800)))
+}
@@ -1,17 +1,22 @@
 package conf
 
 import (
+	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"slices"
-	"sort"
 	"strings"
+	"time"
+	_ "time/tzdata" // timezones of the config file on systems without them
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,128 +24,770 @@ const (
 	cmdStart = iota // menu commands
 	CmdSearch
 	CmdList
+	CmdApprovals
+	CmdExpiring
 	CmdRefresh
 	CmdSave
+	CmdRole
+	CmdSelect
+	CmdExtend
 	CmdCancel
+	CmdDryRun
+	CmdPalette
 	CmdQuit
 	cmdEnd
 )
 
-var ErrConfInvalid = errors.New(lang.ErrConfInvalidS)
+const (
+	actStart = cmdEnd + iota // actions bound to keys, not shown in the menu
+	ActFocusSearch
+	ActNextRow
+	ActPrevRow
+	ActToggleClaim
+	ActOpenDetail
+	ActExport
+	actEnd
+)
+
+var (
+	ErrConfInvalid = lang.NewError(&lang.ErrConfInvalidS)
+	ErrFlags       = lang.NewError(&lang.ErrFlagsS)
+)
+
+// shortcutsKey is the keyboard shortcuts section of the config file, the same in all languages.
+// Shortcuts working only on a page or in a popup are under its pagesKey and popupsKey.
+const (
+	shortcutsKey = "keyboardShortcuts"
+	pagesKey     = "pages"
+	popupsKey    = "popups"
+)
+
+// legacyShortcutsKeys are the translated names of the keyboard shortcuts section of older config files.
+var legacyShortcutsKeys = []string{"Gyorsbillentyűk"}
+
+// cmdIDs are the names of the menu commands and actions in the keyboard shortcuts of the config file,
+// the same in all languages. Their menu texts in the language loaded are accepted too, like in older
+// config files.
+var cmdIDs = map[string]int{
+	"search":      CmdSearch,
+	"list":        CmdList,
+	"approvals":   CmdApprovals,
+	"expiring":    CmdExpiring,
+	"refresh":     CmdRefresh,
+	"save":        CmdSave,
+	"applyRole":   CmdRole,
+	"select":      CmdSelect,
+	"extend":      CmdExtend,
+	"cancel":      CmdCancel,
+	"dryRun":      CmdDryRun,
+	"commands":    CmdPalette,
+	"quit":        CmdQuit,
+	"focusSearch": ActFocusSearch,
+	"nextRow":     ActNextRow,
+	"previousRow": ActPrevRow,
+	"toggleClaim": ActToggleClaim,
+	"openDetail":  ActOpenDetail,
+	"export":      ActExport,
+}
+
+// scopes are the pages and popups shortcuts may be bound on, by the sections they're listed in.
+var scopes = map[string][]string{
+	pagesKey: {lang.PageSearch, lang.PageList, lang.PageApprovals, lang.PageExpiring},
+	popupsKey: {lang.PopupMsg, lang.PopupConfirm, lang.PopupWarn, lang.PopupProgress, lang.PopupClaim,
+		lang.PopupRole, lang.PopupExtend, lang.PopupPalette},
+}
+
+// binding is a keyboard shortcut of the config file, on a page or in a popup if scope is set.
+type binding struct {
+	key   common.Hotkey
+	cmd   int
+	scope string
+	line  int
+}
+
+// shortcutsLoader collects the keyboard shortcuts of the config file, and the problems with them.
+type shortcutsLoader struct {
+	cmds      map[string]int
+	hidden    map[string]struct{}
+	bindings  []binding
+	lines     map[string]map[common.Hotkey]int
+	badKeys   []string
+	badCmds   map[string]struct{}
+	conflicts []string
+}
+
+// localeConf is the language and the date format of the config file.
+type localeConf struct {
+	Language   string `yaml:"language"`
+	DateFormat string `yaml:"dateFormat"`
+}
+
+// expiryConf is how timed claims expire in the config file.
+type expiryConf struct {
+	Timezone        string `yaml:"timezone"`
+	StoreTimestamps bool   `yaml:"storeTimestamps"`
+}
+
+// rolesConf is the role templates section of the config file.
+type rolesConf struct {
+	Roles map[string]common.Role `yaml:"roles"`
+}
+
+// approvalConf is the four-eyes approval section of the config file.
+type approvalConf struct {
+	Approval struct {
+		Permissions []string `yaml:"permissions"`
+	} `yaml:"approval"`
+}
+
+// themeConf is the color scheme of the config file: the chosen theme, and custom ones by their
+// names with the colors overridden.
+type themeConf struct {
+	Theme  string                       `yaml:"theme"`
+	Themes map[string]map[string]string `yaml:"themes"`
+}
+
+// themeBaseKey names the theme a custom one starts from, the dark one by default.
+const themeBaseKey = "base"
+
+// Sources of the permissions operators may grant.
+const (
+	OperatorsConfig    = "config"
+	OperatorsFirestore = "firestore"
+)
+
+// operatorsConf is the operator allowlist section of the config file.
+type operatorsConf struct {
+	Operators struct {
+		Source      string              `yaml:"source"`
+		Permissions map[string][]string `yaml:"permissions"`
+	} `yaml:"operators"`
+}
+
+// Credentials to connect to Firebase with.
+const (
+	CredentialsKey = "key" // service account key file
+	CredentialsADC = "adc" // Application Default Credentials
+)
+
+// DefaultKeyPath is the service account key file used if it exists and no credentials are chosen.
+const DefaultKeyPath = "service-account.json"
+
+// profile is a named set of settings in the config file, selected with --profile. Settings given as
+// flags take precedence.
+type profile struct {
+	ReadOnly    bool   `yaml:"readOnly"`
+	Credentials string `yaml:"credentials"`
+	Key         string `yaml:"key"`
+	Impersonate string `yaml:"impersonate"`
+	Project     string `yaml:"project"`
+}
+
+// profilesConf is the profiles section of the config file.
+type profilesConf struct {
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// readOnlyCmds are the menu commands hidden in read-only mode, as they change permissions.
+var readOnlyCmds = []int{CmdRefresh, CmdSave, CmdRole, CmdSelect, CmdExtend}
 
 var (
 	ConfPath string
 	LogPath  string
 	UseEmu   bool
+	Watch    bool
 	KeyPath  string
+	Operator string
+	DryRun   bool
+	ReadOnly bool
+	Profile  string
+	Lang     string
+
+	// Credentials is CredentialsKey or CredentialsADC, empty for choosing by KeyPath. Impersonate is
+	// a service account to impersonate with them. Project is the Google Cloud project ID.
+	Credentials string
+	Impersonate string
+	Project     string
+
+	// FirestoreEmuHost and AuthEmuHost are the addresses of the emulators used with UseEmu.
+	FirestoreEmuHost = cmp.Or(os.Getenv("FIRESTORE_EMULATOR_HOST"), "localhost:8080")
+	AuthEmuHost      = cmp.Or(os.Getenv("FIREBASE_AUTH_EMULATOR_HOST"), "localhost:9099")
+
+	// RefreshTimeout limits a whole refresh. An interrupted refresh resumes from CheckpointPath.
+	RefreshTimeout = 30 * time.Minute
+	CheckpointPath = "refresh.json"
+
+	// JournalPath is the write-ahead journal of permission writes, to repair them after a partial failure.
+	JournalPath = "journal.json"
+
+	// Timeout limits a Firebase call including its retries, BatchSize is the number of users
+	// downloaded at once.
+	Timeout   = 12 * time.Second
+	BatchSize = 100
+
+	// Retries is the number of retries of a failed Firebase call, Backoff is the wait before the
+	// first one, doubled for every next one.
+	Retries = 4
+	Backoff = 250 * time.Millisecond
+
+	// ApprovalPerms lists the permissions whose changes need the approval of another operator.
+	ApprovalPerms []string
+
+	// OperatorSource is where the permissions of operators are listed, empty if they're not
+	// restricted. OperatorPerms is the allowlist of the config file. Grantable is the set of
+	// permissions the current operator may grant or revoke, nil if all.
+	OperatorSource string
+	OperatorPerms  map[string][]string
+	Grantable      map[string]struct{}
 )
 
-// InitConf initializes configurations, only keyboard shortcuts for now.
+// CheckFlags validates the limits of Firebase calls.
+func CheckFlags() error {
+	if BatchSize < 1 || BatchSize > 100 || Retries < 0 || Backoff < 0 || Timeout <= 0 {
+		return ErrFlags
+	}
+
+	return nil
+}
+
+// InitConf initializes configurations: keyboard shortcuts, date format, expiry, role templates,
+// approval and operators. Then it checks the TimedButtons and the rules against the expiry settings.
 func InitConf(menuCb func(menuKey, text, shortcut string, isPositive bool)) error {
 	// loading config file
 	if len(ConfPath) == 0 {
-		return loadConf(menuCb, nil)
+		if err := loadDateFormat(bytes.NewReader(nil)); err != nil {
+			return err
+		}
+		hidden := hideReadOnly()
+		if err := loadConf(menuCb, nil, hidden); err != nil {
+			return err
+		}
+		return checkTimed()
 	}
-	fp, err := os.Open(ConfPath)
-	if err == nil {
-		return loadConf(menuCb, fp)
+	data, err := os.ReadFile(ConfPath)
+	if err != nil {
+		return fmt.Errorf(lang.ErrConfPath, err)
 	}
-	return fmt.Errorf(lang.ErrConfPath, err)
+	hidden := hideReadOnly()
+	if err = loadConf(menuCb, bytes.NewReader(data), hidden); err != nil {
+		return err
+	}
+	if err = loadDateFormat(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err = loadExpiry(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err = loadRoles(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err = loadApproval(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err = loadOperators(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return checkTimed()
 }
 
-// loadConf loads configurations, only keyboard shortcuts for now.
-func loadConf(menuCb func(menuKey, text, shortcut string, isPositive bool), fp io.Reader) error {
+// checkTimed checks the durations built into the binary against the expiry settings.
+func checkTimed() error {
+	if err := util.CheckTimedButtons(); err != nil {
+		return err
+	}
+
+	return util.ValidateRules()
+}
+
+// loadConf loads configurations, only keyboard shortcuts for now. Shortcuts of hidden commands are
+// skipped.
+func loadConf(menuCb func(menuKey, text, shortcut string, isPositive bool), fp io.Reader,
+	hidden map[string]struct{},
+) error {
 	defer saveShortcuts(menuCb)
 
-	kc, err := loadYamlConf(fp)
-	if err != nil {
+	node, err := loadYamlConf(fp)
+	if err != nil || node == nil {
 		return err
 	}
 
-	if len(kc) == 0 {
-		return nil
+	l := &shortcutsLoader{
+		cmds:    map[string]int{},
+		hidden:  hidden,
+		lines:   map[string]map[common.Hotkey]int{},
+		badCmds: map[string]struct{}{},
+	}
+	for i := cmdStart + 1; i < actEnd; i++ {
+		if m, ok := common.MenuItems[i]; ok {
+			l.cmds[m.Text] = i
+		}
+	}
+	for id, i := range cmdIDs {
+		if _, ok := common.MenuItems[i]; ok {
+			l.cmds[id] = i
+		}
 	}
+	l.read(node, "")
 
-	mapTextToCmd := map[string]int{}
-	for i := cmdStart + 1; i < cmdEnd; i++ {
-		mapTextToCmd[common.MenuItems[i].Text] = i
+	if len(l.badKeys) > 0 { // some more shortcuts not understood
+		return fmt.Errorf(lang.ErrKeyNotFound, strings.Join(l.badKeys, ", "))
+	}
+	if len(l.badCmds) > 0 {
+		return fmt.Errorf(lang.ErrCmdNotFound, strings.Join(slices.Sorted(maps.Keys(l.badCmds)), ", "))
+	}
+	if len(l.conflicts) > 0 {
+		return errors.New(strings.Join(l.conflicts, "\n"))
 	}
 
-	notFound := map[string]struct{}{}           // list of not found key mappings
-	for key, shortcut := range tcell.KeyNames { // iterating tcell key names to check matches
-		text, ok := kc[shortcut]
+	return l.apply()
+}
+
+// read reads the shortcuts of the given mapping node of the config file, on the given page or in the
+// given popup, or everywhere if it's empty.
+func (l *shortcutsLoader) read(node *yaml.Node, scope string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if len(scope) == 0 && v.Kind == yaml.MappingNode {
+			if names, ok := scopes[k.Value]; ok {
+				l.readScopes(v, names)
+				continue
+			}
+		}
+
+		key, ok := common.ParseHotkey(k.Value)
+		if !ok || v.Kind != yaml.ScalarNode {
+			l.badKeys = append(l.badKeys, fmt.Sprintf(lang.SAtLine, k.Value, k.Line))
+			continue
+		}
+		cmd, ok := l.cmds[v.Value]
 		if !ok {
+			if _, ok = l.hidden[v.Value]; !ok {
+				l.badCmds[v.Value] = struct{}{}
+			}
 			continue
 		}
-		cmdStr := text.(string)
-		delete(kc, shortcut)
-		cmd, ok := mapTextToCmd[cmdStr]
+
+		lines, ok := l.lines[scope]
 		if !ok {
-			notFound[cmdStr] = struct{}{}
+			lines = map[common.Hotkey]int{}
+			l.lines[scope] = lines
+		}
+		if line, ok := lines[key]; ok {
+			l.conflicts = append(l.conflicts, fmt.Sprintf(lang.ErrKeyConflict, k.Line, k.Value, line))
 			continue
 		}
+		lines[key] = k.Line
+		l.bindings = append(l.bindings, binding{key: key, cmd: cmd, scope: scope, line: k.Line})
+	}
+}
 
-		m := common.MenuItems[cmd]
-		if !m.IsDef {
-			m.Keys = append(m.Keys, key)
+// readScopes reads the shortcuts of the pages or popups of the given names.
+func (l *shortcutsLoader) readScopes(node *yaml.Node, names []string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if !slices.Contains(names, k.Value) || v.Kind != yaml.MappingNode {
+			l.conflicts = append(l.conflicts, fmt.Sprintf(lang.ErrKeyScope, k.Line, k.Value))
 			continue
 		}
-		m.IsDef = false
-		m.Keys[0] = key
+		l.read(v, k.Value)
 	}
-	if len(kc) > 0 { // some more shortcuts not understood
-		notFoundKeys := make([]string, 0, len(kc))
-		for key := range kc {
-			notFoundKeys = append(notFoundKeys, key)
+}
+
+// apply sets the read shortcuts. The first one of a command replaces its default shortcut, the
+// defaults of the other commands may not be bound to anything else.
+func (l *shortcutsLoader) apply() error {
+	scoped := map[string]map[common.Hotkey]int{}
+	for _, b := range l.bindings {
+		if len(b.scope) > 0 {
+			if _, ok := scoped[b.scope]; !ok {
+				scoped[b.scope] = map[common.Hotkey]int{}
+			}
+			scoped[b.scope][b.key] = b.cmd
+			continue
 		}
-		sort.Strings(notFoundKeys)
-		return fmt.Errorf(lang.ErrKeyNotFound, strings.Join(notFoundKeys, ", "))
+
+		m := common.MenuItems[b.cmd]
+		if m.IsDef {
+			m.IsDef, m.Keys = false, nil
+		}
+		m.Keys = append(m.Keys, b.key)
+		common.MenuItems[b.cmd] = m
 	}
-	if len(notFound) > 0 {
-		notFoundCmds := make([]string, 0, len(notFound))
-		for cmd := range notFound {
-			notFoundCmds = append(notFoundCmds, cmd)
+
+	var conflicts []string
+	for cmd := cmdStart + 1; cmd < actEnd; cmd++ {
+		m, ok := common.MenuItems[cmd]
+		if !ok || !m.IsDef {
+			continue
+		}
+		for _, key := range m.Keys {
+			if line, ok := l.lines[""][key]; ok {
+				conflicts = append(conflicts, fmt.Sprintf(lang.ErrKeyDefault, line, key, m.Text))
+			}
 		}
-		sort.Strings(notFoundCmds)
-		return fmt.Errorf(lang.ErrCmdNotFound, strings.Join(notFoundCmds, ", "))
 	}
+	if len(conflicts) > 0 {
+		return errors.New(strings.Join(conflicts, "\n"))
+	}
+
+	common.ScopedShortcuts = scoped
 	return nil
 }
 
-func loadYamlConf(fp io.Reader) (map[string]any, error) {
+// loadYamlConf returns the keyboard shortcuts section of the config file, with the line numbers.
+func loadYamlConf(fp io.Reader) (*yaml.Node, error) {
 	if fp == nil {
 		return nil, nil
 	}
-	d := yaml.NewDecoder(fp)
-	v := make(map[any]any)
-	if err := d.Decode(&v); err != nil {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(fp).Decode(&doc); err != nil {
 		return nil, fmt.Errorf(lang.ErrConfParse, err)
 	}
 
 	// loading keyboard shortcuts from config file
-	kc, ok := v[lang.CShortcuts].(map[string]any)
-	if !ok {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
 		return nil, ErrConfInvalid
 	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k := root.Content[i].Value
+		if (k == shortcutsKey || slices.Contains(legacyShortcutsKeys, k)) && root.Content[i+1].Kind == yaml.MappingNode {
+			return root.Content[i+1], nil
+		}
+	}
 
-	return kc, nil
+	return nil, ErrConfInvalid
 }
 
+// saveShortcuts registers the shortcuts of the menu commands and actions, and adds the commands to
+// the menu.
 func saveShortcuts(menuCb func(menuKey, text, shortcut string, isPositive bool)) {
-	for i := cmdStart + 1; i < cmdEnd; i++ {
-		m := common.MenuItems[i]
-		if m.IsDef {
-			common.Shortcuts[m.Keys[0]] = i
-			menuCb(m.MenuKey, m.Text, m.Shortcut, m.Positive)
+	for i := cmdStart + 1; i < actEnd; i++ {
+		m, ok := common.MenuItems[i]
+		if !ok {
 			continue
 		}
-		slices.Sort(m.Keys)
-		m.Shortcut = tcell.KeyNames[m.Keys[0]]
-		common.Shortcuts[m.Keys[0]] = i
-		for _, key := range m.Keys[1:] {
+		if !m.IsDef {
+			slices.SortFunc(m.Keys, compareHotkeys)
+			names := make([]string, len(m.Keys))
+			for j, key := range m.Keys {
+				names[j] = key.String()
+			}
+			m.Shortcut = strings.Join(names, "; ")
+			common.MenuItems[i] = m
+		}
+		for _, key := range m.Keys {
 			common.Shortcuts[key] = i
-			m.Shortcut = fmt.Sprintf("%s; %s", m.Shortcut, tcell.KeyNames[key])
 		}
-		menuCb(m.MenuKey, m.Text, m.Shortcut, m.Positive)
+		if i < cmdEnd {
+			menuCb(m.MenuKey, m.Text, m.Shortcut, m.Positive)
+		}
+	}
+}
+
+// compareHotkeys orders special keys by their codes, then runes, then modifiers.
+func compareHotkeys(a, b common.Hotkey) int {
+	return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Rune, b.Rune), cmp.Compare(a.Mod, b.Mod))
+}
+
+// ConfLanguage returns the language set in the config file, empty if there's none. A missing
+// config file is reported by InitConf.
+func ConfLanguage() (string, error) {
+	data, err := os.ReadFile(ConfPath)
+	if err != nil {
+		return "", nil
 	}
+
+	var lc localeConf
+	if err = yaml.Unmarshal(data, &lc); err != nil {
+		return "", fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	return lc.Language, nil
+}
+
+// loadDateFormat sets the layout of dates shown and typed in from the config file, or else from
+// custom.go, or else from the language.
+func loadDateFormat(fp io.Reader) error {
+	var lc localeConf
+	if err := yaml.NewDecoder(fp).Decode(&lc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	layout := cmp.Or(lc.DateFormat, common.BuiltDateFormat, lang.DateLayout)
+	ref := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	if d, err := time.Parse(layout, ref.Format(layout)); err != nil || !d.Equal(ref) {
+		return fmt.Errorf(lang.ErrDateFormat, layout)
+	}
+
+	common.DateFormat = layout
+	return nil
+}
+
+// loadExpiry sets the timezone timed claims expire at the end of the day in, and whether they're
+// stored as timestamps, from the config file. UTC and days are the defaults.
+func loadExpiry(fp io.Reader) error {
+	var ec expiryConf
+	if err := yaml.NewDecoder(fp).Decode(&ec); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	loc, err := time.LoadLocation(ec.Timezone)
+	if err != nil {
+		return fmt.Errorf(lang.ErrTimezone, err)
+	}
+
+	common.ExpiryLocation, common.StoreTimestamps = loc, ec.StoreTimestamps
+	return nil
+}
+
+// LoadProfile applies the settings of the profile selected with --profile from the config file.
+func LoadProfile() error {
+	if len(Profile) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(ConfPath)
+	if err != nil {
+		return fmt.Errorf(lang.ErrConfPath, err)
+	}
+
+	return loadProfile(bytes.NewReader(data))
+}
+
+// loadProfile applies the settings of the selected profile. Flags turned on or given stay so.
+func loadProfile(fp io.Reader) error {
+	var pc profilesConf
+	if err := yaml.NewDecoder(fp).Decode(&pc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	p, ok := pc.Profiles[Profile]
+	if !ok {
+		return fmt.Errorf(lang.ErrProfile, Profile)
+	}
+
+	ReadOnly = ReadOnly || p.ReadOnly
+	Credentials = cmp.Or(Credentials, p.Credentials)
+	KeyPath = cmp.Or(KeyPath, p.Key)
+	Impersonate = cmp.Or(Impersonate, p.Impersonate)
+	Project = cmp.Or(Project, p.Project)
+	return nil
+}
+
+// LoadTheme sets the color scheme from the config file, the dark theme by default. With the
+// NO_COLOR environment variable it's always monochrome. A missing config file is reported by InitConf.
+func LoadTheme() error {
+	data, _ := os.ReadFile(ConfPath)
+	return loadTheme(bytes.NewReader(data))
+}
+
+// loadTheme sets the chosen theme, built-in or custom, checking the custom ones.
+func loadTheme(fp io.Reader) error {
+	var tc themeConf
+	if err := yaml.NewDecoder(fp).Decode(&tc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	themes := maps.Clone(common.Themes)
+	for _, name := range slices.Sorted(maps.Keys(tc.Themes)) {
+		theme, err := customTheme(name, tc.Themes[name])
+		if err != nil {
+			return err
+		}
+		themes[name] = theme
+	}
+
+	name := cmp.Or(tc.Theme, common.ThemeDark)
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		name = common.ThemeMonochrome
+	}
+
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf(lang.ErrTheme, name, builtinThemes())
+	}
+
+	common.CurrentTheme = theme
+	return nil
+}
+
+// customTheme returns a theme of the config file: its base theme with the given colors. A custom
+// theme named as a built-in one changes that.
+func customTheme(name string, colors map[string]string) (common.Theme, error) {
+	base := cmp.Or(colors[themeBaseKey], name)
+	theme, ok := common.Themes[base]
+	if !ok {
+		if _, ok = colors[themeBaseKey]; ok {
+			return theme, fmt.Errorf(lang.ErrTheme, base, builtinThemes())
+		}
+		theme = common.Themes[common.ThemeDark]
+	}
+
+	fields := theme.Colors()
+	for _, key := range slices.Sorted(maps.Keys(colors)) {
+		value := colors[key]
+		if key == themeBaseKey {
+			continue
+		}
+
+		field, ok := fields[key]
+		if !ok {
+			return theme, fmt.Errorf(lang.ErrThemeColor, name, key)
+		}
+
+		color := tcell.GetColor(strings.ToLower(value))
+		if color == tcell.ColorDefault && value != "default" {
+			return theme, fmt.Errorf(lang.ErrThemeValue, name, key, value)
+		}
+		*field = color
+	}
+
+	return theme, nil
+}
+
+// builtinThemes lists the names of the built-in themes for error messages.
+func builtinThemes() string {
+	return strings.Join(slices.Sorted(maps.Keys(common.Themes)), ", ")
+}
+
+// hideReadOnly removes the menu commands changing permissions in read-only mode, and returns
+// their texts and names in the config file.
+func hideReadOnly() map[string]struct{} {
+	hidden := map[string]struct{}{}
+	if !ReadOnly {
+		return hidden
+	}
+
+	for id, cmd := range cmdIDs {
+		if _, ok := common.MenuItems[cmd]; ok && slices.Contains(readOnlyCmds, cmd) {
+			hidden[id] = struct{}{}
+		}
+	}
+	for _, cmd := range readOnlyCmds {
+		if m, ok := common.MenuItems[cmd]; ok {
+			hidden[m.Text] = struct{}{}
+			delete(common.MenuItems, cmd)
+		}
+	}
+
+	return hidden
+}
+
+// loadRoles loads role templates from the config file, checking their permissions and durations.
+// It runs after loadExpiry, as durations with hours need timestamps.
+func loadRoles(fp io.Reader) error {
+	var rc rolesConf
+	if err := yaml.NewDecoder(fp).Decode(&rc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	for name, role := range rc.Roles {
+		for perm, timeStr := range role {
+			if _, ok := common.PermsMap[perm]; !ok {
+				return fmt.Errorf(lang.ErrRolePerm, name, perm)
+			}
+
+			if len(timeStr) == 0 {
+				continue
+			}
+
+			if _, err := util.Expiry(time.Now(), timeStr); err != nil {
+				return fmt.Errorf(lang.ErrRoleTime, name, perm, err)
+			}
+		}
+	}
+
+	common.Roles = rc.Roles
+	return nil
+}
+
+// loadApproval loads the permissions needing approval from the config file, checking them.
+func loadApproval(fp io.Reader) error {
+	var ac approvalConf
+	if err := yaml.NewDecoder(fp).Decode(&ac); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	for _, perm := range ac.Approval.Permissions {
+		if _, ok := common.PermsMap[perm]; !ok {
+			return fmt.Errorf(lang.ErrApprovalPerm, perm)
+		}
+	}
+
+	ApprovalPerms = ac.Approval.Permissions
+	return nil
+}
+
+// NeedsApproval returns if the given permission changes need the approval of another operator.
+func NeedsApproval(actions map[string]common.ClaimsMap) bool {
+	for _, acts := range actions {
+		for perm := range acts {
+			if slices.Contains(ApprovalPerms, perm) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// loadOperators loads the operator allowlist from the config file, checking its permissions.
+func loadOperators(fp io.Reader) error {
+	var oc operatorsConf
+	if err := yaml.NewDecoder(fp).Decode(&oc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf(lang.ErrConfParse, err)
+	}
+
+	ops := oc.Operators
+	switch {
+	case len(ops.Source) == 0 && len(ops.Permissions) > 0:
+		ops.Source = OperatorsConfig
+	case len(ops.Source) > 0 && ops.Source != OperatorsConfig && ops.Source != OperatorsFirestore:
+		return fmt.Errorf(lang.ErrOperatorSource, ops.Source)
+	}
+
+	for name, perms := range ops.Permissions {
+		for _, perm := range perms {
+			if _, ok := common.PermsMap[perm]; !ok {
+				return fmt.Errorf(lang.ErrOperatorPerm, name, perm)
+			}
+		}
+	}
+
+	OperatorSource, OperatorPerms = ops.Source, ops.Permissions
+	return nil
+}
+
+// SetGrantable sets the permissions the current operator may grant or revoke.
+func SetGrantable(perms []string) {
+	Grantable = make(map[string]struct{}, len(perms))
+	for _, perm := range perms {
+		Grantable[perm] = struct{}{}
+	}
+}
+
+// CanGrant returns if the current operator may grant or revoke the given permission.
+func CanGrant(perm string) bool {
+	if Grantable == nil {
+		return true
+	}
+
+	_, ok := Grantable[perm]
+	return ok
+}
+
+// Forbidden returns the permissions of the given changes the current operator may not grant or
+// revoke, sorted.
+func Forbidden(actions map[string]common.ClaimsMap) []string {
+	found := map[string]struct{}{}
+	for _, acts := range actions {
+		for perm := range acts {
+			if !CanGrant(perm) {
+				found[perm] = struct{}{}
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(found))
 }
@@ -1,13 +1,22 @@
 package conf
 
 import (
+	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/lang"
+	"gopkg.in/yaml.v3"
 )
 
 func TestSaveShortcuts(t *testing.T) {
@@ -21,7 +30,7 @@ func TestSaveShortcuts(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -29,7 +38,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -37,7 +46,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -45,7 +54,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -53,7 +62,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -61,7 +70,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -75,7 +84,7 @@ func TestSaveShortcuts(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2, tcell.KeyCtrlS},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}, {Key: tcell.KeyCtrlS}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -83,7 +92,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -91,7 +100,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -99,7 +108,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -107,7 +116,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -115,7 +124,7 @@ func TestSaveShortcuts(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -134,7 +143,7 @@ func TestSaveShortcuts(t *testing.T) {
 
 			// Setup test common.MenuItems
 			common.MenuItems = tt.setupMenuItems
-			common.Shortcuts = make(map[tcell.Key]int)
+			common.Shortcuts = make(map[common.Hotkey]int)
 
 			callCount := 0
 			saveShortcuts(func(menuKey, text, shortcut string, isPositive bool) {
@@ -206,12 +215,12 @@ func TestLoadYamlConf(t *testing.T) {
 				reader = *strings.NewReader(tt.input)
 			}
 
-			var kc map[string]any
+			var node *yaml.Node
 			var err error
 			if tt.input == "" {
-				kc, err = loadYamlConf(nil)
+				node, err = loadYamlConf(nil)
 			} else {
-				kc, err = loadYamlConf(&reader)
+				node, err = loadYamlConf(&reader)
 			}
 
 			if tt.wantError {
@@ -219,6 +228,10 @@ func TestLoadYamlConf(t *testing.T) {
 				assert.Contains(t, err.Error(), tt.wantMsg)
 			} else {
 				assert.NoError(t, err)
+				var kc map[string]any
+				if node != nil {
+					require.NoError(t, node.Decode(&kc))
+				}
 				assert.Equal(t, tt.wantKc, kc)
 			}
 		})
@@ -240,7 +253,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -248,7 +261,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -256,7 +269,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -264,7 +277,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -272,7 +285,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -280,7 +293,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -295,7 +308,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -303,7 +316,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -311,7 +324,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -319,7 +332,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -327,7 +340,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -335,7 +348,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -350,7 +363,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -358,7 +371,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -366,7 +379,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -374,7 +387,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -382,7 +395,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -390,7 +403,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -406,7 +419,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -414,7 +427,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -422,7 +435,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -430,7 +443,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -438,7 +451,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -446,7 +459,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -462,7 +475,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -470,7 +483,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -478,7 +491,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -486,7 +499,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -494,7 +507,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -502,7 +515,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -518,7 +531,7 @@ func TestLoadConf(t *testing.T) {
 			setupMenuItems: map[int]common.MenuItem{
 				CmdSearch: {
 					Shortcut: "F2",
-					Keys:     []tcell.Key{tcell.KeyF2},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 					MenuKey:  "search",
 					Text:     "Search",
 					Positive: false,
@@ -526,7 +539,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdList: {
 					Shortcut: "F3",
-					Keys:     []tcell.Key{tcell.KeyF3},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 					MenuKey:  "list",
 					Text:     "List",
 					Positive: false,
@@ -534,7 +547,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdRefresh: {
 					Shortcut: "F5",
-					Keys:     []tcell.Key{tcell.KeyF5},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 					MenuKey:  "",
 					Text:     "Refresh",
 					Positive: true,
@@ -542,7 +555,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdSave: {
 					Shortcut: "F6",
-					Keys:     []tcell.Key{tcell.KeyF6},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 					MenuKey:  "",
 					Text:     "Save",
 					Positive: true,
@@ -550,7 +563,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdCancel: {
 					Shortcut: "F8",
-					Keys:     []tcell.Key{tcell.KeyF8},
+					Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 					MenuKey:  "",
 					Text:     "Cancel",
 					Positive: false,
@@ -558,7 +571,7 @@ func TestLoadConf(t *testing.T) {
 				},
 				CmdQuit: {
 					Shortcut: "Esc",
-					Keys:     []tcell.Key{tcell.KeyEsc},
+					Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 					MenuKey:  "",
 					Text:     "Quit",
 					Positive: false,
@@ -577,7 +590,7 @@ func TestLoadConf(t *testing.T) {
 
 			// Setup test common.MenuItems
 			common.MenuItems = tt.setupMenuItems
-			common.Shortcuts = make(map[tcell.Key]int)
+			common.Shortcuts = make(map[common.Hotkey]int)
 
 			var ioReader interface{} = nil
 			if tt.input != "" {
@@ -589,11 +602,11 @@ func TestLoadConf(t *testing.T) {
 			if ioReader != nil {
 				actualErr = loadConf(func(menuKey, text, shortcut string, isPositive bool) {
 					callbackCalled = true
-				}, ioReader.(io.Reader))
+				}, ioReader.(io.Reader), nil)
 			} else {
 				actualErr = loadConf(func(menuKey, text, shortcut string, isPositive bool) {
 					callbackCalled = true
-				}, nil)
+				}, nil, nil)
 			}
 
 			if tt.wantError {
@@ -622,7 +635,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 		return map[int]common.MenuItem{
 			CmdSearch: {
 				Shortcut: "F2",
-				Keys:     []tcell.Key{tcell.KeyF2},
+				Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 				MenuKey:  "search",
 				Text:     "Search",
 				Positive: false,
@@ -630,7 +643,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 			},
 			CmdList: {
 				Shortcut: "F3",
-				Keys:     []tcell.Key{tcell.KeyF3},
+				Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 				MenuKey:  "list",
 				Text:     "List",
 				Positive: false,
@@ -638,7 +651,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 			},
 			CmdRefresh: {
 				Shortcut: "F5",
-				Keys:     []tcell.Key{tcell.KeyF5},
+				Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 				MenuKey:  "",
 				Text:     "Refresh",
 				Positive: true,
@@ -646,7 +659,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 			},
 			CmdSave: {
 				Shortcut: "F6",
-				Keys:     []tcell.Key{tcell.KeyF6},
+				Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 				MenuKey:  "",
 				Text:     "Save",
 				Positive: true,
@@ -654,7 +667,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 			},
 			CmdCancel: {
 				Shortcut: "F8",
-				Keys:     []tcell.Key{tcell.KeyF8},
+				Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 				MenuKey:  "",
 				Text:     "Cancel",
 				Positive: false,
@@ -662,7 +675,7 @@ func TestLoadConfErrorMessages(t *testing.T) {
 			},
 			CmdQuit: {
 				Shortcut: "Esc",
-				Keys:     []tcell.Key{tcell.KeyEsc},
+				Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 				MenuKey:  "",
 				Text:     "Quit",
 				Positive: false,
@@ -701,12 +714,12 @@ func TestLoadConfErrorMessages(t *testing.T) {
 
 			// Setup test common.MenuItems
 			common.MenuItems = createTestMenuItems()
-			common.Shortcuts = make(map[tcell.Key]int)
+			common.Shortcuts = make(map[common.Hotkey]int)
 
 			ioReader := strings.NewReader(tt.input)
 
 			var actualErr error
-			actualErr = loadConf(func(menuKey, text, shortcut string, isPositive bool) {}, ioReader)
+			actualErr = loadConf(func(menuKey, text, shortcut string, isPositive bool) {}, ioReader, nil)
 
 			// Verify error occurred
 			assert.Error(t, actualErr, "expected error for test case: %s", tt.name)
@@ -730,7 +743,7 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 	common.MenuItems = map[int]common.MenuItem{
 		CmdSearch: {
 			Shortcut: "F2",
-			Keys:     []tcell.Key{tcell.KeyF2},
+			Keys:     []common.Hotkey{{Key: tcell.KeyF2}},
 			MenuKey:  "search",
 			Text:     "Search",
 			Positive: false,
@@ -738,7 +751,7 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 		},
 		CmdList: {
 			Shortcut: "F3",
-			Keys:     []tcell.Key{tcell.KeyF3},
+			Keys:     []common.Hotkey{{Key: tcell.KeyF3}},
 			MenuKey:  "list",
 			Text:     "List",
 			Positive: false,
@@ -746,7 +759,7 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 		},
 		CmdRefresh: {
 			Shortcut: "F5",
-			Keys:     []tcell.Key{tcell.KeyF5},
+			Keys:     []common.Hotkey{{Key: tcell.KeyF5}},
 			MenuKey:  "",
 			Text:     "Refresh",
 			Positive: true,
@@ -754,7 +767,7 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 		},
 		CmdSave: {
 			Shortcut: "F6",
-			Keys:     []tcell.Key{tcell.KeyF6},
+			Keys:     []common.Hotkey{{Key: tcell.KeyF6}},
 			MenuKey:  "",
 			Text:     "Save",
 			Positive: true,
@@ -762,7 +775,7 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 		},
 		CmdCancel: {
 			Shortcut: "F8",
-			Keys:     []tcell.Key{tcell.KeyF8},
+			Keys:     []common.Hotkey{{Key: tcell.KeyF8}},
 			MenuKey:  "",
 			Text:     "Cancel",
 			Positive: false,
@@ -770,27 +783,686 @@ func TestLoadConfShortcutsPopulated(t *testing.T) {
 		},
 		CmdQuit: {
 			Shortcut: "Esc",
-			Keys:     []tcell.Key{tcell.KeyEsc},
+			Keys:     []common.Hotkey{{Key: tcell.KeyEsc}},
 			MenuKey:  "",
 			Text:     "Quit",
 			Positive: false,
 			IsDef:    true,
 		},
 	}
-	common.Shortcuts = make(map[tcell.Key]int)
+	common.Shortcuts = make(map[common.Hotkey]int)
 
-	err := loadConf(func(menuKey, text, shortcut string, isPositive bool) {}, nil)
+	err := loadConf(func(menuKey, text, shortcut string, isPositive bool) {}, nil, nil)
 	assert.NoError(t, err)
 
 	// Verify all default shortcuts are in the map
-	assert.Equal(t, CmdSearch, common.Shortcuts[tcell.KeyF2], "F2 should map to CmdSearch")
-	assert.Equal(t, CmdList, common.Shortcuts[tcell.KeyF3], "F3 should map to CmdList")
-	assert.Equal(t, CmdRefresh, common.Shortcuts[tcell.KeyF5], "F5 should map to CmdRefresh")
-	assert.Equal(t, CmdSave, common.Shortcuts[tcell.KeyF6], "F6 should map to CmdSave")
-	assert.Equal(t, CmdCancel, common.Shortcuts[tcell.KeyF8], "F8 should map to CmdCancel")
-	assert.Equal(t, CmdQuit, common.Shortcuts[tcell.KeyEsc], "Esc should map to CmdQuit")
+	assert.Equal(t, CmdSearch, common.Shortcuts[common.KeyOf(tcell.KeyF2)], "F2 should map to CmdSearch")
+	assert.Equal(t, CmdList, common.Shortcuts[common.KeyOf(tcell.KeyF3)], "F3 should map to CmdList")
+	assert.Equal(t, CmdRefresh, common.Shortcuts[common.KeyOf(tcell.KeyF5)], "F5 should map to CmdRefresh")
+	assert.Equal(t, CmdSave, common.Shortcuts[common.KeyOf(tcell.KeyF6)], "F6 should map to CmdSave")
+	assert.Equal(t, CmdCancel, common.Shortcuts[common.KeyOf(tcell.KeyF8)], "F8 should map to CmdCancel")
+	assert.Equal(t, CmdQuit, common.Shortcuts[common.KeyOf(tcell.KeyEsc)], "Esc should map to CmdQuit")
 
 	// Restore original state
 	common.MenuItems = originalMenuItems
 	common.Shortcuts = originalShortcuts
 }
+
+func TestLoadConfBindings(t *testing.T) {
+	originalMenuItems := common.MenuItems
+	originalShortcuts := common.Shortcuts
+	originalScoped := common.ScopedShortcuts
+	defer func() {
+		common.MenuItems, common.Shortcuts, common.ScopedShortcuts = originalMenuItems, originalShortcuts,
+			originalScoped
+	}()
+
+	ctrlS, _ := common.ParseHotkey("Ctrl-S")
+	altX, _ := common.ParseHotkey("Alt-x")
+	j, _ := common.ParseHotkey("j")
+	space, _ := common.ParseHotkey("Space")
+
+	tests := []struct {
+		name          string
+		input         string
+		wantErrSubstr []string
+		wantGlobal    map[common.Hotkey]int
+		wantScoped    map[string]map[common.Hotkey]int
+		wantShortcut  string
+	}{
+		{
+			name: "modifiers, runes and actions",
+			input: `keyboardShortcuts:
+  F2: Search
+  Ctrl-S: Save
+  F6: Save
+  Alt-x: Quit
+  Esc: Quit
+  j: Next row`,
+			wantGlobal: map[common.Hotkey]int{
+				common.KeyOf(tcell.KeyF2): CmdSearch, ctrlS: CmdSave, common.KeyOf(tcell.KeyF6): CmdSave,
+				altX: CmdQuit, common.KeyOf(tcell.KeyEsc): CmdQuit, j: ActNextRow,
+			},
+			wantScoped:   map[string]map[common.Hotkey]int{},
+			wantShortcut: "Ctrl-S; F6",
+		},
+		{
+			name: "per page and popup bindings",
+			input: `keyboardShortcuts:
+  F2: Search
+  pages:
+    list:
+      j: Next row
+      F2: Save
+  popups:
+    claim:
+      Space: Toggle claim`,
+			wantGlobal: map[common.Hotkey]int{
+				common.KeyOf(tcell.KeyF2): CmdSearch, common.KeyOf(tcell.KeyF6): CmdSave,
+				common.KeyOf(tcell.KeyEsc): CmdQuit,
+			},
+			wantScoped: map[string]map[common.Hotkey]int{
+				"list":  {j: ActNextRow, common.KeyOf(tcell.KeyF2): CmdSave},
+				"claim": {space: ActToggleClaim},
+			},
+		},
+		{
+			name: "command names and legacy section",
+			input: `Gyorsbillentyűk:
+  F2: search
+  Ctrl-S: save
+  Esc: quit
+  pages:
+    list:
+      j: nextRow
+      Space: toggleClaim`,
+			wantGlobal: map[common.Hotkey]int{
+				common.KeyOf(tcell.KeyF2): CmdSearch, ctrlS: CmdSave, common.KeyOf(tcell.KeyEsc): CmdQuit,
+			},
+			wantScoped: map[string]map[common.Hotkey]int{"list": {j: ActNextRow, space: ActToggleClaim}},
+		},
+		{
+			name: "same key twice",
+			input: `keyboardShortcuts:
+  F2: Search
+  Ctrl-S: Save
+  ctrl-s: Quit`,
+			wantErrSubstr: []string{"line 4: ctrl-s is already bound on line 3"},
+		},
+		{
+			name: "same key twice on a page",
+			input: `keyboardShortcuts:
+  pages:
+    list:
+      Alt-X: Save
+      Alt-Shift-x: Quit`,
+			wantErrSubstr: []string{"line 5: Alt-Shift-x is already bound on line 4"},
+		},
+		{
+			name: "default shortcut of another command",
+			input: `keyboardShortcuts:
+  F6: Search`,
+			wantErrSubstr: []string{"line 2: F6 is the default shortcut of Save"},
+		},
+		{
+			name: "unknown page",
+			input: `keyboardShortcuts:
+  pages:
+    nowhere:
+      j: Next row`,
+			wantErrSubstr: []string{"line 3: no such page or popup for keyboard shortcuts: nowhere"},
+		},
+		{
+			name: "unknown keys with lines",
+			input: `keyboardShortcuts:
+  F2: Search
+  Hyper-x: Save
+  pages:
+    list:
+      invalidKey: Quit`,
+			wantErrSubstr: []string{"Hyper-x (line 3)", "invalidKey (line 6)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.MenuItems = map[int]common.MenuItem{
+				CmdSearch:      {Shortcut: "F2", Keys: []common.Hotkey{{Key: tcell.KeyF2}}, Text: "Search", IsDef: true},
+				CmdSave:        {Shortcut: "F6", Keys: []common.Hotkey{{Key: tcell.KeyF6}}, Text: "Save", IsDef: true},
+				CmdQuit:        {Shortcut: "Esc", Keys: []common.Hotkey{{Key: tcell.KeyEsc}}, Text: "Quit", IsDef: true},
+				ActNextRow:     {Text: "Next row", IsDef: true},
+				ActToggleClaim: {Text: "Toggle claim", IsDef: true},
+			}
+			common.Shortcuts = map[common.Hotkey]int{}
+			common.ScopedShortcuts = map[string]map[common.Hotkey]int{}
+
+			var menu []string
+			err := loadConf(func(_, text, _ string, _ bool) { menu = append(menu, text) },
+				strings.NewReader(tt.input), nil)
+			if len(tt.wantErrSubstr) > 0 {
+				require.Error(t, err)
+				for _, sub := range tt.wantErrSubstr {
+					assert.Contains(t, err.Error(), sub)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGlobal, common.Shortcuts)
+			assert.Equal(t, tt.wantScoped, common.ScopedShortcuts)
+			assert.Equal(t, []string{"Search", "Save", "Quit"}, menu, "actions are not in the menu")
+			if len(tt.wantShortcut) > 0 {
+				assert.Equal(t, tt.wantShortcut, common.MenuItems[CmdSave].Shortcut)
+			}
+		})
+	}
+}
+
+func TestLoadRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantRoles map[string]common.Role
+		wantMsg   string
+	}{
+		{
+			name:  "no roles section",
+			input: "keyboardShortcuts: {F2: Search}",
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+		{
+			name: "valid roles",
+			input: `keyboardShortcuts: {F2: Search}
+roles:
+  auditor:
+    admin:
+    consultant: 3m
+  boss:
+    superAdmin: 1y`,
+			wantRoles: map[string]common.Role{
+				"auditor": {common.Admin: "", common.Consultant: "3m"},
+				"boss":    {common.SuperAdmin: "1y"},
+			},
+		},
+		{
+			name: "unknown permission",
+			input: `roles:
+  auditor:
+    janitor: 3m`,
+			wantMsg: "role template auditor has an unknown permission: janitor",
+		},
+		{
+			name: "invalid duration",
+			input: `roles:
+  auditor:
+    admin: 3x`,
+			wantMsg: "role template auditor, permission admin: invalid unit: x",
+		},
+		{
+			name: "hours stored as a day",
+			input: `roles:
+  oncall:
+    admin: 12h`,
+			wantMsg: "role template oncall, permission admin: hours and times of day need storeTimestamps",
+		},
+		{
+			name:    "invalid yaml",
+			input:   "roles: [unclosed",
+			wantMsg: "error while parsing config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Roles = nil
+
+			err := loadRoles(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.ErrorContains(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRoles, common.Roles)
+		})
+	}
+
+	common.Roles = map[string]common.Role{}
+}
+
+func TestLoadApproval(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantPerms []string
+		wantMsg   string
+	}{
+		{
+			name:  "no approval section",
+			input: "keyboardShortcuts: {F2: Search}",
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+		{
+			name: "valid permissions",
+			input: `approval:
+  permissions: [superAdmin, admin]`,
+			wantPerms: []string{common.SuperAdmin, common.Admin},
+		},
+		{
+			name: "unknown permission",
+			input: `approval:
+  permissions: [janitor]`,
+			wantMsg: "approval has an unknown permission: janitor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ApprovalPerms = nil
+
+			err := loadApproval(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPerms, ApprovalPerms)
+		})
+	}
+
+	ApprovalPerms = nil
+}
+
+func TestLoadOperators(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantSource string
+		wantPerms  map[string][]string
+		wantMsg    string
+	}{
+		{
+			name:  "no operators section",
+			input: "keyboardShortcuts: {F2: Search}",
+		},
+		{
+			name: "config by default",
+			input: `operators:
+  permissions:
+    alice: [admin]`,
+			wantSource: OperatorsConfig,
+			wantPerms:  map[string][]string{"alice": {common.Admin}},
+		},
+		{
+			name: "firestore",
+			input: `operators:
+  source: firestore`,
+			wantSource: OperatorsFirestore,
+		},
+		{
+			name: "unknown source",
+			input: `operators:
+  source: ldap`,
+			wantMsg: "unknown operators source: ldap, use config or firestore",
+		},
+		{
+			name: "unknown permission",
+			input: `operators:
+  permissions:
+    alice: [janitor]`,
+			wantMsg: "operator alice has an unknown permission: janitor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			OperatorSource, OperatorPerms = "", nil
+
+			err := loadOperators(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSource, OperatorSource)
+			assert.Equal(t, tt.wantPerms, OperatorPerms)
+		})
+	}
+
+	OperatorSource, OperatorPerms = "", nil
+}
+
+func TestForbidden(t *testing.T) {
+	defer func() { Grantable = nil }()
+	actions := map[string]common.ClaimsMap{
+		"uid1": {common.Admin: {Checked: true}},
+		"uid2": {common.SuperAdmin: {}, common.Admin: {}},
+	}
+
+	Grantable = nil
+	assert.Empty(t, Forbidden(actions), "all allowed without an allowlist")
+
+	SetGrantable([]string{common.Admin})
+	assert.True(t, CanGrant(common.Admin))
+	assert.False(t, CanGrant(common.SuperAdmin))
+	assert.Equal(t, []string{common.SuperAdmin}, Forbidden(actions), "revoking needs the permission too")
+}
+
+func TestLoadProfile(t *testing.T) {
+	input := `profiles:
+  support:
+    readOnly: true
+  admin:
+    credentials: adc
+    impersonate: firemage@project.iam.gserviceaccount.com
+    project: project`
+
+	tests := []struct {
+		name            string
+		profile         string
+		readOnly        bool
+		project         string
+		wantReadOnly    bool
+		wantCredentials string
+		wantImpersonate string
+		wantProject     string
+		wantMsg         string
+	}{
+		{name: "read-only profile", profile: "support", wantReadOnly: true},
+		{
+			name: "flags stay", profile: "admin", readOnly: true, project: "other",
+			wantReadOnly: true, wantCredentials: CredentialsADC,
+			wantImpersonate: "firemage@project.iam.gserviceaccount.com", wantProject: "other",
+		},
+		{
+			name: "writable profile", profile: "admin", wantCredentials: CredentialsADC,
+			wantImpersonate: "firemage@project.iam.gserviceaccount.com", wantProject: "project",
+		},
+		{name: "unknown profile", profile: "janitor", wantMsg: "profile not found in the config file: janitor"},
+	}
+
+	reset := func() { Profile, ReadOnly, Credentials, KeyPath, Impersonate, Project = "", false, "", "", "", "" }
+	defer reset()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset()
+			Profile, ReadOnly, Project = tt.profile, tt.readOnly, tt.project
+
+			err := loadProfile(strings.NewReader(input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReadOnly, ReadOnly)
+			assert.Equal(t, tt.wantCredentials, Credentials)
+			assert.Equal(t, tt.wantImpersonate, Impersonate)
+			assert.Equal(t, tt.wantProject, Project)
+		})
+	}
+}
+
+func TestHideReadOnly(t *testing.T) {
+	originalMenuItems := common.MenuItems
+	defer func() { common.MenuItems, ReadOnly = originalMenuItems, false }()
+
+	common.MenuItems = map[int]common.MenuItem{
+		CmdList: {Text: "List", Keys: []common.Hotkey{{Key: tcell.KeyF3}}, IsDef: true},
+		CmdSave: {Text: "Save", Keys: []common.Hotkey{{Key: tcell.KeyF6}}, IsDef: true},
+		CmdRole: {Text: "Apply role", Keys: []common.Hotkey{{Key: tcell.KeyF7}}, IsDef: true},
+	}
+
+	assert.Empty(t, hideReadOnly())
+	assert.Len(t, common.MenuItems, 3)
+
+	ReadOnly = true
+	assert.Equal(t, map[string]struct{}{"Save": {}, "Apply role": {}, "save": {}, "applyRole": {}}, hideReadOnly())
+	assert.Equal(t, []int{CmdList}, slices.Collect(maps.Keys(common.MenuItems)))
+
+	common.Shortcuts = make(map[common.Hotkey]int)
+	err := loadConf(func(string, string, string, bool) {},
+		strings.NewReader("keyboardShortcuts: {F3: list, F6: save}"), map[string]struct{}{"save": {}})
+	assert.NoError(t, err, "shortcuts of hidden commands are skipped")
+}
+
+func TestNeedsApproval(t *testing.T) {
+	ApprovalPerms = []string{common.SuperAdmin}
+	defer func() { ApprovalPerms = nil }()
+
+	assert.False(t, NeedsApproval(map[string]common.ClaimsMap{}))
+	assert.False(t, NeedsApproval(map[string]common.ClaimsMap{"uid1": {common.Admin: {Checked: true}}}))
+	assert.True(t, NeedsApproval(map[string]common.ClaimsMap{
+		"uid1": {common.Admin: {Checked: true}},
+		"uid2": {common.SuperAdmin: {}},
+	}))
+}
+
+func TestConfLanguage(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  string
+		wantCode string
+		wantErr  bool
+	}{
+		{name: "no config file"},
+		{name: "not set", content: "keyboardShortcuts: {F2: Search}"},
+		{name: "set", content: "language: hu\nkeyboardShortcuts: {F2: Kereső}", wantCode: "hu"},
+		{name: "invalid", content: "language: [", wantErr: true},
+	}
+
+	defer func(p string) { ConfPath = p }(ConfPath)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ConfPath = filepath.Join(dir, fmt.Sprintf("conf%d.yml", i))
+			if len(tt.content) > 0 {
+				require.NoError(t, os.WriteFile(ConfPath, []byte(tt.content), 0o600))
+			}
+
+			code, err := ConfLanguage()
+
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantCode, code)
+		})
+	}
+}
+
+func TestLoadDateFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		built      string
+		wantFormat string
+		wantMsg    string
+	}{
+		{name: "language default", input: "keyboardShortcuts: {F2: Search}", wantFormat: lang.DateLayout},
+		{name: "configured", input: "dateFormat: 02/01/2006", wantFormat: "02/01/2006"},
+		{name: "built in", built: "02.01.2006", wantFormat: "02.01.2006"},
+		{name: "configured over built in", input: "dateFormat: 02/01/2006", built: "02.01.2006", wantFormat: "02/01/2006"},
+		{
+			name: "no day", input: "dateFormat: 2006-01",
+			wantMsg: "invalid date format in the config file: 2006-01, it needs a year, a month and a day, like 2006-01-02",
+		},
+	}
+
+	defer func(layout, built string) {
+		common.DateFormat, common.BuiltDateFormat = layout, built
+	}(common.DateFormat, common.BuiltDateFormat)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.DateFormat, common.BuiltDateFormat = common.StoreDateFormat, tt.built
+
+			err := loadDateFormat(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				assert.Equal(t, common.StoreDateFormat, common.DateFormat)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFormat, common.DateFormat)
+		})
+	}
+}
+
+func TestLoadExpiry(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		wantLocation   string
+		wantTimestamps bool
+		wantMsg        string
+	}{
+		{name: "defaults", input: "dateFormat: 2006-01-02", wantLocation: "UTC"},
+		{
+			name: "configured", input: "timezone: Europe/Budapest\nstoreTimestamps: true",
+			wantLocation: "Europe/Budapest", wantTimestamps: true,
+		},
+		{
+			name: "unknown timezone", input: "timezone: Mars/Olympus",
+			wantMsg: "unknown timezone in the config file: unknown time zone Mars/Olympus",
+		},
+	}
+
+	defer func(loc *time.Location, stamps bool) {
+		common.ExpiryLocation, common.StoreTimestamps = loc, stamps
+	}(common.ExpiryLocation, common.StoreTimestamps)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.ExpiryLocation, common.StoreTimestamps = time.UTC, false
+
+			err := loadExpiry(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				assert.Equal(t, time.UTC, common.ExpiryLocation)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantLocation, common.ExpiryLocation.String())
+			assert.Equal(t, tt.wantTimestamps, common.StoreTimestamps)
+		})
+	}
+}
+
+func TestLoadTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		noColor string
+		want    string
+		check   func(t *testing.T, theme common.Theme)
+		wantMsg string
+	}{
+		{name: "default", input: "language: en", want: common.ThemeDark},
+		{name: "built-in", input: "theme: light", want: common.ThemeLight},
+		{name: "NO_COLOR", input: "theme: light", noColor: "1", want: common.ThemeMonochrome},
+		{
+			name: "custom", input: "theme: mine\nthemes:\n  mine:\n    base: light\n    accent: '#ff8800'\n    stripeText: Purple",
+			check: func(t *testing.T, theme common.Theme) {
+				assert.Equal(t, tcell.NewHexColor(0xff8800), theme.Accent)
+				assert.Equal(t, tcell.ColorPurple, theme.StripeText)
+				assert.Equal(t, common.Themes[common.ThemeLight].Base, theme.Base)
+			},
+		},
+		{
+			name: "built-in changed", input: "themes:\n  dark:\n    positive: lime\n    background: default",
+			check: func(t *testing.T, theme common.Theme) {
+				assert.Equal(t, tcell.ColorLime, theme.Positive)
+				assert.Equal(t, tcell.ColorDefault, theme.Base.PrimitiveBackgroundColor)
+				assert.Equal(t, common.Themes[common.ThemeDark].Negative, theme.Negative)
+			},
+		},
+		{
+			name: "unknown theme", input: "theme: solarized",
+			wantMsg: "no such theme in the config file: solarized, the built-in ones are dark, high-contrast, " +
+				"light, monochrome",
+		},
+		{
+			name: "unknown base", input: "themes:\n  mine:\n    base: solarized",
+			wantMsg: "no such theme in the config file: solarized, the built-in ones are dark, high-contrast, " +
+				"light, monochrome",
+		},
+		{
+			name: "unknown color", input: "themes:\n  mine:\n    shadow: black",
+			wantMsg: "theme mine: no such color: shadow",
+		},
+		{
+			name: "invalid value", input: "themes:\n  mine:\n    border: blurple",
+			wantMsg: "theme mine: invalid value of border: blurple",
+		},
+	}
+
+	defer func(theme common.Theme) { common.CurrentTheme = theme }(common.CurrentTheme)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			common.CurrentTheme = common.Themes[common.ThemeDark]
+
+			err := loadTheme(strings.NewReader(tt.input))
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			if tt.check != nil {
+				tt.check(t, common.CurrentTheme)
+				return
+			}
+			assert.Equal(t, common.Themes[tt.want], common.CurrentTheme)
+		})
+	}
+}
+
+func TestCheckFlags(t *testing.T) {
+	defer func(batch, retries int, backoff, timeout time.Duration) {
+		BatchSize, Retries, Backoff, Timeout = batch, retries, backoff, timeout
+	}(BatchSize, Retries, Backoff, Timeout)
+
+	tests := []struct {
+		name    string
+		batch   int
+		retries int
+		backoff time.Duration
+		timeout time.Duration
+		wantErr error
+	}{
+		{name: "defaults", batch: 100, retries: 4, backoff: 250 * time.Millisecond, timeout: 12 * time.Second},
+		{name: "no backoff", batch: 1, backoff: 0, timeout: time.Second},
+		{name: "batch too small", batch: 0, timeout: time.Second, wantErr: ErrFlags},
+		{name: "batch too large", batch: 101, timeout: time.Second, wantErr: ErrFlags},
+		{name: "negative retries", batch: 1, retries: -1, timeout: time.Second, wantErr: ErrFlags},
+		{name: "negative backoff", batch: 1, backoff: -time.Second, timeout: time.Second, wantErr: ErrFlags},
+		{name: "zero timeout", batch: 1, wantErr: ErrFlags},
+		{name: "negative timeout", batch: 1, timeout: -time.Second, wantErr: ErrFlags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			BatchSize, Retries, Backoff, Timeout = tt.batch, tt.retries, tt.backoff, tt.timeout
+			assert.Equal(t, tt.wantErr, CheckFlags())
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var (
+	ErrSelfApprove = lang.NewError(&lang.ErrSelfApproveS)
+	ErrNoPending   = lang.NewError(&lang.ErrNoPendingS)
+)
+
+// Propose stores the pending actions as a change set waiting for the approval of another operator
+// instead of saving them, in the background. Only operators in the allowlist can propose. Errors of
+// starting are returned, otherwise done is called with the result.
+func Propose(s *global.Session, done func(error)) error {
+	if err := checkOperator(conf.Operator); err != nil {
+		return err
+	}
+
+	p := &common.PendingChange{
+		Operator: conf.Operator,
+		Created:  time.Now(),
+		Emails:   make(map[string]string, len(s.Actions)),
+		Before:   make(map[string]map[string]any, len(s.Actions)),
+		After:    make(map[string]map[string]any, len(s.Actions)),
+	}
+
+	for uid, acts := range s.Actions {
+		u, ok := s.LocalUsers[uid]
+		if ok {
+			p.Emails[uid] = u.Email
+		}
+
+		before, after := make(map[string]any, len(acts)), make(map[string]any, len(acts))
+		for perm, c := range acts {
+			after[perm] = c.ToAny()
+			before[perm] = false
+			if ok && u.Claims[perm] != nil {
+				before[perm] = u.Claims[perm].ToAny()
+			}
+		}
+		p.Before[uid], p.After[uid] = before, after
+	}
+
+	ctx, cancel, err := startJob(s, conf.Timeout)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer cancel()
+
+		err := common.Fb.AddPending(ctx, p)
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			if err != nil {
+				done(canceled(err))
+				return
+			}
+
+			clear(s.Actions)
+			done(nil)
+		})
+	}()
+
+	return nil
+}
+
+// LoadPending downloads the change sets waiting for approval in the background. Errors of starting
+// are returned, otherwise done is called with the result.
+func LoadPending(s *global.Session, done func(error)) error {
+	return deletePending(s, "", done)
+}
+
+// deletePending removes the change set with the given ID if any, then downloads the remaining ones
+// in the background.
+func deletePending(s *global.Session, id string, done func(error)) error {
+	ctx, cancel, err := startJob(s, conf.Timeout)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer cancel()
+
+		var ps []*common.PendingChange
+		err := deleteAndLoad(ctx, id, &ps)
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			if err != nil {
+				done(canceled(err))
+				return
+			}
+
+			s.Pending = ps
+			done(nil)
+		})
+	}()
+
+	return nil
+}
+
+// deleteAndLoad deletes the change set with the given ID if any, then downloads the ones waiting
+// for approval into ps.
+func deleteAndLoad(ctx context.Context, id string, ps *[]*common.PendingChange) error {
+	if len(id) > 0 {
+		if err := common.Fb.DeletePending(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if *ps, err = common.Fb.GetPending(ctx); err != nil {
+		return fmt.Errorf(lang.ErrPending, err)
+	}
+
+	return nil
+}
+
+// Approve saves the i-th pending change set of another operator through the usual save transaction,
+// then removes it from the pending ones. Both operators need to be in the allowlist. Users whose
+// permissions changed since the proposal fail it, except the ones already changed as proposed.
+// Broken permission rules need a confirmation, like saving. Errors of the checks are returned,
+// otherwise done is called with the result of the background work, or ErrCanceled if the
+// confirmation is refused.
+func Approve(s *global.Session, i int, done func(error)) error {
+	if i < 0 || i >= len(s.Pending) {
+		return ErrNoPending
+	}
+
+	if s.Busy() {
+		return ErrBusy
+	}
+
+	p := s.Pending[i]
+	if p.Operator == conf.Operator {
+		return ErrSelfApprove
+	}
+
+	if len(s.Actions) > 0 {
+		return common.ErrActions
+	}
+
+	if err := checkOperator(conf.Operator); err != nil {
+		return err
+	}
+	if err := checkOperator(p.Operator); err != nil {
+		return err
+	}
+
+	uids := slices.Sorted(maps.Keys(p.After))
+	return FetchUsers(s, uids, func(_ []string, err error) {
+		if err != nil {
+			done(err)
+			return
+		}
+
+		if err := approveFetched(s, p, uids, done); err != nil {
+			done(err)
+		}
+	})
+}
+
+// approveFetched saves a pending change set after downloading its users. Errors of starting are
+// returned, otherwise done is called with the result.
+func approveFetched(s *global.Session, p *common.PendingChange, uids []string, done func(error)) error {
+	if err := stagePending(s, p, uids); err != nil {
+		clear(s.Actions)
+		return err
+	}
+
+	if len(s.Actions) == 0 { // stored already, by an earlier approval failing halfway
+		return deletePending(s, p.ID, done)
+	}
+
+	save := func() {
+		DoSave(s, func(err error) {
+			if err != nil {
+				clear(s.Actions) // the change set stays pending, the users stored are skipped next time
+				done(err)
+				return
+			}
+
+			if err := deletePending(s, p.ID, done); err != nil {
+				done(err)
+			}
+		})
+	}
+
+	if WriteViolations(s) {
+		window.WriteErrorStr(lang.ConfirmRulesS)
+		window.ShowConfirm(save, func() {
+			clear(s.Actions)
+			done(ErrCanceled)
+		})
+		return nil
+	}
+
+	save()
+	return nil
+}
+
+// stagePending adds the changes of a pending change set to the actions of the given users. Claims
+// already equal to the proposed ones are skipped, others need to be the same as at the proposal.
+func stagePending(s *global.Session, p *common.PendingChange, uids []string) error {
+	for _, uid := range uids {
+		u, ok := s.LocalUsers[uid]
+		if !ok {
+			continue // reported as removed by FetchUsers
+		}
+
+		before, err := common.NewClaimsMapFrom(p.Before[uid])
+		if err != nil {
+			return err
+		}
+
+		after, err := common.NewClaimsMapFrom(p.After[uid])
+		if err != nil {
+			return err
+		}
+
+		acts := common.ClaimsMap{}
+		for perm := range p.After[uid] {
+			if !(*after)[perm].Differs(u.Claims[perm]) {
+				continue
+			}
+			if (*before)[perm].Differs(u.Claims[perm]) {
+				return fmt.Errorf(lang.ErrPendingStale, u.Email)
+			}
+			acts[perm] = (*after)[perm]
+		}
+		if len(acts) > 0 {
+			s.Actions[uid] = acts
+		}
+	}
+
+	return nil
+}
+
+// Reject removes the i-th pending change set without saving it in the background. Errors of
+// starting are returned, otherwise done is called with the result.
+func Reject(s *global.Session, i int, done func(error)) error {
+	if i < 0 || i >= len(s.Pending) {
+		return ErrNoPending
+	}
+
+	return deletePending(s, s.Pending[i].ID, done)
+}
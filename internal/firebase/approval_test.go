@@ -0,0 +1,295 @@
+package firebase
+
+import (
+	"context"
+	"testing"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestPropose(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	conf.Operator = "alice"
+	s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()}}
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.SuperAdmin: {Checked: true}}}
+
+	done := make(chan error, 1)
+	assert.ErrorIs(t, Propose(s, func(err error) { done <- err }), ErrNoAllowlist)
+	assert.NotEmpty(t, s.Actions)
+
+	conf.OperatorSource, conf.OperatorPerms = conf.OperatorsConfig, map[string][]string{"alice": nil}
+	var got *common.PendingChange
+	mockFb.EXPECT().AddPending(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *common.PendingChange) error {
+		got = p
+		return nil
+	}).Times(1)
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+
+	assert.NoError(t, Propose(s, func(err error) { done <- err }))
+	assert.NoError(t, <-done)
+	assert.Empty(t, s.Actions)
+	assert.Equal(t, "alice", got.Operator)
+	assert.Equal(t, map[string]string{"uid1": "user1@example.com"}, got.Emails)
+	assert.Equal(t, map[string]any{common.SuperAdmin: false}, got.Before["uid1"])
+	assert.Equal(t, map[string]any{common.SuperAdmin: true}, got.After["uid1"])
+
+	conf.Operator, conf.OperatorSource, conf.OperatorPerms = "", "", nil
+}
+
+func TestApprove(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	pending := func(before bool) *common.PendingChange {
+		return &common.PendingChange{
+			ID:       "p1",
+			Operator: "alice",
+			Emails:   map[string]string{"uid1": "user1@example.com"},
+			Before:   map[string]map[string]any{"uid1": {common.SuperAdmin: before}},
+			After:    map[string]map[string]any{"uid1": {common.SuperAdmin: !before}},
+		}
+	}
+	user := func(claims map[string]any) *auth.UserRecord {
+		return &auth.UserRecord{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"}, CustomClaims: claims}
+	}
+	admin := user(map[string]any{common.Admin: true})
+	allowed := map[string][]string{"alice": nil, "bob": nil}
+
+	tests := []struct {
+		name      string
+		operator  string
+		operators map[string][]string
+		index     int
+		actions   map[string]map[string]any
+		pending   *common.PendingChange
+		user      *auth.UserRecord
+		override  *bool
+		busy      bool
+		wantSave  bool
+		wantStore bool
+		wantError error
+		wantMsg   string
+	}{
+		{
+			name:      "nothing selected",
+			operator:  "bob",
+			operators: allowed,
+			index:     -1,
+			pending:   pending(false),
+			wantError: ErrNoPending,
+		},
+		{
+			name:      "another job runs",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			busy:      true,
+			wantError: ErrBusy,
+		},
+		{
+			name:      "own change set",
+			operator:  "alice",
+			operators: allowed,
+			pending:   pending(false),
+			wantError: ErrSelfApprove,
+		},
+		{
+			name:      "pending actions",
+			operator:  "bob",
+			operators: allowed,
+			actions:   map[string]map[string]any{"uid2": {common.Admin: true}},
+			pending:   pending(false),
+			wantError: common.ErrActions,
+		},
+		{
+			name:      "no allowlist",
+			operator:  "bob",
+			pending:   pending(false),
+			wantError: ErrNoAllowlist,
+		},
+		{
+			name:      "approver not in the allowlist",
+			operator:  "mallory",
+			operators: allowed,
+			pending:   pending(false),
+			wantError: ErrNotOperator,
+		},
+		{
+			name:      "proposer not in the allowlist",
+			operator:  "bob",
+			operators: map[string][]string{"bob": nil},
+			pending:   pending(false),
+			wantMsg:   "operator alice: operator is not on the allowlist",
+		},
+		{
+			name:      "changed since proposal",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			user:      user(map[string]any{common.Admin: true, common.SuperAdmin: "2030-01-01"}),
+			wantMsg:   "permissions of user1@example.com changed since the proposal, reject it and propose again",
+		},
+		{
+			name:      "approved",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			user:      admin,
+			wantSave:  true,
+			wantStore: true,
+		},
+		{
+			name:      "stored already by an earlier approval",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			user:      user(map[string]any{common.Admin: true, common.SuperAdmin: true}),
+			wantSave:  true,
+		},
+		{
+			name:      "broken rules refused",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			user:      user(nil),
+			override:  new(bool),
+			wantError: ErrCanceled,
+		},
+		{
+			name:      "broken rules confirmed",
+			operator:  "bob",
+			operators: allowed,
+			pending:   pending(false),
+			user:      user(nil),
+			override:  func() *bool { b := true; return &b }(),
+			wantSave:  true,
+			wantStore: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+
+			conf.Operator = tt.operator
+			if tt.operators != nil {
+				conf.OperatorSource, conf.OperatorPerms = conf.OperatorsConfig, tt.operators
+			}
+			s.Pending = []*common.PendingChange{tt.pending}
+			s.LocalUsers = map[string]*global.User{}
+			s.Actions = testutil.BuildActionsMap(tt.actions)
+			if tt.busy {
+				s.StartJob()
+				defer s.EndJob()
+			}
+
+			jobs := 0
+			if tt.user != nil {
+				jobs++
+				mockFb.EXPECT().GetUsers(gomock.Any(), []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid1"}}).
+					Return(&auth.GetUsersResult{Users: []*auth.UserRecord{tt.user}}, nil).Times(1)
+			}
+
+			if tt.override != nil {
+				mockFe.EXPECT().ShowConfirm(gomock.Any(), gomock.Any()).DoAndReturn(func(onYes, onNo func(), _ ...string) {
+					window.PopBuffer()
+					if *tt.override {
+						onYes()
+					} else {
+						onNo()
+					}
+				}).Times(1)
+			}
+
+			if tt.wantStore {
+				jobs++
+				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+			if tt.wantSave {
+				jobs++
+				mockFb.EXPECT().DeletePending(gomock.Any(), "p1").Return(nil).Times(1)
+				mockFb.EXPECT().GetPending(gomock.Any()).Return(nil, nil).Times(1)
+			}
+			mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(jobs)
+			mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(jobs)
+
+			done := make(chan error, 1)
+			err := Approve(s, tt.index, func(err error) { done <- err })
+			if err == nil {
+				err = <-done
+			}
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				assert.Empty(t, s.Actions)
+			} else {
+				assert.ErrorIs(t, err, tt.wantError)
+			}
+
+			if tt.wantSave || tt.override != nil {
+				assert.Empty(t, s.Actions)
+			}
+			if tt.wantSave {
+				assert.Empty(t, s.Pending)
+			}
+
+			conf.Operator, conf.OperatorSource, conf.OperatorPerms = "", "", nil
+			window.SetPopups()
+		})
+	}
+}
+
+func TestReject(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	done := make(chan error, 1)
+	s.Pending = []*common.PendingChange{{ID: "p1", Operator: "alice"}}
+	assert.ErrorIs(t, Reject(s, 1, func(err error) { done <- err }), ErrNoPending)
+
+	mockFb.EXPECT().DeletePending(gomock.Any(), "p1").Return(nil).Times(1)
+	mockFb.EXPECT().GetPending(gomock.Any()).Return(nil, nil).Times(1)
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+
+	assert.NoError(t, Reject(s, 0, func(err error) { done <- err }))
+	assert.NoError(t, <-done)
+	assert.Empty(t, s.Pending)
+}
@@ -0,0 +1,79 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// scopes are needed by the Auth and Firestore clients.
+var scopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/datastore",
+	"https://www.googleapis.com/auth/identitytoolkit",
+	"https://www.googleapis.com/auth/userinfo.email",
+}
+
+var ErrCredentials error = &common.ClassError{Class: common.ExitCredentials, Err: lang.NewError(&lang.ErrCredentialsS)}
+
+// findDefault looks up Application Default Credentials, replaced in tests.
+var findDefault = func(ctx context.Context) error {
+	_, err := google.FindDefaultCredentials(ctx, scopes...)
+	return err
+}
+
+// credentialOptions returns the client options authenticating with the credentials chosen by
+// --credentials. By default it's the key file if there's any, otherwise Application Default
+// Credentials, which cover workload identity too. With --impersonate these only authenticate the
+// impersonation of the given service account.
+func credentialOptions(ctx context.Context) ([]option.ClientOption, error) {
+	mode, keyPath := conf.Credentials, conf.KeyPath
+	if len(keyPath) == 0 {
+		keyPath = conf.DefaultKeyPath
+	}
+	if len(mode) == 0 {
+		mode = conf.CredentialsADC
+		if _, err := os.Stat(keyPath); err == nil || len(conf.KeyPath) > 0 {
+			mode = conf.CredentialsKey
+		} else if conf.UseEmu {
+			return []option.ClientOption{option.WithoutAuthentication()}, nil
+		}
+	}
+
+	var opts []option.ClientOption
+	switch mode {
+	case conf.CredentialsKey:
+		if _, err := os.Stat(keyPath); err != nil {
+			return nil, fmt.Errorf(lang.ErrCredentials, ErrCredentials, err)
+		}
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, keyPath))
+	case conf.CredentialsADC:
+		if err := findDefault(ctx); err != nil {
+			return nil, fmt.Errorf(lang.ErrCredentials, ErrCredentials, err)
+		}
+	default:
+		return nil, common.Classify(common.ExitUsage, fmt.Errorf(lang.ErrCredentialsMode, mode))
+	}
+
+	if len(conf.Impersonate) == 0 {
+		return opts, nil
+	}
+
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: conf.Impersonate,
+		Scopes:          scopes,
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf(lang.ErrImpersonate, conf.Impersonate, err)
+	}
+
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
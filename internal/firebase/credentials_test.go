@@ -0,0 +1,60 @@
+package firebase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vendelin8/firemage/internal/conf"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestCredentialOptions(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "key.json")
+	assert.NoError(t, os.WriteFile(keyPath, []byte("{}"), 0o600))
+
+	tests := []struct {
+		name        string
+		credentials string
+		keyPath     string
+		useEmu      bool
+		adcErr      error
+		wantOpts    int
+		wantErr     error
+		wantMsg     string
+	}{
+		{name: "key file given", keyPath: keyPath, wantOpts: 1},
+		{name: "adc without key file", wantOpts: 0},
+		{name: "no credentials", adcErr: testutil.ErrMock, wantErr: ErrCredentials},
+		{name: "emulator without credentials", useEmu: true, adcErr: testutil.ErrMock, wantOpts: 1},
+		{name: "missing key file", credentials: conf.CredentialsKey, keyPath: keyPath + ".missing", wantErr: ErrCredentials},
+		{name: "adc chosen over key file", credentials: conf.CredentialsADC, keyPath: keyPath, wantOpts: 0},
+		{name: "unknown", credentials: "ldap", wantMsg: "unknown credentials: ldap, use key or adc"},
+	}
+
+	origFind := findDefault
+	defer func() {
+		findDefault = origFind
+		conf.Credentials, conf.KeyPath, conf.UseEmu = "", "", false
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.Credentials, conf.KeyPath, conf.UseEmu = tt.credentials, tt.keyPath, tt.useEmu
+			findDefault = func(context.Context) error { return tt.adcErr }
+
+			opts, err := credentialOptions(context.Background())
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Len(t, opts, tt.wantOpts)
+		})
+	}
+}
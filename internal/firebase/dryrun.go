@@ -0,0 +1,191 @@
+package firebase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// dryRun is on when writes are captured instead of executed.
+var dryRun atomic.Bool
+
+// SetDryRun turns dry-run mode on or off.
+func SetDryRun(on bool) {
+	dryRun.Store(on)
+}
+
+// IsDryRun checks if dry-run mode is on.
+func IsDryRun() bool {
+	return dryRun.Load()
+}
+
+// DrySession returns the session to work on: a copy of it in dry-run mode, so the local state
+// doesn't change either.
+func DrySession(s *global.Session) *global.Session {
+	if IsDryRun() {
+		return s.Clone()
+	}
+
+	return s
+}
+
+// DryRunReport returns the writes captured in dry-run mode since the last report.
+func DryRunReport() string {
+	if d, ok := common.Fb.(*DryRun); ok {
+		return d.Report()
+	}
+
+	return lang.SDryRunReport
+}
+
+// DryRun implements common.FbIf by capturing the writes of the wrapped one in dry-run mode, reads
+// still reach the backend.
+type DryRun struct {
+	common.FbIf
+	mu      sync.Mutex
+	claims  map[string]map[string]any
+	specs   map[string]any
+	added   []*common.PendingChange
+	deleted []string
+	users   []string
+}
+
+var _ common.FbIf = (*DryRun)(nil)
+
+func NewDryRun(fb common.FbIf) *DryRun {
+	return &DryRun{FbIf: fb, claims: map[string]map[string]any{}, specs: map[string]any{}}
+}
+
+func (d *DryRun) StoreAuthClaims(ctx context.Context, uid string, newClaims map[string]any) error {
+	if !IsDryRun() {
+		return d.FbIf.StoreAuthClaims(ctx, uid, newClaims)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.claims[uid] = maps.Clone(newClaims)
+	return nil
+}
+
+func (d *DryRun) UpdateSpecs(tr *firestore.Transaction, updates map[string]any) error {
+	if !IsDryRun() {
+		return d.FbIf.UpdateSpecs(tr, updates)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	maps.Copy(d.specs, updates)
+	return nil
+}
+
+func (d *DryRun) AddPending(ctx context.Context, p *common.PendingChange) error {
+	if !IsDryRun() {
+		return d.FbIf.AddPending(ctx, p)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.added = append(d.added, p)
+	return nil
+}
+
+func (d *DryRun) DeletePending(ctx context.Context, id string) error {
+	if !IsDryRun() {
+		return d.FbIf.DeletePending(ctx, id)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.deleted = append(d.deleted, id)
+	return nil
+}
+
+func (d *DryRun) CreateUser(ctx context.Context, uid, email, name string) error {
+	if !IsDryRun() {
+		return d.FbIf.CreateUser(ctx, uid, email, name)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users = append(d.users, fmt.Sprintf("%s %s: %s", lang.SDryRunUser, uid, email))
+	return nil
+}
+
+func (d *DryRun) SetProfile(ctx context.Context, uid string, profile map[string]any) error {
+	if !IsDryRun() {
+		return d.FbIf.SetProfile(ctx, uid, profile)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	js, _ := json.Marshal(profile)
+	d.users = append(d.users, fmt.Sprintf("%s %s: %s", lang.SDryRunProfile, uid, js))
+	return nil
+}
+
+func (d *DryRun) SetSpecs(ctx context.Context, privileged map[string]any) error {
+	if !IsDryRun() {
+		return d.FbIf.SetSpecs(ctx, privileged)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	maps.Copy(d.specs, privileged)
+	return nil
+}
+
+// Report returns the writes captured since the last report, and clears them.
+func (d *DryRun) Report() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(lang.SDryRunReport)
+
+	for _, line := range d.users {
+		b.WriteString("\n" + line)
+	}
+
+	for _, uid := range slices.Sorted(maps.Keys(d.claims)) {
+		js, _ := json.Marshal(d.claims[uid])
+		fmt.Fprintf(&b, "\n%s %s: %s", lang.SDryRunClaims, uid, js)
+	}
+
+	for _, uid := range slices.Sorted(maps.Keys(d.specs)) {
+		value := d.specs[uid]
+		if value == firestore.Delete {
+			value = lang.SDryRunDelete
+		}
+		fmt.Fprintf(&b, "\n%s %s: %v", lang.SDryRunSpecs, uid, value)
+	}
+
+	for _, p := range d.added {
+		emails := slices.Sorted(maps.Values(p.Emails))
+		fmt.Fprintf(&b, "\n%s %s: %s", lang.SDryRunAdded, p.Operator, strings.Join(emails, ", "))
+	}
+
+	for _, id := range d.deleted {
+		fmt.Fprintf(&b, "\n%s %s", lang.SDryRunDeleted, id)
+	}
+
+	if len(d.users)+len(d.claims)+len(d.specs)+len(d.added)+len(d.deleted) == 0 {
+		b.WriteString("\n" + lang.SDryRunNothing)
+	}
+
+	clear(d.claims)
+	clear(d.specs)
+	d.added, d.deleted, d.users = nil, nil, nil
+
+	return b.String()
+}
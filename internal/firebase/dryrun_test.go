@@ -0,0 +1,76 @@
+package firebase
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+)
+
+func TestDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	d := NewDryRun(mockFb)
+	ctx := context.Background()
+	defer SetDryRun(false)
+
+	SetDryRun(false)
+	mockFb.EXPECT().StoreAuthClaims(ctx, "uid1", map[string]any{common.Admin: true}).Return(nil).Times(1)
+	assert.NoError(t, d.StoreAuthClaims(ctx, "uid1", map[string]any{common.Admin: true}))
+	assert.Equal(t, lang.SDryRunReport+"\n"+lang.SDryRunNothing, d.Report(), "real writes aren't reported")
+
+	SetDryRun(true)
+	mockFb.EXPECT().GetSpecs(ctx).Return(map[string]any{"uid2": "user2@example.com"}, nil).Times(1)
+	specs, err := d.GetSpecs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"uid2": "user2@example.com"}, specs, "reads reach the backend")
+
+	assert.NoError(t, d.StoreAuthClaims(ctx, "uid1", map[string]any{common.Admin: true}))
+	assert.NoError(t, d.UpdateSpecs(nil, map[string]any{"uid1": "user1@example.com", "uid2": firestore.Delete}))
+	assert.NoError(t, d.AddPending(ctx, &common.PendingChange{Operator: "op",
+		Emails: map[string]string{"uid1": "user1@example.com"}}))
+	assert.NoError(t, d.DeletePending(ctx, "p1"))
+
+	assert.Equal(t, lang.SDryRunReport+
+		"\n"+lang.SDryRunClaims+` uid1: {"admin":true}`+
+		"\n"+lang.SDryRunSpecs+" uid1: user1@example.com"+
+		"\n"+lang.SDryRunSpecs+" uid2: "+lang.SDryRunDelete+
+		"\n"+lang.SDryRunAdded+" op: user1@example.com"+
+		"\n"+lang.SDryRunDeleted+" p1", d.Report())
+	assert.Equal(t, lang.SDryRunReport+"\n"+lang.SDryRunNothing, d.Report(), "report clears the writes")
+}
+
+func TestDryRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	conf.JournalPath, conf.CheckpointPath = filepath.Join(dir, "journal.json"), filepath.Join(dir, "checkpoint.json")
+	defer SetDryRun(false)
+
+	c := &checkpoint{Project: "demo-test", Token: "token1", Scanned: 10, Found: map[string]string{}}
+	assert.NoError(t, c.save())
+	j := &journal{Users: map[string]*journalEntry{"uid1": {Email: "user1@example.com", Status: statusPending}}}
+	assert.NoError(t, j.save())
+
+	SetDryRun(true)
+	loaded, err := loadCheckpoint()
+	assert.NoError(t, err)
+	assert.Zero(t, loaded.Scanned, "an interrupted refresh isn't resumed")
+	assert.NoError(t, (&checkpoint{Token: "token2", Found: map[string]string{}}).save())
+	assert.NoError(t, removeCheckpoint())
+	assert.NoError(t, (&journal{Users: map[string]*journalEntry{}}).save())
+
+	SetDryRun(false)
+	loaded, err = loadCheckpoint()
+	assert.NoError(t, err)
+	assert.Equal(t, c, loaded, "the checkpoint is kept as it was")
+	assert.True(t, HasJournal(), "the journal is kept")
+}
@@ -0,0 +1,52 @@
+package firebase
+
+import (
+	"cmp"
+	"net/http"
+	"os"
+
+	"google.golang.org/api/option"
+
+	"github.com/vendelin8/firemage/internal/conf"
+)
+
+// emuProject is the project ID of the emulators if it can't be inferred. Emulators accept any
+// project ID starting with "demo-" without credentials.
+const emuProject = "demo-firemage"
+
+// emuTransport sends the Auth requests to the Auth emulator, as this SDK version only supports the
+// Firestore one. The emulator serves the API under a path named after the original host, and
+// accepts "owner" as the admin token.
+type emuTransport struct {
+	host string
+	base http.RoundTripper
+}
+
+func (t emuTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Path = "/" + r.URL.Host + r.URL.Path
+	r.URL.Scheme, r.URL.Host, r.Host = "http", t.host, t.host
+	r.Header.Set("Authorization", "Bearer owner")
+
+	return t.base.RoundTrip(r)
+}
+
+// setupEmulators points the clients to the emulators in emulator mode, and returns the extra
+// options of the Auth client and the project ID to use.
+func setupEmulators(project string) ([]option.ClientOption, string, error) {
+	if !conf.UseEmu {
+		return nil, project, nil
+	}
+
+	if err := os.Setenv("FIRESTORE_EMULATOR_HOST", conf.FirestoreEmuHost); err != nil {
+		return nil, "", err
+	}
+	if err := os.Setenv("FIREBASE_AUTH_EMULATOR_HOST", conf.AuthEmuHost); err != nil {
+		return nil, "", err
+	}
+
+	project = cmp.Or(project, os.Getenv("GOOGLE_CLOUD_PROJECT"), os.Getenv("GCLOUD_PROJECT"), emuProject)
+	hc := &http.Client{Transport: emuTransport{host: conf.AuthEmuHost, base: http.DefaultTransport}}
+
+	return []option.ClientOption{option.WithHTTPClient(hc)}, project, nil
+}
@@ -0,0 +1,55 @@
+package firebase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vendelin8/firemage/internal/conf"
+)
+
+func TestEmuTransport(t *testing.T) {
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: emuTransport{host: strings.TrimPrefix(srv.URL, "http://"), base: http.DefaultTransport}}
+	resp, err := hc.Post("https://identitytoolkit.googleapis.com/v1/projects/p/accounts:update", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, "/identitytoolkit.googleapis.com/v1/projects/p/accounts:update", gotPath)
+	assert.Equal(t, "Bearer owner", gotAuth)
+}
+
+func TestSetupEmulators(t *testing.T) {
+	defer func() { conf.UseEmu = false }()
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	t.Setenv("GCLOUD_PROJECT", "")
+	t.Setenv("FIRESTORE_EMULATOR_HOST", "")
+	t.Setenv("FIREBASE_AUTH_EMULATOR_HOST", "")
+
+	conf.UseEmu = false
+	opts, project, err := setupEmulators("")
+	assert.NoError(t, err)
+	assert.Empty(t, opts)
+	assert.Empty(t, project)
+
+	conf.UseEmu, conf.FirestoreEmuHost, conf.AuthEmuHost = true, "localhost:1", "localhost:2"
+	opts, project, err = setupEmulators("")
+	assert.NoError(t, err)
+	assert.Len(t, opts, 1)
+	assert.Equal(t, emuProject, project)
+	assert.Equal(t, "localhost:1", os.Getenv("FIRESTORE_EMULATOR_HOST"))
+	assert.Equal(t, "localhost:2", os.Getenv("FIREBASE_AUTH_EMULATOR_HOST"))
+
+	_, project, _ = setupEmulators("mine")
+	assert.Equal(t, "mine", project)
+}
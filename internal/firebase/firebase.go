@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/firestore"
@@ -12,7 +14,8 @@ import (
 	"firebase.google.com/go/auth"
 	"go.uber.org/zap"
 	"google.golang.org/api/iterator"
-	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/conf"
@@ -23,11 +26,6 @@ import (
 	"github.com/vendelin8/firemage/internal/util"
 )
 
-const (
-	timeout   = time.Second * 12
-	downLimit = 100
-)
-
 const (
 	actSearch = iota
 	actList
@@ -36,49 +34,79 @@ const (
 )
 
 var (
-	ErrEnd     = errors.New("end")
-	ErrTimeout = errors.New(lang.ErrTimeoutS)
-	ErrMinLen  = fmt.Errorf(lang.ErrMinLen, common.MinSearchLen)
+	ErrEnd      = errors.New("end")
+	ErrTimeout  = lang.NewError(&lang.ErrTimeoutS)
+	ErrCanceled = lang.NewError(&lang.ErrCanceledS)
+	ErrBusy     = lang.NewError(&lang.ErrBusyS)
+	ErrMinLen   = lang.NewError(&lang.ErrMinLen, common.MinSearchLen)
 )
 
 // Firebase implements common.FbIf for real usage.
 type Firebase struct {
-	cAuth  *auth.Client
-	cFs    *firestore.Client
-	fUsers *firestore.CollectionRef
-	fSpecs *firestore.DocumentRef
+	cAuth    *auth.Client
+	cFs      *firestore.Client
+	fUsers   *firestore.CollectionRef
+	fSpecs   *firestore.DocumentRef
+	fChanges *firestore.DocumentRef
+	fPending *firestore.CollectionRef
+	fOps     *firestore.CollectionRef
+	s        *global.Session
+	watching bool
 }
 
-func New() *Firebase {
-	if conf.UseEmu {
-		err := os.Setenv("FIRESTORE_EMULATOR_HOST", "localhost:8080")
-		log.Must("setting firestore emulator env", err)
-		err = os.Setenv("FIREBASE_AUTH_EMULATOR_HOST", "localhost:9099")
-		log.Must("setting auth emulator env", err)
+// New connects to Firebase with the chosen credentials. Missing or unusable credentials are
+// returned with a hint on how to set them up.
+func New(s *global.Session) (*Firebase, error) {
+	f := &Firebase{s: s}
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	opts, err := credentialOptions(ctx)
+	if err != nil {
+		return nil, common.Classify(common.ExitCredentials, err)
 	}
 
-	f := &Firebase{}
-	opt := option.WithAuthCredentialsFile(option.ServiceAccount, conf.KeyPath)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	emuOpts, project, err := setupEmulators(conf.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	var fbConf *firebase.Config
+	if len(project) > 0 {
+		fbConf = &firebase.Config{ProjectID: project}
+	}
 
-	fba, err := firebase.NewApp(ctx, nil, opt)
-	log.Must("initializing app", err)
+	fba, err := firebase.NewApp(ctx, fbConf, opts...)
+	if err != nil {
+		return nil, common.Classify(common.ExitConnection, fmt.Errorf(lang.ErrFirebaseInit, err))
+	}
+
+	authApp := fba
+	if len(emuOpts) > 0 { // the Firestore client doesn't work with the HTTP client of Auth
+		if authApp, err = firebase.NewApp(ctx, fbConf, append(opts, emuOpts...)...); err != nil {
+			return nil, common.Classify(common.ExitConnection, fmt.Errorf(lang.ErrFirebaseInit, err))
+		}
+	}
 
-	f.cAuth, err = fba.Auth(ctx)
-	log.Must("getting Auth client", err)
+	if f.cAuth, err = authApp.Auth(ctx); err != nil {
+		return nil, common.Classify(common.ExitConnection, fmt.Errorf(lang.ErrFirebaseInit, err))
+	}
 
-	f.cFs, err = fba.Firestore(ctx)
-	log.Must("getting Firestore client", err)
+	if f.cFs, err = fba.Firestore(ctx); err != nil {
+		return nil, common.Classify(common.ExitConnection, fmt.Errorf(lang.ErrFirebaseInit, err))
+	}
 
 	f.fUsers = f.cFs.Collection("users")
 	f.fSpecs = f.cFs.Collection("misc").Doc("specialUsers")
+	f.fChanges = f.cFs.Collection("misc").Doc("claimChanges")
+	f.fPending = f.cFs.Collection("pendingChanges")
+	f.fOps = f.cFs.Collection("operators")
 
-	return f
+	return f, nil
 }
 
 func (f *Firebase) Search(ctx context.Context, key, value string, cb func(uid string) error) error {
-	ctx, cancel := context.WithTimeout(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
 	defer cancel()
 
 	ds := f.fUsers.Where(key, ">=", value).Where(key, "<", value+"\uf8ff").Documents(ctx)
@@ -110,12 +138,13 @@ func (f *Firebase) StoreAuthClaims(ctx context.Context, uid string, newClaims ma
 	return f.cAuth.SetCustomUserClaims(ctx, uid, newClaims)
 }
 
-// IterUsers iterates all firebase auth users, and calls callback function with them.
-func (f *Firebase) IterUsers(cb func(*auth.UserRecord) error) error {
-	var token string
-
+// IterUsers iterates firebase auth users starting from the given page token, and calls callback
+// function with them. onPage is called after every page with the token of the next one, which is
+// empty after the last page. It stops when the context is done.
+func (f *Firebase) IterUsers(ctx context.Context, token string, cb func(*auth.UserRecord) error,
+	onPage func(next string) error) error {
 	iterUser := func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
 		defer cancel()
 
 		it := f.cAuth.Users(ctx, token)
@@ -126,6 +155,10 @@ func (f *Firebase) IterUsers(cb func(*auth.UserRecord) error) error {
 				return err
 			}
 
+			if err = ctx.Err(); err != nil {
+				return err
+			}
+
 			if err = cb(r.UserRecord); err != nil {
 				return err
 			}
@@ -140,6 +173,9 @@ func (f *Firebase) IterUsers(cb func(*auth.UserRecord) error) error {
 			return ErrTimeout
 		}
 		token = pi.Token
+		if err = onPage(token); err != nil {
+			return err
+		}
 		if len(token) == 0 {
 			return ErrEnd
 		}
@@ -162,6 +198,9 @@ func (f *Firebase) GetUsers(ctx context.Context, uids []auth.UserIdentifier) (*a
 }
 
 func (f *Firebase) GetSpecs(ctx context.Context) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
+	defer cancel()
+
 	ds, err := f.fSpecs.Get(ctx)
 	if err != nil {
 		return nil, err
@@ -174,8 +213,6 @@ func (f *Firebase) RunTransaction(
 	ctx context.Context,
 	cb func(tr *firestore.Transaction, privileged map[string]any) error,
 ) error {
-	ctx, cancelF := context.WithCancel(ctx)
-	window.ShowProgress(ctx, cancelF)
 	return f.cFs.RunTransaction(ctx, func(ctx context.Context, tr *firestore.Transaction) error {
 		ds, err := tr.Get(f.fSpecs)
 		if err != nil {
@@ -186,55 +223,210 @@ func (f *Firebase) RunTransaction(
 	})
 }
 
+// UpdateSpecs stores the changes of the privileged users, and marks all the given users changed by
+// this process in the claimChanges document for watch mode. Revisions older than revisionsKept are
+// dropped from there, so the document doesn't grow without bound.
 func (f *Firebase) UpdateSpecs(tr *firestore.Transaction, updates map[string]any) error {
+	ds, err := tr.Get(f.fChanges)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+
 	s := make([]firestore.Update, 0, len(updates))
+	revs, rev := make(map[string]any, len(updates)), revision()
+	for _, uid := range staleRevisions(ds.Data(), time.Now()) {
+		revs[uid] = firestore.Delete
+	}
 
 	for uid, value := range updates {
 		s = append(s, firestore.Update{Path: uid, Value: value})
+		revs[uid] = rev
+	}
+
+	if err := tr.Update(f.fSpecs, s); err != nil {
+		return err
+	}
+
+	return tr.Set(f.fChanges, revs, firestore.MergeAll)
+}
+
+// AddPending stores a change set with a generated ID set in p, or with the one already there. A
+// change set stored already by an earlier try isn't duplicated.
+func (f *Firebase) AddPending(ctx context.Context, p *common.PendingChange) error {
+	doc := f.fPending.NewDoc()
+	if len(p.ID) > 0 {
+		doc = f.fPending.Doc(p.ID)
+	}
+	p.ID = doc.ID
+
+	_, err := doc.Create(ctx, p)
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+// GetPending returns all change sets waiting for approval, oldest first.
+func (f *Firebase) GetPending(ctx context.Context) ([]*common.PendingChange, error) {
+	ds, err := f.fPending.OrderBy("created", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	ps := make([]*common.PendingChange, len(ds))
+	for i, d := range ds {
+		ps[i] = &common.PendingChange{}
+		if err = d.DataTo(ps[i]); err != nil {
+			return nil, err
+		}
+		ps[i].ID = d.Ref.ID
+	}
+
+	return ps, nil
+}
+
+func (f *Firebase) DeletePending(ctx context.Context, id string) error {
+	_, err := f.fPending.Doc(id).Delete(ctx)
+	return err
+}
+
+// GetOperator returns the permissions the given operator may grant or revoke, listed in the
+// "permissions" field of its document in the operators collection.
+func (f *Firebase) GetOperator(ctx context.Context, name string) ([]string, error) {
+	ds, err := f.fOps.Doc(name).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, ErrNotOperator
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var op struct {
+		Permissions []string `firestore:"permissions"`
+	}
+	if err = ds.DataTo(&op); err != nil {
+		return nil, err
+	}
+
+	return op.Permissions, nil
+}
+
+// CreateUser creates an Auth user, or updates it if it exists.
+func (f *Firebase) CreateUser(ctx context.Context, uid, email, name string) error {
+	u := (&auth.UserToCreate{}).UID(uid).Email(email)
+	if len(name) > 0 {
+		u.DisplayName(name)
+	}
+
+	_, err := f.cAuth.CreateUser(ctx, u)
+	if auth.IsUIDAlreadyExists(err) {
+		_, err = f.cAuth.UpdateUser(ctx, uid, (&auth.UserToUpdate{}).Email(email).DisplayName(name))
+	}
+
+	return err
+}
+
+// SetProfile overwrites the profile document of a user in the users collection.
+func (f *Firebase) SetProfile(ctx context.Context, uid string, profile map[string]any) error {
+	_, err := f.fUsers.Doc(uid).Set(ctx, profile)
+	return err
+}
+
+// SetSpecs overwrites the whole cache of privileged users.
+func (f *Firebase) SetSpecs(ctx context.Context, privileged map[string]any) error {
+	_, err := f.fSpecs.Set(ctx, privileged)
+	return err
+}
+
+// ProjectID returns the ID of the project connected to, from the path of its documents.
+func (f *Firebase) ProjectID() string {
+	parts := strings.SplitN(f.fSpecs.Path, "/", 3) // projects/<id>/databases/...
+	if len(parts) < 3 {
+		return ""
 	}
 
-	return tr.Update(f.fSpecs, s)
+	return parts[1]
+}
+
+// WatchSpecs calls back with the privileged users on every change of them, until the context is done.
+func (f *Firebase) WatchSpecs(ctx context.Context, cb func(privileged map[string]any)) error {
+	return watchDoc(ctx, f.fSpecs, func(ds *firestore.DocumentSnapshot) {
+		if ds.Exists() {
+			cb(ds.Data())
+		}
+	})
+}
+
+// WatchChanges calls back with the revisions of the users saved, by their uids, on every save, until
+// the context is done. It's empty until the first save.
+func (f *Firebase) WatchChanges(ctx context.Context, cb func(revisions map[string]any)) error {
+	return watchDoc(ctx, f.fChanges, func(ds *firestore.DocumentSnapshot) {
+		revs := ds.Data()
+		if revs == nil {
+			revs = map[string]any{}
+		}
+		cb(revs)
+	})
+}
+
+// watchDoc calls back with every snapshot of a document, until the context is done.
+func watchDoc(ctx context.Context, doc *firestore.DocumentRef, cb func(*firestore.DocumentSnapshot)) error {
+	it := doc.Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		ds, err := it.Next()
+		if ctx.Err() != nil || errors.Is(err, iterator.Done) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		cb(ds)
+	}
 }
 
 // Search looks for users in Firestore with email or name starting with given part.
 // Results are loaded into crntUsers uid string list.
-func Search(searchKey, searchValue string) error {
+func Search(s *global.Session, searchKey, searchValue string) error {
 	log.Lgr.Debug("searching for", zap.String("key", searchKey), zap.String("value", searchValue))
 
 	if len(searchValue) < common.MinSearchLen {
 		return ErrMinLen
 	}
 
-	if len(global.Actions) > 0 {
+	if len(s.Actions) > 0 {
 		window.ShowWarningOnce(lang.WarnSearchAgain)
 	}
 
-	global.CrntUsers = global.CrntUsers[:0]
+	s.CrntUsers = s.CrntUsers[:0]
 
-	err := SearchFor(searchKey, searchValue, func(newUser string) error {
-		global.CrntUsers = append(global.CrntUsers, newUser)
+	err := SearchFor(s, searchKey, searchValue, func(newUser string) error {
+		s.CrntUsers = append(s.CrntUsers, newUser)
 		return nil
 	})
 	if err != nil {
 		return fmt.Errorf(lang.ErrSearch, err)
 	}
 
-	if len(global.CrntUsers) == 0 {
+	if len(s.CrntUsers) == 0 {
 		return common.ErrNoUsers
 	}
 
-	util.SortByNameThenEmail(global.CrntUsers)
+	util.SortByNameThenEmail(s, s.CrntUsers)
 	return nil
 }
 
 // SearchFor queries users with the given start of email or name, and updates user list on screen.
-func SearchFor(key, value string, cb func(uid string) error) error {
+func SearchFor(s *global.Session, key, value string, cb func(uid string) error) error {
 	uids := []auth.UserIdentifier{}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
 	defer cancel()
 
 	if err := common.Fb.Search(ctx, key, value, func(uid string) error {
-		if _, ok := global.LocalUsers[uid]; ok {
+		if _, ok := s.LocalUsers[uid]; ok {
 			return cb(uid)
 		}
 		uids = append(uids, auth.UIDIdentifier{UID: uid})
@@ -247,46 +439,61 @@ func SearchFor(key, value string, cb func(uid string) error) error {
 		return nil
 	}
 
-	return downloadClaims(uids, func(r *auth.UserRecord) error {
-		if _, err := newUserFromAuth(r, actSearch, nil, nil); err != nil {
+	missing, err := downloadClaims(context.Background(), uids, func(r *auth.UserRecord) error {
+		if _, err := newUserFromAuth(s, r, actSearch, nil, nil); err != nil {
 			return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
 		}
 
 		return cb(r.UID)
-	})
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	warnMissing(s, missing)
+	return nil
 }
 
-// setPermissions sets given custom claims for Firebase auth user.
-func setPermissions(r *auth.UserRecord, d common.ClaimsMap) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// storeClaims merges the given changes into the custom claims of a Firebase auth user, and stores
+// them. Returns the stored permissions.
+func storeClaims(ctx context.Context, r *auth.UserRecord, d common.ClaimsMap) (common.ClaimsMap, error) {
+	ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
 	defer cancel()
 
 	newClaims := merge(r.CustomClaims, d)
 	if err := common.Fb.StoreAuthClaims(ctx, r.UID, newClaims); err != nil {
-		return fmt.Errorf("store auth claims: %w", err)
+		return nil, fmt.Errorf("store auth claims: %w", err)
 	}
 
 	claims, err := common.NewClaimsMapFrom(newClaims)
 	if err != nil {
-		return fmt.Errorf("map auth claims %s => %w", d, err)
+		return nil, fmt.Errorf("map auth claims %s => %w", d, err)
 	}
 
-	u := global.LocalUsers[r.UID]
-	u.Claims = *claims
-	global.LocalUsers[r.UID] = u
-
-	return nil
+	return *claims, nil
 }
 
-// downloadClaims updates local auth user custom claims for the given list of users.
-// Returns an optional error, and if it's critical.
-func downloadClaims(uids []auth.UserIdentifier, cb func(*auth.UserRecord) error) error {
-	idx, endIdx := 0, downLimit
+// downloadClaims updates local auth user custom claims for the given list of users in batches,
+// calling report with the batches done, if given. It stops when the context is done.
+// Returns the identifiers not found, and an optional error. It doesn't touch the session, so
+// callers running in the background need to sync in the callback.
+func downloadClaims(
+	ctx context.Context,
+	uids []auth.UserIdentifier,
+	cb func(*auth.UserRecord) error,
+	report func(done, total int),
+) ([]auth.UserIdentifier, error) {
+	idx, endIdx := 0, conf.BatchSize
 	endIdx = min(endIdx, len(uids))
-	var missing []string
+	total := (len(uids) + conf.BatchSize - 1) / conf.BatchSize
+	var missing []auth.UserIdentifier
 
 	forFunc := func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, conf.Timeout)
 		defer cancel()
 
 		rs, err := common.Fb.GetUsers(ctx, uids[idx:endIdx])
@@ -294,10 +501,7 @@ func downloadClaims(uids []auth.UserIdentifier, cb func(*auth.UserRecord) error)
 			return fmt.Errorf(lang.ErrGetAuthUsers, err)
 		}
 
-		for _, n := range rs.NotFound {
-			uid := n.(auth.UIDIdentifier).UID
-			missing = append(missing, global.LocalUsers[uid].Email)
-		}
+		missing = append(missing, rs.NotFound...)
 
 		for _, r := range rs.Users {
 			if err = cb(r); err != nil {
@@ -305,11 +509,15 @@ func downloadClaims(uids []auth.UserIdentifier, cb func(*auth.UserRecord) error)
 			}
 		}
 
+		if report != nil {
+			report((endIdx+conf.BatchSize-1)/conf.BatchSize, total)
+		}
+
 		if endIdx == len(uids) {
 			return ErrEnd
 		}
 
-		idx, endIdx = endIdx, endIdx+downLimit
+		idx, endIdx = endIdx, endIdx+conf.BatchSize
 		if endIdx > len(uids) {
 			endIdx = len(uids)
 		}
@@ -326,203 +534,444 @@ func downloadClaims(uids []auth.UserIdentifier, cb func(*auth.UserRecord) error)
 			break
 		}
 
-		return err
+		return missing, err
 	}
 
-	if len(missing) > 0 {
-		window.UseWarn()
-		window.AppendListError(lang.ErrRemoved, missing, lang.ErrManualS)
-		window.ShowWarn()
+	return missing, nil
+}
+
+// batchProgress reports the downloaded batches from a background goroutine.
+func batchProgress(done, total int) {
+	progress(fmt.Sprintf(lang.SBatches, done, total))
+}
+
+// progress shows the given progress message from a background goroutine.
+func progress(msg string) {
+	common.Fe.QueueUpdateDraw(func() { common.Fe.SetProgress(msg) })
+}
+
+// startJob shows the progress dialog of a background job cancelling it, unless another job runs.
+// The job is cancelled after the given timeout too, if it's not 0. It must call endJob on the GUI
+// goroutine when it's done.
+func startJob(s *global.Session, timeout time.Duration) (context.Context, context.CancelFunc, error) {
+	if !s.StartJob() {
+		return nil, nil, ErrBusy
 	}
 
-	return nil
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	}
+	window.ShowProgress(ctx, cancel)
+
+	return ctx, cancel, nil
 }
 
-// doList downloads privileged user list for the first time.
-func (f *Firebase) doList(ctx context.Context) error {
-	privileged, err := f.GetSpecs(ctx)
-	if err != nil {
-		return fmt.Errorf(lang.ErrGetFSUsers, err)
+// endJob allows starting another background job.
+func endJob(s *global.Session) {
+	s.EndJob()
+}
+
+// canceled returns ErrCanceled if err is a result of cancellation, otherwise err itself.
+func canceled(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return ErrCanceled
 	}
+	return err
+}
 
-	var (
-		uids  = map[string]struct{}{}
-		empty []string
-	)
+// warnMissing shows a warning about the users not found in Firebase auth, if any.
+func warnMissing(s *global.Session, missing []auth.UserIdentifier) {
+	if len(missing) == 0 {
+		return
+	}
 
-	for uid := range privileged {
-		uids[uid] = struct{}{}
-		global.LocalPrivileged[uid] = struct{}{}
+	names := make([]string, len(missing))
+	for i, id := range missing {
+		names[i] = identifierName(s, id)
 	}
 
-	uidList := make([]auth.UserIdentifier, 0, len(uids))
-	for uid := range uids {
-		uidList = append(uidList, auth.UIDIdentifier{UID: uid})
+	window.UseWarn()
+	window.AppendListError(lang.ErrRemoved, names, lang.ErrManualS)
+	window.ShowWarn()
+}
+
+// identifierName returns a human readable name of a user identifier, preferably the email address.
+func identifierName(s *global.Session, id auth.UserIdentifier) string {
+	switch n := id.(type) {
+	case auth.UIDIdentifier:
+		if u, ok := s.LocalUsers[n.UID]; ok {
+			return u.Email
+		}
+		return n.UID
+	case auth.EmailIdentifier:
+		return n.Email
+	default:
+		return fmt.Sprint(id)
 	}
+}
 
-	if err = downloadClaims(uidList, func(r *auth.UserRecord) error {
-		u, err := newUserFromAuth(r, actList, nil, nil)
-		if err != nil {
-			return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
+// FetchUsers downloads the given users by email address or uid into the local cache in the
+// background. Errors of starting are returned, otherwise done is called with their uids.
+func FetchUsers(s *global.Session, users []string, done func(uids []string, err error)) error {
+	ids := make([]auth.UserIdentifier, len(users))
+	for i, u := range users {
+		if strings.Contains(u, "@") {
+			ids[i] = auth.EmailIdentifier{Email: u}
+		} else {
+			ids[i] = auth.UIDIdentifier{UID: u}
 		}
+	}
+
+	ctx, cancel, err := startJob(s, 0)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer cancel()
 
-		if len(u.Claims) == 0 {
-			empty = append(empty, u.Email)
+		var records []*auth.UserRecord
+		missing, err := downloadClaims(ctx, ids, func(r *auth.UserRecord) error {
+			records = append(records, r)
 			return nil
+		}, nil)
+
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			if err != nil {
+				done(nil, canceled(err))
+				return
+			}
+
+			done(fetched(s, records, missing))
+		})
+	}()
+
+	return nil
+}
+
+// fetched adds the downloaded users to the local cache, and returns their uids.
+func fetched(s *global.Session, records []*auth.UserRecord, missing []auth.UserIdentifier) ([]string, error) {
+	uids := make([]string, 0, len(records))
+	for _, r := range records {
+		if _, err := newUserFromAuth(s, r, actSearch, nil, nil); err != nil {
+			return nil, fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
 		}
 
-		global.LocalPrivileged[u.UID] = struct{}{}
+		uids = append(uids, r.UID)
+	}
 
-		return nil
-	}); err != nil {
-		return err
+	warnMissing(s, missing)
+
+	if len(uids) == 0 {
+		return nil, common.ErrNoUsers
 	}
 
-	global.CrntUsers = make([]string, 0, len(privileged))
+	return uids, nil
+}
 
+// listPrivileged downloads privileged user list. Runs in the background, returns the privileged uids,
+// the email addresses of the ones without permissions, and the ones not found.
+func listPrivileged(ctx context.Context, s *global.Session) (uids, empty []string,
+	missing []auth.UserIdentifier, err error,
+) {
+	privileged, err := common.Fb.GetSpecs(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf(lang.ErrGetFSUsers, err)
+	}
+
+	uidList := make([]auth.UserIdentifier, 0, len(privileged))
+	uids = make([]string, 0, len(privileged))
 	for uid := range privileged {
-		global.CrntUsers = append(global.CrntUsers, uid)
+		uidList = append(uidList, auth.UIDIdentifier{UID: uid})
+		uids = append(uids, uid)
 	}
 
-	util.SortByNameThenEmail(global.CrntUsers)
+	s.Sync(func() {
+		for _, uid := range uids {
+			s.LocalPrivileged[uid] = struct{}{}
+		}
+	})
 
-	if window.AppendListError(lang.ErrEmpty, empty, lang.ErrManualS) {
-		return window.GetError()
-	}
+	missing, err = downloadClaims(ctx, uidList, func(r *auth.UserRecord) error {
+		var err error
+		s.Sync(func() {
+			var u *global.User
+			if u, err = newUserFromAuth(s, r, actList, nil, nil); err != nil {
+				return
+			}
 
-	return nil
+			if len(u.Claims) == 0 {
+				empty = append(empty, u.Email)
+				return
+			}
+
+			s.LocalPrivileged[u.UID] = struct{}{}
+		})
+		if err != nil {
+			return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
+		}
+
+		return nil
+	}, batchProgress)
+
+	return uids, empty, missing, canceled(err)
 }
 
-// DoList downloads privileged user list for the first time.
+// DoList downloads privileged user list for the first time in the background. The list shows up
+// when it's done. Only the Firebase calls time out, not the whole download.
 func (f *Firebase) DoList() error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel, err := startJob(f.s, 0)
+	if err != nil {
+		return err
+	}
 
-	window.ShowProgress(ctx, cancel)
+	go func() {
+		defer cancel()
 
-	defer cancel()
+		uids, empty, missing, err := listPrivileged(ctx, f.s)
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(f.s)
+			warnMissing(f.s, missing)
+			window.ShowErrorBuffer(f.listDone(uids, empty, err))
+		})
+	}()
+
+	return nil
+}
+
+// ListPrivileged downloads privileged user list, and waits for it. It's for the command line, users
+// without permissions are skipped.
+func ListPrivileged(s *global.Session) error {
+	_, _, missing, err := listPrivileged(context.Background(), s)
+	warnMissing(s, missing)
+	return common.Classify(common.ExitConnection, err)
+}
 
-	if err := f.doList(ctx); err != nil {
+// listDone shows the downloaded privileged users on the GUI goroutine.
+func (f *Firebase) listDone(uids, empty []string, err error) error {
+	if err != nil {
 		return err
 	}
 
-	if len(global.CrntUsers) == 0 {
+	util.SortByNameThenEmail(f.s, uids)
+	setListUsers(f.s, uids)
+
+	if window.AppendListError(lang.ErrEmpty, empty, lang.ErrManualS) {
+		return window.GetError()
+	}
+
+	if conf.Watch && !f.watching {
+		f.watching = true
+		watch(f)
+	}
+
+	if len(uids) == 0 {
 		return fmt.Errorf("%s %s", lang.ErrNoUsersS, lang.WarnMayRefresh)
 	}
 
 	common.Fe.LayoutUsers()
+	common.Fe.LayoutExpiring()
 
 	return nil
 }
 
-// DoSave saves privileged user list in a transaction Firebase auth.
-func DoSave() error {
-	var (
-		errStoreClaims error
-		clrActs        []string
-	)
+// setListUsers sets the users of the List page, even if it's not the current one.
+func setListUsers(s *global.Session, uids []string) {
+	if common.Fe.CurrentPage() == lang.PageList {
+		s.CrntUsers = uids
+	} else {
+		s.SavedUsers[lang.PageList] = uids
+	}
+}
+
+// WriteViolations adds the broken permission rules of all users with pending actions to the
+// confirm buffer, and returns if there's any. The buffer is kept only if there was some.
+func WriteViolations(s *global.Session) bool {
+	window.UseConfirm()
+
+	now, found := time.Now(), false
+	for _, uid := range slices.Sorted(maps.Keys(s.Actions)) {
+		email := uid
+		if u, ok := s.LocalUsers[uid]; ok {
+			email = u.Email
+		}
+		found = window.WriteViolations(email, util.UserViolations(s, uid, now)) || found
+	}
+
+	if !found {
+		window.PopBuffer()
+	}
+
+	return found
+}
+
+// DoSave saves privileged user list in a transaction Firebase auth in the background. Pending actions
+// are snapshotted when called, the saved ones are cleared right before done is called on the GUI goroutine.
+// Claims of users stored before a failure or cancellation are kept, privileged users change only if
+// the transaction succeeds. Changes of permissions the operator may not grant or revoke fail it right away.
+// Only the Firebase calls time out, not the whole save.
+func DoSave(s *global.Session, done func(error)) {
+	if err := checkGrants(s.Actions); err != nil {
+		done(err)
+		return
+	}
+
+	ctx, cancel, err := startJob(s, 0)
+	if err != nil {
+		done(err)
+		return
+	}
 
-	updates := make(map[string]any, len(global.Actions))
-	uidList := make([]auth.UserIdentifier, 0, len(global.Actions))
+	actions := make(map[string]common.ClaimsMap, len(s.Actions))
+	uidList := make([]auth.UserIdentifier, 0, len(s.Actions))
 
-	for uid := range global.Actions {
+	for uid, acts := range s.Actions {
+		actions[uid] = maps.Clone(acts)
 		uidList = append(uidList, auth.UIDIdentifier{UID: uid})
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	go func() {
+		defer cancel()
 
-	if err := common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, privileged map[string]any) error {
-		errStoreClaims = downloadClaims(uidList, func(r *auth.UserRecord) error {
-			_, err := newUserFromAuth(r, actSave, privileged, updates)
-			if err != nil {
-				return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
+		var (
+			errStoreClaims error
+			errJournal     error
+			clrActs        []string
+			missing        []auth.UserIdentifier
+			privileged     map[string]any
+		)
+
+		statuses := make(map[string]UserStatus, len(actions))
+		updates := make(map[string]any, len(actions))
+		j, err := loadJournal()
+		if err == nil {
+			err = common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, p map[string]any) error {
+				privileged = p
+				missing, errStoreClaims = downloadClaims(ctx, uidList, func(r *auth.UserRecord) error {
+					if slices.Contains(clrActs, r.UID) {
+						return nil // stored by a previous attempt of the transaction
+					}
+
+					var err error
+					s.Sync(func() { err = stageUpdate(s, r, privileged, updates) })
+					if err != nil {
+						return err
+					}
+
+					j.Users[r.UID] = newJournalEntry(r, actions[r.UID])
+					if err = j.save(); err != nil {
+						return err
+					}
+
+					claims, err := storeClaims(ctx, r, actions[r.UID])
+					if err != nil {
+						delete(updates, r.UID) // the cache stays as it is
+						statuses[r.UID] = UserStatus{Email: r.Email, Err: err}
+						return j.set(r.UID, statusFailed, err)
+					}
+
+					s.Sync(func() { s.LocalUsers[r.UID].Claims = claims })
+					clrActs = append(clrActs, r.UID)
+					statuses[r.UID] = UserStatus{Email: r.Email}
+
+					return j.set(r.UID, statusStored, nil)
+				}, batchProgress)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				return doUpdate(tr, updates)
+			})
+		}
+
+		if err == nil && len(statuses) > 0 {
+			for _, uid := range clrActs { // consistent with the cache now
+				delete(j.Users, uid)
 			}
+			errJournal = j.save()
+		}
 
-			hasAnyValue := false
-			for _, value := range util.FixedUserClaims(r.UID) {
-				if !value.IsZero() {
-					hasAnyValue = true
-					break
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			warnMissing(s, missing)
+
+			failed := len(statuses) > len(clrActs)
+			if err == nil {
+				applyUpdates(s, privileged, updates)
+				if errStoreClaims == nil {
+					clrActs = slices.DeleteFunc(slices.Collect(maps.Keys(actions)), func(uid string) bool {
+						return statuses[uid].Err != nil
+					})
 				}
 			}
 
-			if hasAnyValue {
-				updates[r.UID] = r.Email
-			} else {
-				updates[r.UID] = firestore.Delete
+			for _, uid := range clrActs {
+				delete(s.Actions, uid)
 			}
 
-			if err := setPermissions(r, global.Actions[r.UID]); err != nil {
-				return fmt.Errorf("set permissions: %w", err)
+			switch {
+			case errors.Is(err, context.Canceled):
+				done(saveError(ErrCanceled, statuses))
+			case err != nil && len(clrActs) > 0:
+				done(saveError(fmt.Errorf("%w: %w", ErrCacheNotSaved, err), statuses))
+			case err != nil:
+				done(err)
+			case errStoreClaims != nil:
+				done(saveError(errStoreClaims, statuses))
+			case failed:
+				done(saveError(ErrUsersFailed, statuses))
+			default:
+				done(errJournal)
 			}
-
-			clrActs = append(clrActs, r.UID)
-
-			return nil
 		})
+	}()
+}
 
-		return doUpdate(updates, tr, privileged)
-	}); err != nil {
-		return fmt.Errorf("only store parts of your request was stored, retry saving")
-	}
-
-	if errStoreClaims == nil {
-		clear(global.Actions)
-		return nil
+// stageUpdate checks a user to save against the cache, and adds its privileged state to the updates.
+func stageUpdate(s *global.Session, r *auth.UserRecord, privileged, updates map[string]any) error {
+	if _, err := newUserFromAuth(s, r, actSave, privileged, updates); err != nil {
+		return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
 	}
 
-	for _, a := range clrActs {
-		delete(global.Actions, a)
+	if granted(util.FixedUserClaims(s, r.UID)) {
+		updates[r.UID] = r.Email
+	} else {
+		updates[r.UID] = firestore.Delete
 	}
 
-	return errStoreClaims
+	return nil
 }
 
-// DoRefresh downloads all users from Firebase auth and checks if there are new or changed users.
-func DoRefresh() error {
-	updates := make(map[string]any)
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	if err := common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, privileged map[string]any) error {
-		if err := common.Fb.IterUsers(func(r *auth.UserRecord) error {
-			_, err := newUserFromAuth(r, actRefresh, privileged, updates)
-			if err != nil {
-				return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
-			}
-
-			return nil
-		}); err != nil {
-			return err
-		}
-
-		return doUpdate(updates, tr, privileged)
-	}); err != nil {
-		return err
+// doUpdate stores the changes of the privileged users in the transaction.
+func doUpdate(tr *firestore.Transaction, updates map[string]any) error {
+	if len(updates) == 0 {
+		return nil
 	}
 
-	global.SavedUsers[lang.PageList] = global.SavedUsers[lang.PageList][:0]
-	for uid := range global.LocalPrivileged {
-		global.SavedUsers[lang.PageList] = append(global.SavedUsers[lang.PageList], uid)
+	if err := common.Fb.UpdateSpecs(tr, updates); err != nil {
+		return fmt.Errorf(lang.ErrUpdateFSUsers, err)
 	}
 
-	util.SortByNameThenEmail(global.SavedUsers[lang.PageList])
-
 	return nil
 }
 
-func doUpdate(updates map[string]any, tr *firestore.Transaction, privileged map[string]any) error {
-	if len(updates) > 0 {
-		if err := common.Fb.UpdateSpecs(tr, updates); err != nil {
-			return fmt.Errorf(lang.ErrUpdateFSUsers, err)
-		}
-	}
-
-	clear(global.LocalPrivileged)
+// applyUpdates sets the local privileged users to the stored ones after a successful transaction.
+// The marks of the saved users are cleared, a snapshot of the save may have arrived earlier.
+func applyUpdates(s *global.Session, privileged map[string]any, updates map[string]any) {
+	clear(s.LocalPrivileged)
 
 	for uid := range privileged {
-		global.LocalPrivileged[uid] = struct{}{}
+		s.LocalPrivileged[uid] = struct{}{}
 	}
 
-	return nil
+	for uid, value := range updates {
+		if value == firestore.Delete {
+			delete(s.LocalPrivileged, uid)
+		} else {
+			s.LocalPrivileged[uid] = struct{}{}
+		}
+		delete(s.RemoteChanges, uid)
+	}
 }
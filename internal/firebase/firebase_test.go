@@ -2,13 +2,19 @@ package firebase
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/auth"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
 	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/lang"
 	"github.com/vendelin8/firemage/internal/mock"
@@ -16,6 +22,7 @@ import (
 )
 
 func TestSearchFor(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
@@ -55,23 +62,23 @@ func TestSearchFor(t *testing.T) {
 
 			defer func() {
 				ctrl.Finish()
-				global.Actions = map[string]common.ClaimsMap{}
-				global.CrntUsers = []string{}
-				global.LocalUsers = make(map[string]*global.User)
+				s.Actions = map[string]common.ClaimsMap{}
+				s.CrntUsers = []string{}
+				s.LocalUsers = make(map[string]*global.User)
 				lang.Warns = make(map[int]string)
 			}()
 
 			// Setup global state
-			global.Actions = testutil.BuildActionsMap(tt.actions)
-			global.CrntUsers = []string{}
-			global.LocalUsers = make(map[string]*global.User)
+			s.Actions = testutil.BuildActionsMap(tt.actions)
+			s.CrntUsers = []string{}
+			s.LocalUsers = make(map[string]*global.User)
 
 			mockFb.EXPECT().Search(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				DoAndReturn(func(_ context.Context, _, _ string, cb func(uid string) error) error {
 					for _, uid := range tt.results {
 						// Only populate if not already pre-populated
-						if _, exists := global.LocalUsers[uid]; !exists {
-							global.LocalUsers[uid] = &global.User{
+						if _, exists := s.LocalUsers[uid]; !exists {
+							s.LocalUsers[uid] = &global.User{
 								UID:   uid,
 								Email: uid + "@example.com",
 								Name:  "User " + uid,
@@ -86,7 +93,7 @@ func TestSearchFor(t *testing.T) {
 				Times(1)
 
 			// Execute test
-			err := SearchFor(tt.searchKey, tt.searchValue, func(uid string) error {
+			err := SearchFor(s, tt.searchKey, tt.searchValue, func(uid string) error {
 				return tt.cbErr
 			})
 
@@ -97,6 +104,7 @@ func TestSearchFor(t *testing.T) {
 }
 
 func TestSearch(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
@@ -116,8 +124,8 @@ func TestSearch(t *testing.T) {
 			DoAndReturn(func(_ context.Context, _, _ string, cb func(uid string) error) error {
 				for _, uid := range results {
 					// Only populate if not already pre-populated
-					if _, exists := global.LocalUsers[uid]; !exists {
-						global.LocalUsers[uid] = &global.User{
+					if _, exists := s.LocalUsers[uid]; !exists {
+						s.LocalUsers[uid] = &global.User{
 							UID:   uid,
 							Email: uid + "@example.com",
 							Name:  "User " + uid,
@@ -175,7 +183,7 @@ func TestSearch(t *testing.T) {
 			results:     []string{"uid1", "uid2", "uid3"},
 			setup: func(results []string, mockFb *mock.MockFbIf, mockFe *mock.MockFeIf) {
 				setupMock(results, mockFb, mockFe)
-				global.LocalUsers = map[string]*global.User{
+				s.LocalUsers = map[string]*global.User{
 					"uid1": {UID: "uid1", Email: "alice@example.com", Name: "Charlie"},
 					"uid2": {UID: "uid2", Email: "bob@example.com", Name: "Alice"},
 					"uid3": {UID: "uid3", Email: "charlie@example.com", Name: "Alice"},
@@ -195,31 +203,31 @@ func TestSearch(t *testing.T) {
 
 			defer func() {
 				ctrl.Finish()
-				global.Actions = map[string]common.ClaimsMap{}
-				global.CrntUsers = []string{}
-				global.LocalUsers = make(map[string]*global.User)
+				s.Actions = map[string]common.ClaimsMap{}
+				s.CrntUsers = []string{}
+				s.LocalUsers = make(map[string]*global.User)
 				lang.Warns = make(map[int]string)
 			}()
 
 			// Setup global state
-			global.Actions = testutil.BuildActionsMap(tt.actions)
-			global.CrntUsers = []string{}
-			global.LocalUsers = make(map[string]*global.User)
+			s.Actions = testutil.BuildActionsMap(tt.actions)
+			s.CrntUsers = []string{}
+			s.LocalUsers = make(map[string]*global.User)
 
 			if tt.setup != nil {
 				tt.setup(tt.results, mockFb, mockFe)
 			}
 
 			// Execute test
-			err := Search(tt.searchKey, tt.searchValue)
+			err := Search(s, tt.searchKey, tt.searchValue)
 
 			// Verify results
 			assert.ErrorIs(t, err, tt.wantError)
 			if tt.wantError == nil && len(tt.results) > 0 {
 				if len(tt.wantOrder) > 0 {
-					assert.Equal(t, tt.wantOrder, global.CrntUsers)
+					assert.Equal(t, tt.wantOrder, s.CrntUsers)
 				} else {
-					assert.Equal(t, tt.results, global.CrntUsers)
+					assert.Equal(t, tt.results, s.CrntUsers)
 				}
 			}
 		})
@@ -227,12 +235,14 @@ func TestSearch(t *testing.T) {
 }
 
 func TestDownloadClaims(t *testing.T) {
+	s := global.NewSession()
 	cleanup := testutil.InitLog()
 	defer cleanup()
 
 	tests := []struct {
 		name      string
 		userCount int
+		canceled  bool
 		wantError error
 	}{
 		{
@@ -245,6 +255,12 @@ func TestDownloadClaims(t *testing.T) {
 			userCount: 3,
 			wantError: nil,
 		},
+		{
+			name:      "canceled before the first batch",
+			userCount: 2,
+			canceled:  true,
+			wantError: context.Canceled,
+		},
 		{
 			name:      "error from GetUsers",
 			wantError: testutil.ErrMock,
@@ -264,9 +280,11 @@ func TestDownloadClaims(t *testing.T) {
 			mockFb := mock.NewMockFbIf(ctrl)
 			common.Fb = mockFb
 
-			global.LocalUsers = make(map[string]*global.User)
+			s.LocalUsers = make(map[string]*global.User)
 
-			if tt.wantError != nil {
+			if tt.canceled {
+				mockFb.EXPECT().GetUsers(gomock.Any(), gomock.Any()).Times(0)
+			} else if tt.wantError != nil {
 				mockFb.EXPECT().
 					GetUsers(gomock.Any(), gomock.Any()).
 					Return(nil, testutil.ErrMock).
@@ -298,24 +316,39 @@ func TestDownloadClaims(t *testing.T) {
 			for i := 0; i < tt.userCount; i++ {
 				uid := "uid" + string(rune(i+1+48))
 				uids[i] = auth.UIDIdentifier{UID: uid}
-				global.LocalUsers[uid] = &global.User{UID: uid}
+				s.LocalUsers[uid] = &global.User{UID: uid}
 			}
 
-			callCount := 0
-			err := downloadClaims(uids, func(r *auth.UserRecord) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.canceled {
+				cancel()
+			}
+			defer cancel()
+
+			batches := (tt.userCount + conf.BatchSize - 1) / conf.BatchSize
+			callCount, reports := 0, []int{}
+			_, err := downloadClaims(ctx, uids, func(r *auth.UserRecord) error {
 				callCount++
 				return nil
+			}, func(done, total int) {
+				assert.Equal(t, batches, total)
+				reports = append(reports, done)
 			})
 
 			assert.ErrorIs(t, err, tt.wantError)
-			assert.Equal(t, tt.userCount, callCount)
-
-			global.LocalUsers = make(map[string]*global.User)
+			if tt.wantError == nil {
+				assert.Equal(t, tt.userCount, callCount)
+				assert.Equal(t, []int{batches}, reports)
+			} else {
+				assert.Zero(t, callCount)
+				assert.Empty(t, reports)
+			}
 		})
 	}
 }
 
 func TestNewUserFromAuthClaimFiltering(t *testing.T) {
+	s := global.NewSession()
 	tests := []struct {
 		name           string
 		authClaims     map[string]any
@@ -368,7 +401,7 @@ func TestNewUserFromAuthClaimFiltering(t *testing.T) {
 			common.Fe = mockFe
 			testutil.InitLog()
 
-			global.LocalUsers = make(map[string]*global.User)
+			s.LocalUsers = make(map[string]*global.User)
 			testuid := "test-uid-" + tt.name
 
 			authRecord := &auth.UserRecord{
@@ -383,7 +416,7 @@ func TestNewUserFromAuthClaimFiltering(t *testing.T) {
 			privileged := make(map[string]any)
 			updates := make(map[string]any)
 
-			user, err := newUserFromAuth(authRecord, tt.action, privileged, updates)
+			user, err := newUserFromAuth(s, authRecord, tt.action, privileged, updates)
 
 			assert.NoError(t, err)
 			assert.NotNil(t, user)
@@ -396,7 +429,275 @@ func TestNewUserFromAuthClaimFiltering(t *testing.T) {
 			}
 
 			// Cleanup
-			global.LocalUsers = make(map[string]*global.User)
 		})
 	}
 }
+
+// guiLoop simulates the GUI goroutine for background operations: Sync calls and queued updates are
+// run by the returned function, until the operation is done.
+func guiLoop(s *global.Session, mockFe *mock.MockFeIf) func(done *bool) {
+	ui := make(chan func(), 16)
+	s.SetSync(func(f func()) {
+		done := make(chan struct{})
+		ui <- func() {
+			f()
+			close(done)
+		}
+		<-done
+	})
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { ui <- f }).AnyTimes()
+	mockFe.EXPECT().SetProgress(gomock.Any()).AnyTimes()
+
+	return func(done *bool) {
+		for !*done {
+			(<-ui)()
+		}
+	}
+}
+
+func TestDoSave(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	conf.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	s := global.NewSession()
+	run := guiLoop(s, mockFe)
+	s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()}}
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.Admin: {Checked: true}}}
+
+	user := &auth.UserRecord{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"}}
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+			return cb(nil, map[string]any{})
+		}).Times(1)
+	mockFb.EXPECT().GetUsers(gomock.Any(), []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid1"}}).
+		Return(&auth.GetUsersResult{Users: []*auth.UserRecord{user}}, nil).Times(1)
+	mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid1", map[string]any{common.Admin: true}).Return(nil).Times(1)
+	mockFb.EXPECT().UpdateSpecs(gomock.Any(), map[string]any{"uid1": "user1@example.com"}).Return(nil).Times(1)
+
+	var (
+		err  error
+		done bool
+	)
+	DoSave(s, func(e error) { err, done = e, true })
+	run(&done)
+
+	assert.NoError(t, err)
+	assert.Empty(t, s.Actions)
+	assert.True(t, s.LocalUsers["uid1"].Claims[common.Admin].Checked)
+	assert.Equal(t, map[string]struct{}{"uid1": {}}, s.LocalPrivileged)
+	assert.False(t, s.Busy(), "another job may start")
+}
+
+func TestDoSaveBusy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	common.Fe = mock.NewMockFeIf(ctrl) // no progress dialog
+	s := global.NewSession()
+	s.StartJob()
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.Admin: {Checked: true}}}
+
+	var err error
+	DoSave(s, func(e error) { err = e })
+	assert.ErrorIs(t, err, ErrBusy)
+	assert.Contains(t, s.Actions, "uid1")
+
+	DoRefresh(s, func(e error) { err = e })
+	assert.ErrorIs(t, err, ErrBusy)
+
+	assert.ErrorIs(t, (&Firebase{s: s}).DoList(), ErrBusy)
+}
+
+func TestDoRefresh(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	conf.RefreshTimeout = time.Minute
+	admin := map[string]any{common.Admin: true}
+	users := []*auth.UserRecord{
+		{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"}, CustomClaims: admin},
+		{UserInfo: &auth.UserInfo{UID: "uid2", Email: "user2@example.com"}},
+	}
+
+	tests := []struct {
+		name        string
+		checkpoint  *checkpoint
+		wantToken   string
+		pages       [][]*auth.UserRecord
+		iterErr     error
+		specs       map[string]any
+		recheck     []string
+		wantUpdates map[string]any
+		wantUsers   []string
+		wantSaved   *checkpoint
+	}{
+		{
+			name:        "lists privileged users",
+			pages:       [][]*auth.UserRecord{users[:1], users[1:]},
+			specs:       map[string]any{"uid2": "user2@example.com", "uid3": "user3@example.com", "uid4": "user4@example.com"},
+			recheck:     []string{"uid2", "uid3", "uid4"},
+			wantUpdates: map[string]any{"uid1": "user1@example.com", "uid2": firestore.Delete, "uid3": firestore.Delete},
+			wantUsers:   []string{"uid1", "uid4"},
+		},
+		{
+			name:    "keeps checkpoint on error",
+			pages:   [][]*auth.UserRecord{users[:1]},
+			iterErr: testutil.ErrMock,
+			wantSaved: &checkpoint{Project: "demo-test", Token: "token1", Scanned: 1,
+				Found: map[string]string{"uid1": "user1@example.com"}},
+		},
+		{
+			name: "resumes from checkpoint",
+			checkpoint: &checkpoint{Project: "demo-test", Token: "token1", Scanned: 1,
+				Found: map[string]string{"uid1": "user1@example.com"}},
+			wantToken: "token1",
+			pages:     [][]*auth.UserRecord{users[1:]},
+			specs:     map[string]any{"uid1": "user1@example.com"},
+			wantUsers: []string{"uid1"},
+		},
+		{
+			name: "starts over after checkpoint of another project",
+			checkpoint: &checkpoint{Project: "production", Token: "token1", Scanned: 1,
+				Found: map[string]string{"uid3": "user3@example.com"}},
+			pages:       [][]*auth.UserRecord{users[:1], users[1:]},
+			specs:       map[string]any{},
+			wantUpdates: map[string]any{"uid1": "user1@example.com"},
+			wantUsers:   []string{"uid1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+
+			conf.CheckpointPath = filepath.Join(t.TempDir(), "refresh.json")
+			if tt.checkpoint != nil {
+				require.NoError(t, tt.checkpoint.save())
+			}
+
+			s := global.NewSession()
+			run := guiLoop(s, mockFe)
+
+			mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+			mockFb.EXPECT().ProjectID().Return("demo-test").Times(1)
+			mockFb.EXPECT().IterUsers(gomock.Any(), tt.wantToken, gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ string, cb func(*auth.UserRecord) error, onPage func(string) error) error {
+					for i, page := range tt.pages {
+						for _, r := range page {
+							if err := cb(r); err != nil {
+								return err
+							}
+						}
+
+						next := ""
+						if i < len(tt.pages)-1 || tt.iterErr != nil {
+							next = fmt.Sprintf("token%d", i+1)
+						}
+						if err := onPage(next); err != nil {
+							return err
+						}
+					}
+					return tt.iterErr
+				}).Times(1)
+
+			if tt.iterErr == nil {
+				mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+						return cb(nil, tt.specs)
+					}).Times(1)
+				if len(tt.recheck) > 0 { // uid4 was granted since its page was scanned, uid3 is deleted
+					ids := make([]auth.UserIdentifier, len(tt.recheck))
+					for i, uid := range tt.recheck {
+						ids[i] = auth.UIDIdentifier{UID: uid}
+					}
+					mockFb.EXPECT().GetUsers(gomock.Any(), ids).Return(&auth.GetUsersResult{
+						Users: []*auth.UserRecord{users[1],
+							{UserInfo: &auth.UserInfo{UID: "uid4", Email: "user4@example.com"}, CustomClaims: admin}},
+						NotFound: []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid3"}},
+					}, nil).Times(1)
+				}
+				if len(tt.wantUpdates) > 0 {
+					mockFb.EXPECT().UpdateSpecs(gomock.Any(), tt.wantUpdates).Return(nil).Times(1)
+				}
+				mockFe.EXPECT().CurrentPage().Return(lang.PageList).Times(1)
+				mockFe.EXPECT().LayoutUsers().Times(1)
+			}
+
+			var (
+				err  error
+				done bool
+			)
+			DoRefresh(s, func(e error) { err, done = e, true })
+			run(&done)
+
+			assert.ErrorIs(t, err, tt.iterErr)
+			assert.Equal(t, tt.wantUsers, s.CrntUsers)
+			assert.Contains(t, s.LocalUsers, tt.pages[0][0].UID)
+
+			if tt.wantSaved == nil {
+				assert.NoFileExists(t, conf.CheckpointPath, "removed after finishing")
+				return
+			}
+
+			saved, err := loadCheckpoint()
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSaved, saved)
+		})
+	}
+}
+
+func TestDoSaveCanceled(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	conf.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	s := global.NewSession()
+	run := guiLoop(s, mockFe)
+	s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()}}
+	s.LocalPrivileged = map[string]struct{}{"uid2": {}}
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.Admin: {Checked: true}}}
+
+	// the user presses Cancel on the progress popup right away
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, cancel context.CancelFunc, _ ...string) { cancel() }).Times(1)
+	mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+			return cb(nil, map[string]any{"uid2": "user2@example.com"})
+		}).Times(1)
+
+	var (
+		err  error
+		done bool
+	)
+	DoSave(s, func(e error) { err, done = e, true })
+	run(&done)
+
+	assert.ErrorIs(t, err, ErrCanceled)
+	assert.Contains(t, s.Actions, "uid1", "not stored actions are kept")
+	assert.False(t, s.LocalUsers["uid1"].Claims[common.Admin].Checked)
+	assert.Equal(t, map[string]struct{}{"uid2": {}}, s.LocalPrivileged)
+}
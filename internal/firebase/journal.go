@@ -0,0 +1,265 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+const (
+	statusPending = "pending" // the claims are about to be written
+	statusStored  = "stored"  // the claims are written, the cache may not be
+	statusFailed  = "failed"  // writing the claims failed, but it may have reached Auth
+)
+
+var (
+	ErrCacheNotSaved       = lang.NewError(&lang.ErrCacheNotSavedS)
+	ErrUsersFailed   error = &common.ClassError{Class: common.ExitPartial, Err: lang.NewError(&lang.ErrUsersFailedS)}
+	ErrRepairStale         = lang.NewError(&lang.ErrRepairStaleS)
+)
+
+// journalEntry is an intended claim write of a user, with the claims of the changed permissions
+// before it.
+type journalEntry struct {
+	Email   string           `json:"email"`
+	Before  common.ClaimsMap `json:"before"`
+	Changes common.ClaimsMap `json:"changes"`
+	Status  string           `json:"status"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// newJournalEntry returns the pending write of the given changes to a user.
+func newJournalEntry(r *auth.UserRecord, changes common.ClaimsMap) *journalEntry {
+	claims := filterClaims(r.CustomClaims)
+	before := make(common.ClaimsMap, len(changes))
+	for perm := range changes {
+		before[perm] = claims[perm]
+	}
+
+	return &journalEntry{Email: r.Email, Before: before, Changes: changes, Status: statusPending}
+}
+
+// missingWrites returns the changes of the entry not in the given claims of the user yet. They're
+// stale if any of their permissions changed since the entry was written, eg. by another operator.
+func (e *journalEntry) missingWrites(claims common.ClaimsMap) (acts common.ClaimsMap, stale bool) {
+	acts = common.ClaimsMap{}
+	for perm, c := range e.Changes {
+		if !c.Differs(claims[perm]) {
+			continue
+		}
+
+		if b := e.Before[perm]; b == nil || b.Differs(claims[perm]) {
+			return nil, true
+		}
+		acts[perm] = c
+	}
+
+	return acts, false
+}
+
+// journal is the write-ahead log of claim writes, so they can be repaired after a partial failure.
+// It's saved after every change, and removed once Auth and the cache are consistent.
+type journal struct {
+	Users map[string]*journalEntry `json:"users"`
+}
+
+// loadJournal reads the journal, or returns an empty one.
+func loadJournal() (*journal, error) {
+	j := &journal{}
+	if _, err := readJSON(conf.JournalPath, j); err != nil {
+		return nil, fmt.Errorf(lang.ErrJournal, err)
+	}
+
+	if j.Users == nil {
+		j.Users = make(map[string]*journalEntry)
+	}
+
+	return j, nil
+}
+
+// HasJournal checks if there are claim writes left to repair.
+func HasJournal() bool {
+	j, err := loadJournal()
+	return err != nil || len(j.Users) > 0
+}
+
+// save writes the journal, or removes it if there's nothing left in it. Nothing is written in
+// dry-run mode, the journal is of the real writes only.
+func (j *journal) save() error {
+	if IsDryRun() {
+		return nil
+	}
+
+	var err error
+	if len(j.Users) == 0 {
+		err = removeFile(conf.JournalPath)
+	} else {
+		err = writeJSON(conf.JournalPath, j)
+	}
+	if err != nil {
+		return fmt.Errorf(lang.ErrJournal, err)
+	}
+
+	return nil
+}
+
+// set updates the status of a user, and saves the journal.
+func (j *journal) set(uid, status string, err error) error {
+	e := j.Users[uid]
+	e.Status, e.Error = status, ""
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	return j.save()
+}
+
+// UserStatus is the result of saving a user.
+type UserStatus struct {
+	Email string
+	Err   error
+}
+
+func (u UserStatus) String() string {
+	if u.Err != nil {
+		return fmt.Sprintf(lang.SUserFailed, u.Email, u.Err)
+	}
+
+	return fmt.Sprintf(lang.SUserStored, u.Email)
+}
+
+// SaveError reports the result of every user after a partially failed save.
+type SaveError struct {
+	Err      error
+	Statuses []UserStatus
+}
+
+func (e *SaveError) Error() string {
+	lines := make([]string, 0, len(e.Statuses)+1)
+	lines = append(lines, e.Err.Error())
+	for _, u := range e.Statuses {
+		lines = append(lines, u.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *SaveError) Unwrap() error {
+	return e.Err
+}
+
+// saveError returns the cause with the status of the users attempted, sorted by email.
+func saveError(err error, statuses map[string]UserStatus) error {
+	if len(statuses) == 0 {
+		return err
+	}
+
+	return &SaveError{Err: err, Statuses: slices.SortedFunc(maps.Values(statuses), func(a, b UserStatus) int {
+		return strings.Compare(a.Email, b.Email)
+	})}
+}
+
+// Repair checks the claim writes left in the journal against the current claims in Auth. The ones
+// still missing are staged as actions of the session, to be saved again with all the checks of a
+// save. The ones stored already, stale or of users deleted since are removed from the journal, and
+// the cache of privileged users is reconciled with Auth for them. The result of every user not
+// staged is reported. Claims of users changed since the interrupted save are kept, those fail it.
+func Repair(s *global.Session, report func(UserStatus)) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+
+	uids := slices.Sorted(maps.Keys(j.Users))
+	if len(uids) == 0 {
+		return nil
+	}
+
+	ids := make([]auth.UserIdentifier, len(uids))
+	for i, uid := range uids {
+		ids[i] = auth.UIDIdentifier{UID: uid}
+	}
+
+	want := make(map[string]any, len(uids))
+	failed := false
+	ctx := context.Background()
+	missing, err := downloadClaims(ctx, ids, func(r *auth.UserRecord) error {
+		e := j.Users[r.UID]
+		claims := filterClaims(r.CustomClaims)
+		acts, stale := e.missingWrites(claims)
+		switch {
+		case stale:
+			report(UserStatus{Email: e.Email, Err: ErrRepairStale})
+			failed = true
+		case len(acts) > 0:
+			if _, err := newUserFromAuth(s, r, actSearch, nil, nil); err != nil {
+				return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
+			}
+			s.Actions[r.UID] = acts
+			return nil
+		default:
+			report(UserStatus{Email: e.Email})
+		}
+
+		want[r.UID] = firestore.Delete
+		if granted(claims) {
+			want[r.UID] = r.Email
+		}
+
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range missing { // deleted from Auth since
+		want[id.(auth.UIDIdentifier).UID] = firestore.Delete
+	}
+
+	if err = common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, privileged map[string]any) error {
+		updates := make(map[string]any)
+		for uid, value := range want {
+			if _, ok := privileged[uid]; ok != (value != firestore.Delete) {
+				updates[uid] = value
+			}
+		}
+
+		return doUpdate(tr, updates)
+	}); err != nil {
+		return err
+	}
+
+	if err = ForgetJournal(slices.Collect(maps.Keys(want))); err != nil {
+		return err
+	}
+
+	if failed {
+		return ErrUsersFailed
+	}
+
+	return nil
+}
+
+// ForgetJournal removes the given users from the journal, once their writes are done or handed over.
+func ForgetJournal(uids []string) error {
+	j, err := loadJournal()
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		delete(j.Users, uid)
+	}
+
+	return j.save()
+}
@@ -0,0 +1,154 @@
+package firebase
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestDoSavePartial(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	conf.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	s := global.NewSession()
+	run := guiLoop(s, mockFe)
+	s.LocalUsers = map[string]*global.User{
+		"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()},
+		"uid2": {UID: "uid2", Email: "user2@example.com", Claims: *common.NewClaimsMap()},
+	}
+	s.Actions = map[string]common.ClaimsMap{
+		"uid1": {common.Admin: {Checked: true}},
+		"uid2": {common.Admin: {Checked: true}},
+	}
+
+	users := []*auth.UserRecord{
+		{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"}},
+		{UserInfo: &auth.UserInfo{UID: "uid2", Email: "user2@example.com"}},
+	}
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+			return cb(nil, map[string]any{})
+		}).Times(1)
+	mockFb.EXPECT().GetUsers(gomock.Any(), gomock.Any()).
+		Return(&auth.GetUsersResult{Users: users}, nil).Times(1)
+	mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid1", gomock.Any()).Return(nil).Times(1)
+	mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid2", gomock.Any()).Return(testutil.ErrMock).Times(1)
+	mockFb.EXPECT().UpdateSpecs(gomock.Any(), map[string]any{"uid1": "user1@example.com"}).Return(nil).Times(1)
+
+	var (
+		err  error
+		done bool
+	)
+	DoSave(s, func(e error) { err, done = e, true })
+	run(&done)
+
+	assert.ErrorIs(t, err, ErrUsersFailed)
+	var saveErr *SaveError
+	require.ErrorAs(t, err, &saveErr)
+	assert.Equal(t, []UserStatus{
+		{Email: "user1@example.com"},
+		{Email: "user2@example.com", Err: saveErr.Statuses[1].Err},
+	}, saveErr.Statuses)
+	assert.ErrorIs(t, saveErr.Statuses[1].Err, testutil.ErrMock)
+
+	assert.Equal(t, []string{"uid2"}, slices.Sorted(maps.Keys(s.Actions)), "failed user stays to retry")
+	assert.Equal(t, map[string]struct{}{"uid1": {}}, s.LocalPrivileged)
+
+	j, err := loadJournal()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uid2"}, slices.Sorted(maps.Keys(j.Users)), "only the failed user is left to repair")
+	assert.Equal(t, statusFailed, j.Users["uid2"].Status)
+}
+
+func TestRepair(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	conf.JournalPath = filepath.Join(t.TempDir(), "journal.json")
+	granting := func(email string) *journalEntry {
+		return &journalEntry{Email: email, Before: common.ClaimsMap{common.Admin: {}},
+			Changes: common.ClaimsMap{common.Admin: {Checked: true}}, Status: statusPending}
+	}
+	j := &journal{Users: map[string]*journalEntry{
+		"uid1": granting("user1@example.com"),
+		"uid2": {Email: "user2@example.com", Before: common.ClaimsMap{common.Admin: {Checked: true}},
+			Changes: common.ClaimsMap{common.Admin: {}}, Status: statusStored},
+		"uid3": granting("user3@example.com"),
+		"uid4": granting("user4@example.com"),
+		"uid5": {Email: "user5@example.com", Changes: common.ClaimsMap{common.Admin: {Checked: true}}, Status: statusPending},
+	}}
+	require.NoError(t, j.save())
+	assert.True(t, HasJournal())
+
+	mockFb.EXPECT().GetUsers(gomock.Any(), []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid1"},
+		auth.UIDIdentifier{UID: "uid2"}, auth.UIDIdentifier{UID: "uid3"}, auth.UIDIdentifier{UID: "uid4"},
+		auth.UIDIdentifier{UID: "uid5"}}).
+		Return(&auth.GetUsersResult{
+			Users: []*auth.UserRecord{
+				{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"}},
+				{UserInfo: &auth.UserInfo{UID: "uid2", Email: "user2@example.com"}},
+				{UserInfo: &auth.UserInfo{UID: "uid4", Email: "user4@example.com"},
+					CustomClaims: map[string]any{common.Admin: "2030-01-01"}},
+				{UserInfo: &auth.UserInfo{UID: "uid5", Email: "user5@example.com"}},
+			},
+			NotFound: []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid3"}},
+		}, nil).Times(1)
+	mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+			return cb(nil, map[string]any{"uid2": "user2@example.com", "uid3": "user3@example.com"})
+		}).Times(1)
+	mockFb.EXPECT().UpdateSpecs(gomock.Any(), map[string]any{
+		"uid2": firestore.Delete, "uid3": firestore.Delete, "uid4": "user4@example.com",
+	}).Return(nil).Times(1)
+
+	s := global.NewSession()
+	var reported []UserStatus
+	assert.ErrorIs(t, Repair(s, func(u UserStatus) { reported = append(reported, u) }), ErrUsersFailed)
+	assert.Equal(t, []UserStatus{
+		{Email: "user2@example.com"},
+		{Email: "user4@example.com", Err: ErrRepairStale},
+		{Email: "user5@example.com", Err: ErrRepairStale},
+	}, reported, "claims changed since, or without the ones before, are kept")
+
+	assert.Equal(t, map[string]common.ClaimsMap{"uid1": {common.Admin: {Checked: true}}}, s.Actions,
+		"missing writes are staged to save again")
+	assert.Contains(t, s.LocalUsers, "uid1")
+
+	j, err := loadJournal()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uid1"}, slices.Sorted(maps.Keys(j.Users)), "staged ones stay until saved")
+
+	require.NoError(t, ForgetJournal([]string{"uid1"}))
+	assert.False(t, HasJournal())
+	assert.NoFileExists(t, conf.JournalPath)
+}
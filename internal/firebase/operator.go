@@ -0,0 +1,81 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var (
+	ErrNotOperator error = &common.ClassError{Class: common.ExitDenied, Err: lang.NewError(&lang.ErrOperatorS)}
+	ErrForbidden   error = &common.ClassError{Class: common.ExitDenied, Err: lang.NewError(&lang.ErrForbiddenS)}
+	ErrNoAllowlist error = &common.ClassError{Class: common.ExitDenied, Err: lang.NewError(&lang.ErrNoAllowlistS)}
+)
+
+// ResolveOperator looks up the permissions the current operator may grant or revoke in the
+// allowlist of the config file or Firestore. Without an allowlist all of them are allowed.
+func ResolveOperator() error {
+	var (
+		perms []string
+		err   error
+	)
+
+	switch conf.OperatorSource {
+	case "":
+		conf.Grantable = nil
+		return nil
+	case conf.OperatorsFirestore:
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+		defer cancel()
+		perms, err = common.Fb.GetOperator(ctx, conf.Operator)
+		err = common.Classify(common.ExitConnection, err)
+	default:
+		var ok bool
+		if perms, ok = conf.OperatorPerms[conf.Operator]; !ok {
+			err = ErrNotOperator
+		}
+	}
+	if err != nil {
+		return fmt.Errorf(lang.ErrOperator, conf.Operator, err)
+	}
+
+	conf.SetGrantable(perms)
+	return nil
+}
+
+// checkOperator returns an error if the given operator isn't in the allowlist, or there's none.
+// Operator names can be chosen freely, so four-eyes approval is only as strong as the allowlist.
+func checkOperator(name string) error {
+	var err error
+	switch conf.OperatorSource {
+	case "":
+		return ErrNoAllowlist
+	case conf.OperatorsFirestore:
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+		defer cancel()
+		_, err = common.Fb.GetOperator(ctx, name)
+	default:
+		if _, ok := conf.OperatorPerms[name]; !ok {
+			err = ErrNotOperator
+		}
+	}
+	if err != nil {
+		return fmt.Errorf(lang.ErrOperator, name, err)
+	}
+
+	return nil
+}
+
+// checkGrants returns an error if the current operator may not grant or revoke some permissions
+// of the given changes.
+func checkGrants(actions map[string]common.ClaimsMap) error {
+	if forbidden := conf.Forbidden(actions); len(forbidden) > 0 {
+		return fmt.Errorf(lang.ErrForbidden, conf.Operator, ErrForbidden, strings.Join(forbidden, ", "))
+	}
+
+	return nil
+}
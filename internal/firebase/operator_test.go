@@ -0,0 +1,94 @@
+package firebase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestResolveOperator(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		perms         map[string][]string
+		fsPerms       []string
+		fsErr         error
+		wantGrantable map[string]struct{}
+		wantErr       error
+	}{
+		{
+			name: "no allowlist",
+		},
+		{
+			name:          "config",
+			source:        conf.OperatorsConfig,
+			perms:         map[string][]string{"alice": {common.Admin}},
+			wantGrantable: map[string]struct{}{common.Admin: {}},
+		},
+		{
+			name:    "not in config",
+			source:  conf.OperatorsConfig,
+			perms:   map[string][]string{"bob": {common.Admin}},
+			wantErr: ErrNotOperator,
+		},
+		{
+			name:          "firestore",
+			source:        conf.OperatorsFirestore,
+			fsPerms:       []string{common.SuperAdmin},
+			wantGrantable: map[string]struct{}{common.SuperAdmin: {}},
+		},
+		{
+			name:    "firestore error",
+			source:  conf.OperatorsFirestore,
+			fsErr:   testutil.ErrMock,
+			wantErr: testutil.ErrMock,
+		},
+	}
+
+	defer func() { conf.Operator, conf.OperatorSource, conf.OperatorPerms, conf.Grantable = "", "", nil, nil }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFb := mock.NewMockFbIf(ctrl)
+			common.Fb = mockFb
+
+			conf.Operator, conf.OperatorSource, conf.OperatorPerms, conf.Grantable = "alice", tt.source, tt.perms, nil
+			if tt.source == conf.OperatorsFirestore {
+				mockFb.EXPECT().GetOperator(gomock.Any(), "alice").Return(tt.fsPerms, tt.fsErr).Times(1)
+			}
+
+			err := ResolveOperator()
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantGrantable, conf.Grantable)
+		})
+	}
+}
+
+func TestDoSaveForbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	common.Fb = mock.NewMockFbIf(ctrl) // nothing is called
+	defer func() { conf.Grantable = nil }()
+
+	conf.SetGrantable([]string{common.Admin})
+	s := global.NewSession()
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.SuperAdmin: {Checked: true}}}
+
+	var err error
+	DoSave(s, func(e error) { err = e })
+
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Len(t, s.Actions, 1, "changes are kept")
+}
@@ -0,0 +1,80 @@
+package firebase
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var ErrReadOnly error = &common.ClassError{Class: common.ExitDenied, Err: lang.NewError(&lang.ErrReadOnlyS)}
+
+// ReadOnly implements common.FbIf by refusing the writes of the wrapped one in read-only mode.
+type ReadOnly struct {
+	common.FbIf
+}
+
+var _ common.FbIf = (*ReadOnly)(nil)
+
+func NewReadOnly(fb common.FbIf) *ReadOnly {
+	return &ReadOnly{FbIf: fb}
+}
+
+func (r *ReadOnly) StoreAuthClaims(ctx context.Context, uid string, newClaims map[string]any) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.StoreAuthClaims(ctx, uid, newClaims)
+}
+
+func (r *ReadOnly) UpdateSpecs(tr *firestore.Transaction, updates map[string]any) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.UpdateSpecs(tr, updates)
+}
+
+func (r *ReadOnly) AddPending(ctx context.Context, p *common.PendingChange) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.AddPending(ctx, p)
+}
+
+func (r *ReadOnly) DeletePending(ctx context.Context, id string) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.DeletePending(ctx, id)
+}
+
+func (r *ReadOnly) CreateUser(ctx context.Context, uid, email, name string) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.CreateUser(ctx, uid, email, name)
+}
+
+func (r *ReadOnly) SetProfile(ctx context.Context, uid string, profile map[string]any) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.SetProfile(ctx, uid, profile)
+}
+
+func (r *ReadOnly) SetSpecs(ctx context.Context, privileged map[string]any) error {
+	if conf.ReadOnly {
+		return ErrReadOnly
+	}
+
+	return r.FbIf.SetSpecs(ctx, privileged)
+}
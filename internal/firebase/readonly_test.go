@@ -0,0 +1,37 @@
+package firebase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/mock"
+)
+
+func TestReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	r := NewReadOnly(mockFb)
+	ctx := context.Background()
+	defer func() { conf.ReadOnly = false }()
+
+	conf.ReadOnly = false
+	mockFb.EXPECT().StoreAuthClaims(ctx, "uid1", gomock.Any()).Return(nil).Times(1)
+	assert.NoError(t, r.StoreAuthClaims(ctx, "uid1", nil))
+
+	conf.ReadOnly = true
+	mockFb.EXPECT().GetSpecs(ctx).Return(map[string]any{}, nil).Times(1)
+	_, err := r.GetSpecs(ctx)
+	assert.NoError(t, err, "reads are allowed")
+
+	assert.ErrorIs(t, r.StoreAuthClaims(ctx, "uid1", nil), ErrReadOnly)
+	assert.ErrorIs(t, r.UpdateSpecs(nil, map[string]any{}), ErrReadOnly)
+	assert.ErrorIs(t, r.AddPending(ctx, &common.PendingChange{}), ErrReadOnly)
+	assert.ErrorIs(t, r.DeletePending(ctx, "p1"), ErrReadOnly)
+}
@@ -0,0 +1,230 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+)
+
+// checkpoint is the progress of a refresh saved after every page, to resume it after interruption.
+// It's only resumed in the same project, with or without the emulators as it was started.
+type checkpoint struct {
+	Project  string            `json:"project"`
+	Emulator bool              `json:"emulator"`
+	Token    string            `json:"token"`
+	Scanned  int               `json:"scanned"`
+	Found    map[string]string `json:"found"` // uid -> email of users with any permission
+}
+
+// loadCheckpoint reads the progress of an interrupted refresh, or returns an empty one. In dry-run
+// mode the refresh starts over, and the progress is neither saved nor removed.
+func loadCheckpoint() (*checkpoint, error) {
+	c := &checkpoint{}
+	if IsDryRun() {
+		c.Found = make(map[string]string)
+		return c, nil
+	}
+
+	if _, err := readJSON(conf.CheckpointPath, c); err != nil {
+		return nil, fmt.Errorf(lang.ErrCheckpoint, err)
+	}
+
+	if c.Found == nil {
+		c.Found = make(map[string]string)
+	}
+
+	return c, nil
+}
+
+func (c *checkpoint) save() error {
+	if IsDryRun() {
+		return nil
+	}
+
+	if err := writeJSON(conf.CheckpointPath, c); err != nil {
+		return fmt.Errorf(lang.ErrCheckpoint, err)
+	}
+
+	return nil
+}
+
+// removeCheckpoint deletes the progress after a finished refresh.
+func removeCheckpoint() error {
+	if IsDryRun() {
+		return nil
+	}
+
+	if err := removeFile(conf.CheckpointPath); err != nil {
+		return fmt.Errorf(lang.ErrCheckpoint, err)
+	}
+
+	return nil
+}
+
+// DoRefresh pages through all users from Firebase auth in the background and checks if there are
+// new or changed users, reporting the users scanned after every page. The progress is saved after
+// every page, and an interrupted refresh of the same project continues from there. Only the resulting
+// changes of the privileged users are stored in a transaction. done is called on the GUI goroutine.
+func DoRefresh(s *global.Session, done func(error)) {
+	ctx, cancel, err := startJob(s, conf.RefreshTimeout)
+	if err != nil {
+		done(err)
+		return
+	}
+
+	go func() {
+		defer cancel()
+
+		var privileged, updates map[string]any
+		c, err := scanUsers(ctx, s)
+		if err == nil {
+			err = common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, p map[string]any) error {
+				var err error
+				privileged = p
+				if updates, err = specsUpdates(ctx, s, c.Found, p); err != nil {
+					return err
+				}
+				return doUpdate(tr, updates)
+			})
+		}
+		stored := err == nil
+		if stored {
+			err = removeCheckpoint()
+		}
+
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			if stored {
+				applyUpdates(s, privileged, updates)
+				uids := slices.Collect(maps.Keys(s.LocalPrivileged))
+				util.SortByNameThenEmail(s, uids)
+				setListUsers(s, uids)
+				common.Fe.LayoutUsers()
+			}
+
+			done(canceled(err))
+		})
+	}()
+}
+
+// scanUsers pages through the Firebase auth users from the saved checkpoint, checks them against
+// the cache, and collects the ones with any permission.
+func scanUsers(ctx context.Context, s *global.Session) (*checkpoint, error) {
+	c, err := loadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	project := common.Fb.ProjectID()
+	if c.Project != project || c.Emulator != conf.UseEmu {
+		if c.Scanned > 0 {
+			progress(fmt.Sprintf(lang.SDiscarded, c.Scanned))
+		}
+		c = &checkpoint{Project: project, Emulator: conf.UseEmu, Found: make(map[string]string)}
+	} else if c.Scanned > 0 {
+		progress(fmt.Sprintf(lang.SResumed, c.Scanned))
+	}
+
+	var known map[string]any
+	s.Sync(func() {
+		known = make(map[string]any, len(s.LocalPrivileged))
+		for uid := range s.LocalPrivileged {
+			known[uid] = struct{}{}
+		}
+	})
+
+	var page []*auth.UserRecord
+	err = common.Fb.IterUsers(ctx, c.Token, func(r *auth.UserRecord) error {
+		page = append(page, r)
+		return nil
+	}, func(next string) error {
+		var err error
+		s.Sync(func() { err = checkPage(s, page, known, c.Found) })
+		if err != nil {
+			return err
+		}
+
+		c.Token = next
+		c.Scanned += len(page)
+		page = page[:0]
+		progress(fmt.Sprintf(lang.SScanned, c.Scanned))
+
+		return c.save()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// checkPage checks a page of users against the cache, and updates the ones found with any permission.
+func checkPage(s *global.Session, page []*auth.UserRecord, known map[string]any, found map[string]string) error {
+	for _, r := range page {
+		if _, err := newUserFromAuth(s, r, actRefresh, known, map[string]any{}); err != nil {
+			return fmt.Errorf(lang.ErrNewUsrFrmAuth, err)
+		}
+
+		delete(found, r.UID)
+		if granted(filterClaims(r.CustomClaims)) {
+			found[r.UID] = r.Email
+		}
+	}
+
+	return nil
+}
+
+// specsUpdates returns the changes of the stored privileged users to match the ones found. The scan
+// may have taken long, or resumed from an earlier run, so the stored ones not found are checked again
+// in Auth, and only removed if they have no permissions now either.
+func specsUpdates(ctx context.Context, s *global.Session, found map[string]string, privileged map[string]any,
+) (map[string]any, error) {
+	updates := make(map[string]any)
+	for uid, email := range found {
+		if _, ok := privileged[uid]; !ok {
+			updates[uid] = email
+		}
+	}
+
+	var ids []auth.UserIdentifier
+	for _, uid := range slices.Sorted(maps.Keys(privileged)) {
+		if _, ok := found[uid]; !ok {
+			ids = append(ids, auth.UIDIdentifier{UID: uid})
+		}
+	}
+	if len(ids) == 0 {
+		return updates, nil
+	}
+
+	missing, err := downloadClaims(ctx, ids, func(r *auth.UserRecord) error {
+		var err error
+		s.Sync(func() { err = checkPage(s, []*auth.UserRecord{r}, map[string]any{r.UID: struct{}{}}, found) })
+		if err != nil {
+			return err
+		}
+
+		if _, ok := found[r.UID]; !ok { // granted since its page was scanned otherwise
+			updates[r.UID] = firestore.Delete
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range missing {
+		updates[id.(auth.UIDIdentifier).UID] = firestore.Delete
+	}
+
+	return updates, nil
+}
@@ -0,0 +1,266 @@
+package firebase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+	"go.uber.org/zap"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/log"
+)
+
+const maxBackoff = 30 * time.Second
+
+// Retry implements common.FbIf by retrying the temporary failures of the wrapped one with
+// exponential backoff and jitter. Calls not reaching the backend are passed through.
+type Retry struct {
+	common.FbIf
+}
+
+var _ common.FbIf = (*Retry)(nil)
+
+func NewRetry(fb common.FbIf) *Retry {
+	return &Retry{FbIf: fb}
+}
+
+// Search delivers the results only after a successful query, so a retry can't duplicate them.
+func (r *Retry) Search(ctx context.Context, key, value string, cb func(uid string) error) error {
+	var uids []string
+	if err := retry(ctx, func() error {
+		uids = uids[:0]
+		return r.FbIf.Search(ctx, key, value, func(uid string) error {
+			uids = append(uids, uid)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		if err := cb(uid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Retry) StoreAuthClaims(ctx context.Context, uid string, newClaims map[string]any) error {
+	return retry(ctx, func() error { return r.FbIf.StoreAuthClaims(ctx, uid, newClaims) })
+}
+
+// IterUsers delivers the users page by page, and retries from the failed page.
+func (r *Retry) IterUsers(ctx context.Context, token string, cb func(*auth.UserRecord) error,
+	onPage func(next string) error) error {
+	var page []*auth.UserRecord
+	return retry(ctx, func() error {
+		page = page[:0]
+		return r.FbIf.IterUsers(ctx, token, func(u *auth.UserRecord) error {
+			page = append(page, u)
+			return nil
+		}, func(next string) error {
+			for _, u := range page {
+				if err := cb(u); err != nil {
+					return err
+				}
+			}
+
+			page, token = page[:0], next
+			return onPage(next)
+		})
+	})
+}
+
+func (r *Retry) GetUsers(ctx context.Context, uids []auth.UserIdentifier) (*auth.GetUsersResult, error) {
+	var rs *auth.GetUsersResult
+	err := retry(ctx, func() (err error) {
+		rs, err = r.FbIf.GetUsers(ctx, uids)
+		return err
+	})
+	return rs, err
+}
+
+func (r *Retry) GetSpecs(ctx context.Context) (map[string]any, error) {
+	var privileged map[string]any
+	err := retry(ctx, func() (err error) {
+		privileged, err = r.FbIf.GetSpecs(ctx)
+		return err
+	})
+	return privileged, err
+}
+
+// RunTransaction retries only failures before calling back, the calls within are retried on their
+// own, and Firestore retries conflicting transactions.
+func (r *Retry) RunTransaction(ctx context.Context,
+	cb func(tr *firestore.Transaction, privileged map[string]any) error) error {
+	var called bool
+	return retry(ctx, func() error {
+		err := r.FbIf.RunTransaction(ctx, func(tr *firestore.Transaction, privileged map[string]any) error {
+			called = true
+			return cb(tr, privileged)
+		})
+		if called {
+			return noRetry{err}
+		}
+		return err
+	})
+}
+
+// AddPending keeps the ID of the change set between the tries, so it's stored only once.
+func (r *Retry) AddPending(ctx context.Context, p *common.PendingChange) error {
+	return retry(ctx, func() error { return r.FbIf.AddPending(ctx, p) })
+}
+
+func (r *Retry) GetPending(ctx context.Context) ([]*common.PendingChange, error) {
+	var ps []*common.PendingChange
+	err := retry(ctx, func() (err error) {
+		ps, err = r.FbIf.GetPending(ctx)
+		return err
+	})
+	return ps, err
+}
+
+func (r *Retry) GetOperator(ctx context.Context, name string) ([]string, error) {
+	var perms []string
+	err := retry(ctx, func() (err error) {
+		perms, err = r.FbIf.GetOperator(ctx, name)
+		return err
+	})
+	return perms, err
+}
+
+func (r *Retry) DeletePending(ctx context.Context, id string) error {
+	return retry(ctx, func() error { return r.FbIf.DeletePending(ctx, id) })
+}
+
+// WatchSpecs listens again after temporary failures. Every callback has all privileged users, so
+// repeated ones do no harm.
+func (r *Retry) WatchSpecs(ctx context.Context, cb func(privileged map[string]any)) error {
+	return retryWatch(ctx, func(reset func()) error {
+		return r.FbIf.WatchSpecs(ctx, func(privileged map[string]any) {
+			reset()
+			cb(privileged)
+		})
+	})
+}
+
+// WatchChanges listens again after temporary failures. Every callback has all revisions, so
+// repeated ones do no harm.
+func (r *Retry) WatchChanges(ctx context.Context, cb func(revisions map[string]any)) error {
+	return retryWatch(ctx, func(reset func()) error {
+		return r.FbIf.WatchChanges(ctx, func(revisions map[string]any) {
+			reset()
+			cb(revisions)
+		})
+	})
+}
+
+// noRetry marks an error not to be retried.
+type noRetry struct {
+	error
+}
+
+func (e noRetry) Unwrap() error {
+	return e.error
+}
+
+// retry calls f until it succeeds, fails with an error not worth retrying, runs out of retries,
+// or the context is done. The waits between the calls grow exponentially with random jitter. Every
+// error is shown as the last one.
+func retry(ctx context.Context, f func() error) error {
+	return retryWatch(ctx, func(func()) error { return f() })
+}
+
+// retryWatch is retry for long running calls: f calls reset on every success within, like a
+// snapshot of a watch, to start over with the retries and the waits. So only failures in a row
+// stop it.
+func retryWatch(ctx context.Context, f func(reset func()) error) error {
+	wait, attempt := conf.Backoff, 0
+	reset := func() { wait, attempt = conf.Backoff, 0 }
+	for ; ; attempt++ {
+		err := f(reset)
+		var nr noRetry
+		if errors.As(err, &nr) {
+			err = nr.error
+		}
+		if err == nil {
+			return nil
+		}
+
+		lastError(err)
+		if nr.error != nil || attempt >= conf.Retries || !retryable(err) {
+			return err
+		}
+
+		d := wait/2 + rand.N(wait/2+1)
+		log.Lgr.Warn("retrying", zap.Int("attempt", attempt+1), zap.Duration("wait", d), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+
+		wait = min(wait*2, maxBackoff)
+	}
+}
+
+// lastError shows the given error in the GUI.
+func lastError(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	common.Fe.QueueUpdateDraw(func() { common.Fe.SetLastError(err.Error()) })
+}
+
+// retryable checks if the error is temporary: an unavailable or overloaded gRPC or HTTP backend,
+// or a network timeout.
+func retryable(err error) bool {
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.ResourceExhausted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var gErr *googleapi.Error
+	if errors.As(err, &gErr) {
+		return retryableHTTP(gErr.Code)
+	}
+
+	// Auth errors have the HTTP status in their text only.
+	var code int
+	if auth.IsUnknown(err) {
+		if _, e := fmt.Sscanf(err.Error(), "http error status: %d", &code); e == nil {
+			return retryableHTTP(code)
+		}
+	}
+
+	var nErr net.Error
+	return errors.As(err, &nErr) && nErr.Timeout()
+}
+
+func retryableHTTP(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
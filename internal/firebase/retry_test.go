@@ -0,0 +1,246 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "unavailable", err: status.Error(codes.Unavailable, "down"), want: true},
+		{name: "quota", err: fmt.Errorf("wrapped: %w", status.Error(codes.ResourceExhausted, "quota")), want: true},
+		{name: "denied", err: status.Error(codes.PermissionDenied, "denied")},
+		{name: "http unavailable", err: &googleapi.Error{Code: http.StatusServiceUnavailable}, want: true},
+		{name: "http not found", err: &googleapi.Error{Code: http.StatusNotFound}},
+		{name: "other", err: testutil.ErrMock},
+		{name: "canceled", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retryable(tt.err))
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	conf.Retries, conf.Backoff = 2, time.Millisecond
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "succeeds first",
+			errs:      []error{nil},
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds after retries",
+			errs:      []error{unavailable, unavailable, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "runs out of retries",
+			errs:      []error{unavailable, unavailable, unavailable},
+			wantCalls: 3,
+			wantErr:   unavailable,
+		},
+		{
+			name:      "doesn't retry others",
+			errs:      []error{testutil.ErrMock},
+			wantCalls: 1,
+			wantErr:   testutil.ErrMock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFb := mock.NewMockFbIf(ctrl)
+
+			failures := 0
+			for _, err := range tt.errs {
+				if err != nil {
+					failures++
+				}
+			}
+			mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(failures)
+			mockFe.EXPECT().SetLastError(gomock.Any()).Times(failures)
+
+			calls := 0
+			mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid1", gomock.Any()).DoAndReturn(
+				func(context.Context, string, map[string]any) error {
+					calls++
+					return tt.errs[calls-1]
+				}).Times(tt.wantCalls)
+
+			err := NewRetry(mockFb).StoreAuthClaims(context.Background(), "uid1", nil)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestRetryPages(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	conf.Retries, conf.Backoff = 2, time.Millisecond
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(2)
+	mockFe.EXPECT().SetLastError(gomock.Any()).Times(2)
+
+	user := func(uid string) *auth.UserRecord { return &auth.UserRecord{UserInfo: &auth.UserInfo{UID: uid}} }
+	gomock.InOrder(
+		mockFb.EXPECT().IterUsers(gomock.Any(), "", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, cb func(*auth.UserRecord) error, onPage func(string) error) error {
+				_ = cb(user("uid1"))
+				if err := onPage("token1"); err != nil {
+					return err
+				}
+				_ = cb(user("uid2"))
+				return unavailable
+			}),
+		mockFb.EXPECT().IterUsers(gomock.Any(), "token1", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ string, cb func(*auth.UserRecord) error, onPage func(string) error) error {
+				_ = cb(user("uid2"))
+				return onPage("")
+			}),
+		mockFb.EXPECT().Search(gomock.Any(), "email", "user", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, cb func(string) error) error {
+				_ = cb("uid1")
+				return unavailable
+			}),
+		mockFb.EXPECT().Search(gomock.Any(), "email", "user", gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _ string, cb func(string) error) error {
+				_ = cb("uid1")
+				return cb("uid2")
+			}),
+	)
+
+	fb := NewRetry(mockFb)
+
+	var uids, tokens []string
+	err := fb.IterUsers(context.Background(), "", func(r *auth.UserRecord) error {
+		uids = append(uids, r.UID)
+		return nil
+	}, func(next string) error {
+		tokens = append(tokens, next)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uid1", "uid2"}, uids, "users of the failed page aren't duplicated")
+	assert.Equal(t, []string{"token1", ""}, tokens)
+
+	uids = nil
+	err = fb.Search(context.Background(), "email", "user", func(uid string) error {
+		uids = append(uids, uid)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"uid1", "uid2"}, uids, "results of the failed search aren't duplicated")
+}
+
+func TestRetryWatch(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	conf.Retries, conf.Backoff = 2, time.Millisecond
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(5)
+	mockFe.EXPECT().SetLastError(gomock.Any()).Times(5)
+
+	// every snapshot starts over with the retries, only failures in a row stop the watch
+	calls := 0
+	mockFb.EXPECT().WatchSpecs(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(map[string]any)) error {
+			calls++
+			if calls <= 3 {
+				cb(map[string]any{"uid1": "user1@example.com"})
+			}
+			return unavailable
+		}).Times(5)
+
+	snapshots := 0
+	err := NewRetry(mockFb).WatchSpecs(context.Background(), func(map[string]any) { snapshots++ })
+	assert.Equal(t, unavailable, err)
+	assert.Equal(t, 3, snapshots)
+}
+
+func TestRetryAddPending(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	conf.Retries, conf.Backoff = 2, time.Millisecond
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+	mockFe.EXPECT().SetLastError(gomock.Any()).Times(1)
+
+	// the first try may have been stored, so the retry uses the same ID
+	var ids []string
+	mockFb.EXPECT().AddPending(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, p *common.PendingChange) error {
+			if len(p.ID) == 0 {
+				p.ID = "p1"
+			}
+			ids = append(ids, p.ID)
+			if len(ids) == 1 {
+				return unavailable
+			}
+			return nil
+		}).Times(2)
+
+	assert.NoError(t, NewRetry(mockFb).AddPending(context.Background(), &common.PendingChange{}))
+	assert.Equal(t, []string{"p1", "p1"}, ids)
+}
@@ -0,0 +1,121 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+var ErrNotEmulator error = &common.ClassError{Class: common.ExitUsage, Err: lang.NewError(&lang.ErrNotEmulatorS)}
+
+// fixtureUser is a user to create in the emulator. Claims are true or a date in common.StoreDateFormat.
+type fixtureUser struct {
+	UID    string         `yaml:"uid"`
+	Email  string         `yaml:"email"`
+	Name   string         `yaml:"name"`
+	Claims map[string]any `yaml:"claims"`
+}
+
+// fixtures is the file of users to seed the emulator with.
+type fixtures struct {
+	Users []fixtureUser `yaml:"users"`
+}
+
+// loadFixtures reads the users of the fixtures file, checking them.
+func loadFixtures(path string) ([]fixtureUser, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(lang.ErrFixtures, err)
+	}
+
+	var fx fixtures
+	if err = yaml.Unmarshal(data, &fx); err != nil {
+		return nil, fmt.Errorf(lang.ErrFixtures, err)
+	}
+
+	for i, u := range fx.Users {
+		if len(u.UID) == 0 || len(u.Email) == 0 {
+			return nil, fmt.Errorf(lang.ErrFixtureUser, i+1)
+		}
+
+		for perm, value := range u.Claims {
+			if _, ok := common.PermsMap[perm]; !ok {
+				return nil, fmt.Errorf(lang.ErrFixturePerm, u.UID, perm)
+			}
+
+			if date, ok := value.(time.Time); ok { // unquoted dates are parsed by YAML
+				value = date.Format(common.StoreDateFormat)
+				u.Claims[perm] = value
+			}
+
+			if _, err = common.NewClaimFrom(value); err != nil {
+				return nil, fmt.Errorf(lang.ErrFixtureClaim, u.UID, perm, err)
+			}
+		}
+	}
+
+	return fx.Users, nil
+}
+
+// Seed creates the users of the fixtures file in the emulator: Auth users with their claims, their
+// profile documents, and the cache of privileged users, which is overwritten. Returns the number of
+// users created.
+func Seed(path string) (int, error) {
+	if !conf.UseEmu {
+		return 0, ErrNotEmulator
+	}
+
+	users, err := loadFixtures(path)
+	if err != nil {
+		return 0, err
+	}
+
+	privileged := make(map[string]any)
+	for _, u := range users {
+		if err = seedUser(u); err != nil {
+			return 0, fmt.Errorf(lang.ErrSeedUser, u.Email, err)
+		}
+
+		claims, err := common.NewClaimsMapFrom(u.Claims)
+		if err != nil {
+			return 0, err
+		}
+		if granted(*claims) {
+			privileged[u.UID] = u.Email
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	if err = common.Fb.SetSpecs(ctx, privileged); err != nil {
+		return 0, fmt.Errorf(lang.ErrSeedSpecs, err)
+	}
+
+	return len(users), nil
+}
+
+// seedUser creates an Auth user with its claims and profile document.
+func seedUser(u fixtureUser) error {
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	if err := common.Fb.CreateUser(ctx, u.UID, u.Email, u.Name); err != nil {
+		return err
+	}
+
+	if len(u.Claims) > 0 {
+		if err := common.Fb.StoreAuthClaims(ctx, u.UID, u.Claims); err != nil {
+			return err
+		}
+	}
+
+	return common.Fb.SetProfile(ctx, u.UID, map[string]any{"email": u.Email, "name": u.Name})
+}
@@ -0,0 +1,112 @@
+package firebase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/mock"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantUsers []fixtureUser
+		wantMsg   string
+	}{
+		{
+			name: "valid",
+			input: `users:
+  - uid: uid1
+    email: user1@example.com
+    name: User One
+    claims:
+      admin: true
+      consultant: 2030-01-02
+  - uid: uid2
+    email: user2@example.com`,
+			wantUsers: []fixtureUser{
+				{UID: "uid1", Email: "user1@example.com", Name: "User One",
+					Claims: map[string]any{common.Admin: true, "consultant": "2030-01-02"}},
+				{UID: "uid2", Email: "user2@example.com"},
+			},
+		},
+		{
+			name:    "missing email",
+			input:   "users: [{uid: uid1}]",
+			wantMsg: "fixture user 1: uid and email are required",
+		},
+		{
+			name:    "unknown permission",
+			input:   "users: [{uid: uid1, email: user1@example.com, claims: {janitor: true}}]",
+			wantMsg: "fixture user uid1 has an unknown permission: janitor",
+		},
+		{
+			name:    "wrong claim",
+			input:   "users: [{uid: uid1, email: user1@example.com, claims: {admin: 3}}]",
+			wantMsg: "fixture user uid1, permission admin: " + common.ErrWrongDBClaim.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fixtures.yml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.input), 0o600))
+
+			users, err := loadFixtures(path)
+
+			if len(tt.wantMsg) > 0 {
+				assert.EqualError(t, err, tt.wantMsg)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUsers, users)
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+	defer func() { conf.UseEmu = false }()
+
+	path := filepath.Join(t.TempDir(), "fixtures.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`users:
+  - uid: uid1
+    email: user1@example.com
+    name: User One
+    claims: {admin: true}
+  - uid: uid2
+    email: user2@example.com
+    claims: {admin: false}`), 0o600))
+
+	conf.UseEmu = false
+	_, err := Seed(path)
+	assert.ErrorIs(t, err, ErrNotEmulator, "never seeds production")
+
+	conf.UseEmu = true
+	gomock.InOrder(
+		mockFb.EXPECT().CreateUser(gomock.Any(), "uid1", "user1@example.com", "User One").Return(nil),
+		mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid1", map[string]any{common.Admin: true}).Return(nil),
+		mockFb.EXPECT().SetProfile(gomock.Any(), "uid1", map[string]any{"email": "user1@example.com", "name": "User One"}).Return(nil),
+		mockFb.EXPECT().CreateUser(gomock.Any(), "uid2", "user2@example.com", "").Return(nil),
+		mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid2", map[string]any{common.Admin: false}).Return(nil),
+		mockFb.EXPECT().SetProfile(gomock.Any(), "uid2", map[string]any{"email": "user2@example.com", "name": ""}).Return(nil),
+		mockFb.EXPECT().SetSpecs(gomock.Any(), map[string]any{"uid1": "user1@example.com"}).Return(nil),
+	)
+
+	n, err := Seed(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
@@ -1,6 +1,7 @@
 package firebase
 
 import (
+	"context"
 	"fmt"
 	"maps"
 
@@ -16,14 +17,14 @@ import (
 // newUserFromAuth converts firebase auth user to User and saves it to local cache if needed.
 // Claims are filtered to the permissions we're interested in. Returns the converted User,
 // and if it differs from an already downloaded version.
-func newUserFromAuth(r *auth.UserRecord, act int, privileged map[string]any, updates map[string]any) (*global.User, error) {
+func newUserFromAuth(s *global.Session, r *auth.UserRecord, act int, privileged map[string]any, updates map[string]any) (*global.User, error) {
 	filtered := filterClaims(r.CustomClaims)
 	uid := r.UID
-	u, ok := global.LocalUsers[uid]
+	u, ok := s.LocalUsers[uid]
 	if !ok {
 		u = &global.User{Name: r.DisplayName, Email: r.Email, Claims: filtered, UID: uid}
 		if act != actRefresh || len(filtered) > 0 {
-			global.LocalUsers[uid] = u
+			s.LocalUsers[uid] = u
 		}
 
 		// user just saved, can't be
@@ -36,7 +37,7 @@ func newUserFromAuth(r *auth.UserRecord, act int, privileged map[string]any, upd
 
 	toCompare := u.Claims
 	if act == actSave {
-		toCompare = util.FixedUserClaims(uid)
+		toCompare = util.FixedUserClaims(s, uid)
 		if !differs(toCompare, filtered) {
 			return u, nil
 		}
@@ -63,8 +64,14 @@ func newUserFromAuth(r *auth.UserRecord, act int, privileged map[string]any, upd
 	window.WriteErrorStr(lang.ConfirmSaveS)
 
 	var err error
+	answering := true // answered right away on the command line, there's no GUI to freeze there
 
 	window.ShowConfirm(func() {
+		if !answering {
+			go restoreClaims(s, r, u, toCompare, hasClaims)
+			return
+		}
+
 		if hasClaims {
 			privileged[uid] = struct{}{}
 
@@ -78,19 +85,53 @@ func newUserFromAuth(r *auth.UserRecord, act int, privileged map[string]any, upd
 			delete(privileged, uid)
 		}
 
-		if err = setPermissions(r, toCompare); err != nil {
+		if _, err = storeClaims(context.Background(), r, toCompare); err != nil {
 			err = fmt.Errorf(lang.ErrSetPerms, err)
 			return
 		}
 
 		u.Claims = toCompare
 	}, func() {
-		global.LocalUsers[uid] = u
+		s.LocalUsers[uid] = u
 	})
+	answering = false
 
 	return u, err
 }
 
+// restoreClaims stores the cached claims of a user changed by others again in the background, and
+// its privileged state in a transaction. The cache is updated on the GUI goroutine when it's done.
+func restoreClaims(s *global.Session, r *auth.UserRecord, u *global.User, claims common.ClaimsMap, hasClaims bool) {
+	ctx := context.Background() // the calls have their own timeouts
+	_, err := storeClaims(ctx, r, claims)
+	if err == nil {
+		err = common.Fb.RunTransaction(ctx, func(tr *firestore.Transaction, privileged map[string]any) error {
+			_, isPrivileged := privileged[r.UID]
+			switch {
+			case hasClaims && !isPrivileged:
+				return doUpdate(tr, map[string]any{r.UID: r.Email})
+			case !hasClaims && isPrivileged:
+				return doUpdate(tr, map[string]any{r.UID: firestore.Delete})
+			}
+			return nil
+		})
+	}
+
+	common.Fe.QueueUpdateDraw(func() {
+		if err != nil {
+			window.ShowErrorBuffer(fmt.Errorf(lang.ErrSetPerms, err))
+			return
+		}
+
+		u.Claims = claims
+		if hasClaims {
+			s.LocalPrivileged[r.UID] = struct{}{}
+		} else {
+			delete(s.LocalPrivileged, r.UID)
+		}
+	})
+}
+
 // fltrClaims removes all claims but permissions we're interested in.
 func filterClaims(c map[string]any) common.ClaimsMap {
 	var err error
@@ -106,8 +147,22 @@ func filterClaims(c map[string]any) common.ClaimsMap {
 	return perms
 }
 
+// granted checks if any of the permissions is given.
+func granted(c common.ClaimsMap) bool {
+	for _, value := range c {
+		if !value.IsZero() {
+			return true
+		}
+	}
+
+	return false
+}
+
 func merge(a map[string]any, d common.ClaimsMap) map[string]any {
 	b := maps.Clone(a)
+	if b == nil { // user without custom claims
+		b = make(map[string]any, len(d))
+	}
 	mergeIn(b, d)
 	return b
 }
@@ -1,8 +1,10 @@
 package firebase
 
 import (
+	"context"
 	"testing"
 
+	"cloud.google.com/go/firestore"
 	"firebase.google.com/go/auth"
 	"github.com/stretchr/testify/assert"
 	"github.com/vendelin8/firemage/internal/common"
@@ -13,6 +15,7 @@ import (
 )
 
 func TestNewUserFromAuth(t *testing.T) {
+	s := global.NewSession()
 	tests := []struct {
 		name              string
 		userInCache       bool
@@ -85,7 +88,7 @@ func TestNewUserFromAuth(t *testing.T) {
 
 			testutil.InitLog()
 
-			global.LocalUsers = make(map[string]*global.User)
+			s.LocalUsers = make(map[string]*global.User)
 
 			testuid := "test-uid-123"
 			testEmail := "test@example.com"
@@ -94,7 +97,7 @@ func TestNewUserFromAuth(t *testing.T) {
 			// Set up cached user if needed
 			if tt.userInCache {
 				cm, _ := common.NewClaimsMapFrom(tt.cachedClaims)
-				global.LocalUsers[testuid] = &global.User{
+				s.LocalUsers[testuid] = &global.User{
 					UID:    testuid,
 					Email:  testEmail,
 					Name:   testName,
@@ -135,7 +138,7 @@ func TestNewUserFromAuth(t *testing.T) {
 					Times(1)
 			}
 
-			user, err := newUserFromAuth(authRecord, tt.action, privileged, updates)
+			user, err := newUserFromAuth(s, authRecord, tt.action, privileged, updates)
 
 			assert.ErrorIs(t, err, tt.wantError)
 			assert.NotNil(t, user)
@@ -146,12 +149,57 @@ func TestNewUserFromAuth(t *testing.T) {
 			// Verify caching behavior
 			if !tt.userInCache && !tt.shouldShowConfirm {
 				if tt.action != actRefresh || len(tt.authClaims) > 0 {
-					assert.Equal(t, user, global.LocalUsers[testuid], "user should be cached")
+					assert.Equal(t, user, s.LocalUsers[testuid], "user should be cached")
 				}
 			}
 
 			// Cleanup
-			global.LocalUsers = make(map[string]*global.User)
 		})
 	}
 }
+
+func TestNewUserFromAuthConfirmedLater(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	s := global.NewSession()
+	cm, _ := common.NewClaimsMapFrom(map[string]any{common.Admin: true})
+	s.LocalUsers["uid1"] = &global.User{UID: "uid1", Email: "user1@example.com", Claims: *cm}
+	r := &auth.UserRecord{UserInfo: &auth.UserInfo{UID: "uid1", Email: "user1@example.com"},
+		CustomClaims: map[string]any{common.Admin: false}}
+
+	var onYes func()
+	mockFe.EXPECT().ShowConfirm(gomock.Any(), gomock.Any(), gomock.Any()).
+		Do(func(yes, _ func(), _ ...string) { onYes = yes }).Times(1)
+
+	_, err := newUserFromAuth(s, r, actList, nil, nil)
+	assert.NoError(t, err)
+
+	// the GUI answers later, the write runs in the background
+	ui := make(chan func())
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { ui <- f }).Times(1)
+	mockFb.EXPECT().StoreAuthClaims(gomock.Any(), "uid1", gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, claims map[string]any) error {
+			assert.Equal(t, true, claims[common.Admin])
+			return nil
+		}).Times(1)
+	mockFb.EXPECT().RunTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, cb func(*firestore.Transaction, map[string]any) error) error {
+			return cb(nil, map[string]any{})
+		}).Times(1)
+	mockFb.EXPECT().UpdateSpecs(gomock.Any(), map[string]any{"uid1": "user1@example.com"}).Return(nil).Times(1)
+
+	onYes()
+	(<-ui)()
+
+	assert.Contains(t, s.LocalPrivileged, "uid1")
+	assert.True(t, s.LocalUsers["uid1"].Claims[common.Admin].Checked)
+}
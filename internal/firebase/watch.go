@@ -0,0 +1,271 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/auth"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// revisionsKept is how long the revisions of saved users are kept in the claimChanges document.
+// Watches reconnecting later miss the changes of the dropped ones.
+const revisionsKept = 24 * time.Hour
+
+// instance identifies this process in the revisions of the claimChanges document, to tell its own
+// saves from the ones of others.
+var instance = strconv.FormatUint(rand.Uint64(), 36)
+
+// watch starts listening to the changes of the privileged users and of the claims saved by others
+// in the background. Updates are run on the GUI goroutine.
+func watch(f *Firebase) {
+	go func() {
+		err := f.WatchSpecs(context.Background(), func(privileged map[string]any) {
+			common.Fe.QueueUpdateDraw(func() { onSpecsChange(f.s, privileged) })
+		})
+		if err != nil {
+			common.Fe.QueueUpdateDraw(func() { window.ShowErrorBuffer(fmt.Errorf(lang.ErrWatch, err)) })
+		}
+	}()
+
+	go func() {
+		err := f.WatchChanges(context.Background(), func(revisions map[string]any) {
+			common.Fe.QueueUpdateDraw(func() { onClaimsChange(f.s, revisions) })
+		})
+		if err != nil {
+			common.Fe.QueueUpdateDraw(func() { window.ShowErrorBuffer(fmt.Errorf(lang.ErrWatch, err)) })
+		}
+	}()
+}
+
+// revision returns a new revision of users saved by this process.
+func revision() string {
+	return fmt.Sprintf("%s %d", instance, time.Now().UnixNano())
+}
+
+// staleRevisions returns the uids with revisions saved longer than revisionsKept before now.
+// Unknown revisions are kept.
+func staleRevisions(revisions map[string]any, now time.Time) []string {
+	var uids []string
+	for uid, rev := range revisions {
+		var id string
+		var nanos int64
+		if _, err := fmt.Sscanf(fmt.Sprint(rev), "%s %d", &id, &nanos); err != nil {
+			continue
+		}
+
+		if now.Sub(time.Unix(0, nanos)) > revisionsKept {
+			uids = append(uids, uid)
+		}
+	}
+
+	return uids
+}
+
+// onSpecsChange marks the privileged users added or removed by others, and downloads the added ones
+// not known yet in the background. The users are laid out again only if the marks changed.
+func onSpecsChange(s *global.Session, privileged map[string]any) {
+	prev := maps.Clone(s.RemoteChanges)
+	clear(s.RemoteChanges)
+
+	var missing []auth.UserIdentifier
+	for uid := range privileged {
+		if _, ok := s.LocalPrivileged[uid]; ok {
+			continue
+		}
+
+		s.RemoteChanges[uid] = true
+		if _, ok := s.LocalUsers[uid]; ok {
+			listRemoteUser(s, uid)
+		} else {
+			missing = append(missing, auth.UIDIdentifier{UID: uid})
+		}
+	}
+
+	for uid := range s.LocalPrivileged {
+		if _, ok := privileged[uid]; !ok {
+			s.RemoteChanges[uid] = false
+		}
+	}
+
+	if len(missing) > 0 {
+		go fetchRemoteUsers(s, missing)
+	}
+
+	if !maps.Equal(prev, s.RemoteChanges) {
+		common.Fe.LayoutUsers()
+	}
+}
+
+// onClaimsChange marks the users saved by others since the previous snapshot. The first snapshot
+// is only remembered, it's as old as the loaded users.
+func onClaimsChange(s *global.Session, revisions map[string]any) {
+	seen := s.SeenRevisions
+	s.SeenRevisions = revisions
+	if seen == nil {
+		return
+	}
+
+	changed := false
+	for uid, rev := range revisions {
+		if rev == seen[uid] || strings.HasPrefix(fmt.Sprint(rev), instance+" ") {
+			continue
+		}
+
+		if _, ok := s.RemoteEdits[uid]; !ok {
+			s.RemoteEdits[uid] = struct{}{}
+			changed = true
+		}
+	}
+
+	if changed {
+		common.Fe.LayoutUsers()
+	}
+}
+
+// fetchRemoteUsers downloads users added by others, and adds them to the local cache on the GUI goroutine.
+func fetchRemoteUsers(s *global.Session, uids []auth.UserIdentifier) {
+	var users []*auth.UserRecord
+	for chunk := range slices.Chunk(uids, conf.BatchSize) {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+		rs, err := common.Fb.GetUsers(ctx, chunk)
+		cancel()
+
+		if err != nil {
+			common.Fe.QueueUpdateDraw(func() { window.ShowErrorBuffer(fmt.Errorf(lang.ErrGetAuthUsers, err)) })
+			return
+		}
+		users = append(users, rs.Users...)
+	}
+
+	common.Fe.QueueUpdateDraw(func() {
+		for _, r := range users {
+			if _, ok := s.LocalUsers[r.UID]; !ok {
+				s.LocalUsers[r.UID] = &global.User{UID: r.UID, Name: r.DisplayName, Email: r.Email,
+					Claims: filterClaims(r.CustomClaims)}
+			}
+			listRemoteUser(s, r.UID)
+		}
+		common.Fe.LayoutUsers()
+	})
+}
+
+// listRemoteUser adds a user added by others to the List page.
+func listRemoteUser(s *global.Session, uid string) {
+	users, isList := s.SavedUsers[lang.PageList], common.Fe.CurrentPage() == lang.PageList
+	if isList {
+		users = s.CrntUsers
+	}
+
+	if slices.Contains(users, uid) {
+		return
+	}
+
+	users = append(users, uid)
+	if isList {
+		s.CrntUsers = users
+	} else {
+		s.SavedUsers[lang.PageList] = users
+	}
+}
+
+// Conflicts returns the email addresses of users with pending actions changed by others since loading.
+func Conflicts(s *global.Session) []string {
+	var emails []string
+	for _, uid := range slices.Sorted(maps.Keys(s.Actions)) {
+		_, added := s.RemoteChanges[uid]
+		_, edited := s.RemoteEdits[uid]
+		if added || edited {
+			emails = append(emails, identifierName(s, auth.UIDIdentifier{UID: uid}))
+		}
+	}
+
+	return emails
+}
+
+// Reload downloads the claims of users changed by others in the background. Then it applies the
+// changes to the privileged users and clears their marks on the GUI goroutine. Errors of starting
+// are returned, otherwise done is called with the result.
+func Reload(s *global.Session, done func(error)) error {
+	uids := slices.Sorted(maps.Keys(s.RemoteChanges))
+	for uid := range s.RemoteEdits {
+		if _, ok := s.RemoteChanges[uid]; !ok {
+			uids = append(uids, uid)
+		}
+	}
+	slices.Sort(uids)
+
+	ids := make([]auth.UserIdentifier, len(uids))
+	for i, uid := range uids {
+		ids[i] = auth.UIDIdentifier{UID: uid}
+	}
+
+	ctx, cancel, err := startJob(s, 0)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		defer cancel()
+
+		var records []*auth.UserRecord
+		missing, err := downloadClaims(ctx, ids, func(r *auth.UserRecord) error {
+			records = append(records, r)
+			return nil
+		}, nil)
+
+		common.Fe.QueueUpdateDraw(func() {
+			endJob(s)
+			if err != nil {
+				done(canceled(err))
+				return
+			}
+
+			done(reloaded(s, uids, records, missing))
+		})
+	}()
+
+	return nil
+}
+
+// reloaded applies the downloaded claims of users changed by others, and clears their marks.
+func reloaded(s *global.Session, uids []string, records []*auth.UserRecord, missing []auth.UserIdentifier) error {
+	for _, r := range records {
+		if u, ok := s.LocalUsers[r.UID]; ok {
+			u.Claims = filterClaims(r.CustomClaims)
+			continue
+		}
+
+		if _, err := newUserFromAuth(s, r, actSearch, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	warnMissing(s, missing)
+
+	for _, uid := range uids {
+		if added, ok := s.RemoteChanges[uid]; ok {
+			if added {
+				s.LocalPrivileged[uid] = struct{}{}
+			} else {
+				delete(s.LocalPrivileged, uid)
+			}
+		}
+
+		delete(s.RemoteChanges, uid)
+		delete(s.RemoteEdits, uid)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package firebase
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"firebase.google.com/go/auth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestOnSpecsChange(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	s.LocalPrivileged = map[string]struct{}{"uid1": {}, "uid2": {}}
+	s.LocalUsers = map[string]*global.User{"uid3": {UID: "uid3"}}
+	s.CrntUsers = []string{"uid1", "uid2"}
+	s.RemoteChanges = map[string]bool{"old": true}
+
+	mockFe.EXPECT().CurrentPage().Return(lang.PageList).Times(1)
+	mockFe.EXPECT().LayoutUsers().Times(1)
+
+	onSpecsChange(s, map[string]any{"uid1": "user1@example.com", "uid3": "user3@example.com"})
+
+	assert.Equal(t, map[string]bool{"uid2": false, "uid3": true}, s.RemoteChanges)
+	assert.Equal(t, []string{"uid1", "uid2", "uid3"}, s.CrntUsers)
+
+	// the same snapshot again doesn't lay out the users, so the focus is kept
+	mockFe.EXPECT().CurrentPage().Return(lang.PageList).Times(1)
+	onSpecsChange(s, map[string]any{"uid1": "user1@example.com", "uid3": "user3@example.com"})
+
+	assert.Equal(t, map[string]bool{"uid2": false, "uid3": true}, s.RemoteChanges)
+}
+
+func TestOnClaimsChange(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	own := revision()
+
+	// the first snapshot is as old as the loaded users
+	onClaimsChange(s, map[string]any{"uid1": "other 1"})
+	assert.Empty(t, s.RemoteEdits)
+
+	mockFe.EXPECT().LayoutUsers().Times(1)
+	onClaimsChange(s, map[string]any{"uid1": "other 2", "uid2": own, "uid3": "other 3"})
+	assert.Equal(t, map[string]struct{}{"uid1": {}, "uid3": {}}, s.RemoteEdits)
+
+	// saved by this process, or not changed
+	onClaimsChange(s, map[string]any{"uid1": "other 2", "uid2": revision(), "uid3": "other 3"})
+	assert.Equal(t, map[string]struct{}{"uid1": {}, "uid3": {}}, s.RemoteEdits)
+}
+
+func TestStaleRevisions(t *testing.T) {
+	now := time.Now()
+	revisions := map[string]any{
+		"uid1": fmt.Sprintf("other %d", now.Add(-revisionsKept-time.Minute).UnixNano()),
+		"uid2": fmt.Sprintf("other %d", now.Add(-time.Minute).UnixNano()),
+		"uid3": "unknown",
+	}
+
+	assert.Equal(t, []string{"uid1"}, staleRevisions(revisions, now))
+}
+
+func TestFetchRemoteUsers(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	s.LocalUsers = map[string]*global.User{}
+	s.SavedUsers = map[string][]string{lang.PageList: {"uid1"}}
+
+	ids := []auth.UserIdentifier{auth.UIDIdentifier{UID: "uid2"}}
+	mockFb.EXPECT().GetUsers(gomock.Any(), ids).Return(&auth.GetUsersResult{Users: []*auth.UserRecord{{
+		UserInfo:     &auth.UserInfo{UID: "uid2", Email: "user2@example.com"},
+		CustomClaims: map[string]any{common.Admin: true},
+	}}}, nil).Times(1)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+	mockFe.EXPECT().CurrentPage().Return(lang.PageSearch).Times(1)
+	mockFe.EXPECT().LayoutUsers().Times(1)
+
+	fetchRemoteUsers(s, ids)
+
+	assert.Equal(t, "user2@example.com", s.LocalUsers["uid2"].Email)
+	assert.True(t, s.LocalUsers["uid2"].Claims[common.Admin].Checked)
+	assert.Equal(t, []string{"uid1", "uid2"}, s.SavedUsers[lang.PageList])
+}
+
+func TestConflictsAndReload(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	s.LocalPrivileged = map[string]struct{}{"uid1": {}}
+	s.LocalUsers = map[string]*global.User{
+		"uid1": {UID: "uid1", Email: "user1@example.com", Claims: *common.NewClaimsMap()},
+		"uid2": {UID: "uid2", Email: "user2@example.com", Claims: *common.NewClaimsMap()},
+		"uid3": {UID: "uid3", Email: "user3@example.com", Claims: *common.NewClaimsMap()},
+	}
+	s.RemoteChanges = map[string]bool{"uid1": false, "uid2": true}
+	s.RemoteEdits = map[string]struct{}{"uid3": {}}
+	s.Actions = map[string]common.ClaimsMap{"uid2": {common.Admin: {}}, "uid3": {common.Admin: {}}}
+
+	assert.Equal(t, []string{"user2@example.com", "user3@example.com"}, Conflicts(s))
+
+	mockFb.EXPECT().GetUsers(gomock.Any(), []auth.UserIdentifier{
+		auth.UIDIdentifier{UID: "uid1"}, auth.UIDIdentifier{UID: "uid2"}, auth.UIDIdentifier{UID: "uid3"},
+	}).Return(&auth.GetUsersResult{Users: []*auth.UserRecord{{
+		UserInfo:     &auth.UserInfo{UID: "uid2", Email: "user2@example.com"},
+		CustomClaims: map[string]any{common.Admin: true},
+	}, {
+		UserInfo:     &auth.UserInfo{UID: "uid3", Email: "user3@example.com"},
+		CustomClaims: map[string]any{common.Consultant: true},
+	}}}, nil).Times(1)
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFe.EXPECT().QueueUpdateDraw(gomock.Any()).Do(func(f func()) { f() }).Times(1)
+
+	done := make(chan error, 1)
+	assert.NoError(t, Reload(s, func(err error) { done <- err }))
+	assert.NoError(t, <-done)
+	assert.False(t, s.Busy())
+	assert.Empty(t, s.RemoteChanges)
+	assert.Empty(t, s.RemoteEdits)
+	assert.True(t, s.LocalUsers["uid3"].Claims[common.Consultant].Checked)
+	assert.Empty(t, Conflicts(s))
+	assert.Equal(t, map[string]struct{}{"uid2": {}}, s.LocalPrivileged)
+	assert.True(t, s.LocalUsers["uid2"].Claims[common.Admin].Checked)
+}
+
+func TestDoUpdate(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFb := mock.NewMockFbIf(ctrl)
+	common.Fb = mockFb
+
+	updates := map[string]any{"uid2": "user2@example.com", "uid3": firestore.Delete}
+	mockFb.EXPECT().UpdateSpecs(gomock.Any(), updates).Return(nil).Times(1)
+
+	assert.NoError(t, doUpdate(nil, updates))
+	assert.Empty(t, s.LocalPrivileged, "applied only after the transaction")
+
+	s.RemoteChanges = map[string]bool{"uid2": true, "uid4": true}
+	applyUpdates(s, map[string]any{"uid1": "user1@example.com", "uid3": "user3@example.com"}, updates)
+	assert.Equal(t, map[string]struct{}{"uid1": {}, "uid2": {}}, s.LocalPrivileged)
+	assert.Equal(t, map[string]bool{"uid4": true}, s.RemoteChanges, "own save isn't a remote change")
+}
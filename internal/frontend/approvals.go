@@ -0,0 +1,113 @@
+package frontend
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/tview"
+)
+
+func (f *Frontend) initApprovals() {
+	f.SetOnShow(lang.PageApprovals, func() {})
+
+	f.pendingDiff = tview.NewTextView().SetWrap(false)
+	f.pendingList = tview.NewList().ShowSecondaryText(false).
+		SetChangedFunc(func(i int, _, _ string, _ rune) {
+			f.pendingDiff.SetText(pendingDiff(f.s.Pending[i]))
+		})
+
+	form := tview.NewForm().
+		AddButton(lang.SApprove, func() {
+			window.ShowErrorBuffer(approve(f.s, f.pendingList.GetCurrentItem()))
+		}).
+		AddButton(lang.SReject, func() {
+			window.ShowErrorBuffer(reject(f.s, f.pendingList.GetCurrentItem()))
+		})
+
+	details := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(f.pendingDiff, 0, 1, false).
+		AddItem(form, 3, 0, false)
+	f.approvalsPage = tview.NewFlex().AddItem(f.pendingList, 0, 1, true).AddItem(details, 0, 2, false)
+}
+
+// LayoutApprovals updates the list of change sets waiting for approval.
+func (f *Frontend) LayoutApprovals() {
+	f.pendingList.Clear()
+	f.pendingDiff.SetText(lang.SNoPending)
+
+	for _, p := range f.s.Pending {
+		text := fmt.Sprintf(lang.SPendingItem, p.Created.Format(common.DateFormat), p.Operator, len(p.After))
+		f.pendingList.AddItem(text, "", 0, nil)
+	}
+
+	if len(f.s.Pending) > 0 {
+		f.pendingDiff.SetText(pendingDiff(f.s.Pending[0]))
+	}
+}
+
+// approve saves the i-th pending change set in the background.
+func approve(s *global.Session, i int) error {
+	return firebase.Approve(firebase.DrySession(s), i, pendingDone(lang.SApproved))
+}
+
+// reject removes the i-th pending change set in the background.
+func reject(s *global.Session, i int) error {
+	return firebase.Reject(firebase.DrySession(s), i, pendingDone(lang.SRejected))
+}
+
+// pendingDone returns the callback of approving or rejecting a change set, showing msg if it's done.
+func pendingDone(msg string) func(error) {
+	return func(err error) {
+		if firebase.IsDryRun() {
+			window.WriteErrorStr(firebase.DryRunReport())
+		}
+		if err != nil || firebase.IsDryRun() {
+			window.ShowErrorBuffer(err)
+			return
+		}
+
+		common.Fe.LayoutApprovals()
+		common.Fe.ShowMsg(msg)
+	}
+}
+
+// pendingDiff returns the changes of a pending change set in human readable form, line by line.
+func pendingDiff(p *common.PendingChange) string {
+	var b strings.Builder
+	for _, uid := range slices.Sorted(maps.Keys(p.After)) {
+		email, ok := p.Emails[uid]
+		if !ok {
+			email = uid
+		}
+		b.WriteString(email)
+		b.WriteByte('\n')
+
+		for _, perm := range slices.Sorted(maps.Keys(p.After[uid])) {
+			fmt.Fprintf(&b, "  %s: %s -> %s\n", common.PermsMap[perm], claimText(p.Before[uid][perm]),
+				claimText(p.After[uid][perm]))
+		}
+	}
+
+	return b.String()
+}
+
+// claimText returns a claim value stored in Firebase auth in human readable form.
+func claimText(a any) string {
+	switch v := a.(type) {
+	case bool:
+		if v {
+			return lang.SActive
+		}
+		return lang.SInactive
+	case string:
+		return v
+	default:
+		return fmt.Sprint(a)
+	}
+}
@@ -0,0 +1,30 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vendelin8/firemage/internal/common"
+)
+
+func TestPendingDiff(t *testing.T) {
+	p := &common.PendingChange{
+		Emails: map[string]string{"uid1": "user1@example.com"},
+		Before: map[string]map[string]any{
+			"uid1": {common.SuperAdmin: false, common.Consultant: true},
+			"uid2": {common.Admin: "2025-01-01"},
+		},
+		After: map[string]map[string]any{
+			"uid1": {common.SuperAdmin: true, common.Consultant: "2025-06-30"},
+			"uid2": {common.Admin: false},
+		},
+	}
+
+	want := "user1@example.com\n" +
+		"  Consultant: Active -> 2025-06-30\n" +
+		"  SuperAdmin: Inactive -> Active\n" +
+		"uid2\n" +
+		"  Admin: 2025-01-01 -> Inactive\n"
+	assert.Equal(t, want, pendingDiff(p))
+}
@@ -0,0 +1,107 @@
+// Package console implements common.FeIf for command line usage, without the GUI.
+package console
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/tview"
+)
+
+// Frontend prints messages to out, and reads confirmations from in.
+type Frontend struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+var _ common.FeIf = (*Frontend)(nil)
+
+func New(in io.Reader, out io.Writer) *Frontend {
+	return &Frontend{in: bufio.NewReader(in), out: out}
+}
+
+func extractMsg(ms ...string) string {
+	if len(ms) > 0 {
+		return ms[0]
+	}
+	return window.PopBuffer()
+}
+
+// ShowMsg prints the given message.
+func (f *Frontend) ShowMsg(ms ...string) {
+	if m := extractMsg(ms...); len(m) > 0 {
+		fmt.Fprintln(f.out, m)
+	}
+}
+
+// ShowConfirm prints the given question, and calls onYes or onNo based on the answer read.
+func (f *Frontend) ShowConfirm(onYes, onNo func(), ms ...string) {
+	fmt.Fprintf(f.out, "%s [%s/%s] ", extractMsg(ms...), lang.SYes, lang.SNo)
+
+	answer, _ := f.in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	label := lang.SNo
+	if len(answer) > 0 && strings.HasPrefix(strings.ToLower(lang.SYes), answer) {
+		label = lang.SYes
+	}
+
+	window.ConfirmDoneFunc(onYes, onNo)(0, label)
+}
+
+// ShowProgress prints the given message if any, there's nothing to cancel from the command line.
+func (f *Frontend) ShowProgress(_ context.Context, _ context.CancelFunc, ms ...string) {
+	if len(ms) > 0 {
+		fmt.Fprintln(f.out, ms[0])
+	}
+}
+
+// SetProgress prints the given progress message.
+func (f *Frontend) SetProgress(m string) {
+	fmt.Fprintln(f.out, m)
+}
+
+// SetLastError prints the given error of a Firebase call.
+func (f *Frontend) SetLastError(m string) {
+	fmt.Fprintf(f.out, "%s: %s\n", lang.SLastError, m)
+}
+
+// QueueUpdateDraw calls f right away, there's no GUI goroutine on the command line.
+func (f *Frontend) QueueUpdateDraw(fn func()) {
+	fn()
+}
+
+// The followings are GUI only functionalities, that do nothing on the command line.
+
+func (f *Frontend) Run() error                                    { return nil }
+func (f *Frontend) CurrentPage() string                           { return "" }
+func (f *Frontend) SetPage(string)                                {}
+func (f *Frontend) SetOnShow(string, func())                      {}
+func (f *Frontend) ClaimButtonSetDisabled(int, bool)              {}
+func (f *Frontend) CancelProgress()                               {}
+func (f *Frontend) HidePopup(string)                              {}
+func (f *Frontend) LayoutUsers()                                  {}
+func (f *Frontend) LayoutApprovals()                              {}
+func (f *Frontend) LayoutExpiring()                               {}
+func (f *Frontend) Quit()                                         {}
+func (f *Frontend) ShowClaimChoser(int, string, common.Claim)     {}
+func (f *Frontend) CreateClaimChoser()                            {}
+func (f *Frontend) ClaimsDateSetDisabled(bool)                    {}
+func (f *Frontend) ClaimsBtns(bool)                               {}
+func (f *Frontend) ClaimsSetDate(time.Time)                       {}
+func (f *Frontend) ClaimsDate() *time.Time                        { return nil }
+func (f *Frontend) ReplaceTableItem(int, string, tview.Primitive) {}
+func (f *Frontend) ShowRoleChoser([]int)                          {}
+func (f *Frontend) ShowExtendChoser([]int)                        {}
+func (f *Frontend) ReplaceUserNames(int)                          {}
+func (f *Frontend) FocusCell(int, string)                         {}
+func (f *Frontend) FocusSearch()                                  {}
+func (f *Frontend) ShowPalette()                                  {}
+func (f *Frontend) UpdateHeader()                                 {}
@@ -0,0 +1,63 @@
+package console
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/lang"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestShowConfirm(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantYes bool
+	}{
+		{name: "short yes", input: "y\n", wantYes: true},
+		{name: "full yes in uppercase", input: strings.ToUpper(lang.SYes) + "\n", wantYes: true},
+		{name: "no", input: "n\n"},
+		{name: "empty line", input: "\n"},
+		{name: "end of input", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			f := New(strings.NewReader(tt.input), &out)
+			common.Fe = f
+
+			yes, no := false, false
+			window.ShowConfirm(func() { yes = true }, func() { no = true }, "Sure?")
+
+			assert.Equal(t, tt.wantYes, yes)
+			assert.Equal(t, !tt.wantYes, no)
+			assert.True(t, strings.HasPrefix(out.String(), "Sure? ["))
+			assert.False(t, window.HasPopup())
+		})
+	}
+}
+
+func TestShowMsg(t *testing.T) {
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	var out strings.Builder
+	f := New(strings.NewReader(""), &out)
+
+	f.ShowMsg("hello")
+	f.ShowMsg("")
+
+	window.UseWarn()
+	window.WriteErrorStr("buffered")
+	f.ShowMsg()
+
+	assert.Equal(t, "hello\nbuffered\n", out.String())
+}
@@ -0,0 +1,272 @@
+package frontend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+	"github.com/vendelin8/tview"
+)
+
+const (
+	calendarWidth = 20 // 7 days of 2 digits, with spaces between
+	calendarRows  = 8  // title, weekdays, and at most 6 weeks
+)
+
+// DatePicker is a form item to choose an expiry. It can be typed in common.DateFormat with an
+// optional time, or as a duration from now like "+3m", or picked in the calendar below the field. Up and Down step a day, PgUp
+// and PgDn a month, Home goes to today. Invalid input is shown in place of the calendar title.
+type DatePicker struct {
+	*tview.InputField
+	date       time.Time // the picked expiry, or the one shown before the input became invalid
+	err        error     // why the input is invalid, nil if it's valid
+	labelWidth int
+	changed    func(d *time.Time)
+}
+
+// NewDatePicker returns a date picker set to today.
+func NewDatePicker() *DatePicker {
+	d := &DatePicker{InputField: tview.NewInputField().SetFieldWidth(calendarWidth)}
+	d.InputField.SetChangedFunc(d.parse)
+	d.SetDate(common.EndOfToday())
+	return d
+}
+
+// SetChangedFunc sets a handler called with the picked date when the input changes, or with nil if
+// it's invalid.
+func (d *DatePicker) SetChangedFunc(handler func(date *time.Time)) *DatePicker {
+	d.changed = handler
+	return d
+}
+
+// Date returns the picked expiry, nil if the input is invalid.
+func (d *DatePicker) Date() *time.Time {
+	if d.err != nil {
+		return nil
+	}
+
+	date := d.date
+	return &date
+}
+
+// SetDate sets the picked expiry. The time is shown only if it's not the end of the day.
+func (d *DatePicker) SetDate(date time.Time) {
+	d.SetText(common.FormatExpiry(date))
+}
+
+// day returns the picked day in common.ExpiryLocation.
+func (d *DatePicker) day() time.Time {
+	return d.date.In(common.ExpiryLocation)
+}
+
+// parse checks the input after every change, and moves the calendar to it.
+func (d *DatePicker) parse(text string) {
+	date, err := util.ParseDate(text, time.Now())
+	if d.err = err; err == nil {
+		d.date = date
+	}
+
+	if d.changed != nil {
+		d.changed(d.Date())
+	}
+}
+
+// move steps the picked date, from today if the input is invalid.
+func (d *DatePicker) move(months, days int) {
+	date := d.day()
+	if d.err != nil {
+		date = common.EndOfToday()
+	}
+
+	d.SetDate(date.AddDate(0, months, days))
+}
+
+// SetFormAttributes sets the attributes shared by all form items.
+func (d *DatePicker) SetFormAttributes(labelWidth int, labelColor, bgColor, fieldTextColor,
+	fieldBgColor tcell.Color,
+) tview.FormItem {
+	d.labelWidth = labelWidth
+	d.InputField.SetFormAttributes(labelWidth, labelColor, bgColor, fieldTextColor, fieldBgColor)
+	return d
+}
+
+// GetFieldHeight returns the height of the field and the calendar.
+func (d *DatePicker) GetFieldHeight() int {
+	return 1 + calendarRows
+}
+
+// SetDisabled sets whether the date can be changed.
+func (d *DatePicker) SetDisabled(disabled bool) tview.FormItem {
+	d.InputField.SetDisabled(disabled)
+	return d
+}
+
+// calendarX returns the left edge of the calendar, below the field.
+func (d *DatePicker) calendarX() int {
+	x, _, _, _ := d.GetRect()
+	if d.labelWidth > 0 {
+		return x + d.labelWidth
+	}
+
+	return x + tview.TaggedStringWidth(d.GetLabel())
+}
+
+// Draw draws the field and the calendar of the month of the picked date.
+func (d *DatePicker) Draw(screen tcell.Screen) {
+	x, y, width, height := d.GetRect()
+	d.InputField.SetRect(x, y, width, 1)
+	d.InputField.Draw(screen)
+	d.InputField.SetRect(x, y, width, height)
+
+	color := tview.Styles.PrimaryTextColor
+	if d.GetDisabled() {
+		color = tview.Styles.TertiaryTextColor
+	}
+
+	cx := d.calendarX()
+	for i, line := range d.calendar() {
+		lineWidth := calendarWidth
+		if i == 0 { // the validation error may be longer
+			lineWidth = max(lineWidth, x+width-cx)
+		}
+		if i+1 < height {
+			tview.Print(screen, line, cx, y+1+i, lineWidth, tview.AlignLeft, color)
+		}
+	}
+}
+
+// calendar returns the lines of the calendar: the title or the validation error, the weekdays,
+// and the weeks starting on Monday with the picked day highlighted.
+func (d *DatePicker) calendar() []string {
+	day := d.day()
+	month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	title := fmt.Sprintf(lang.SCalendarTitle, monthName(month.Month()), month.Year())
+	title = fmt.Sprintf("<%s>", centered(title, calendarWidth-2))
+	if d.err != nil {
+		title = fmt.Sprintf("[%s]%s[-]", common.ColorTag(common.CurrentTheme.Negative), tview.Escape(d.err.Error()))
+	}
+
+	lines := []string{title, lang.SWeekdays}
+	offset := (int(month.Weekday()) + 6) % 7 // Monday first
+	days := month.AddDate(0, 1, -1).Day()
+
+	var week strings.Builder
+	week.WriteString(strings.Repeat("   ", offset))
+	for i := 1; i <= days; i++ {
+		cell := fmt.Sprintf("%2d", i)
+		if i == day.Day() && d.err == nil {
+			cell = fmt.Sprintf("[::r]%s[::-]", cell)
+		}
+		week.WriteString(cell)
+
+		if (offset+i)%7 == 0 || i == days {
+			lines = append(lines, week.String())
+			week.Reset()
+		} else {
+			week.WriteByte(' ')
+		}
+	}
+
+	return lines
+}
+
+// dayAt returns the day of the month shown at the given calendar cell, 0 if there's none.
+func (d *DatePicker) dayAt(col, week int) int {
+	if col%3 == 2 || col >= calendarWidth {
+		return 0
+	}
+
+	picked := d.day()
+	month := time.Date(picked.Year(), picked.Month(), 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(month.Weekday()) + 6) % 7
+	day := week*7 + col/3 - offset + 1
+	if day < 1 || day > month.AddDate(0, 1, -1).Day() {
+		return 0
+	}
+
+	return day
+}
+
+// InputHandler handles the calendar navigation keys, and passes the others to the field. A duration
+// is replaced with its date when leaving the field.
+func (d *DatePicker) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	field := d.InputField.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if d.GetDisabled() {
+			return
+		}
+
+		switch event.Key() {
+		case tcell.KeyUp:
+			d.move(0, -1)
+		case tcell.KeyDown:
+			d.move(0, 1)
+		case tcell.KeyPgUp:
+			d.move(-1, 0)
+		case tcell.KeyPgDn:
+			d.move(1, 0)
+		case tcell.KeyHome:
+			d.SetDate(common.EndOfToday())
+		case tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab:
+			if d.err == nil && strings.HasPrefix(strings.TrimSpace(d.GetText()), "+") {
+				d.SetDate(d.date)
+			}
+			field(event, setFocus)
+		default:
+			field(event, setFocus)
+		}
+	}
+}
+
+// MouseHandler picks the clicked day, or steps a month with the arrows of the title.
+func (d *DatePicker) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse,
+	setFocus func(p tview.Primitive),
+) (consumed bool, capture tview.Primitive) {
+	field := d.InputField.MouseHandler()
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive),
+	) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+		_, top, _, _ := d.GetRect()
+		if y == top || !d.InRect(x, y) || d.GetDisabled() {
+			return field(action, event, setFocus)
+		}
+		if action != tview.MouseLeftClick {
+			return true, nil
+		}
+
+		col, row := x-d.calendarX(), y-top-1
+		switch {
+		case row == 0 && col == 0:
+			d.move(-1, 0)
+		case row == 0 && col == calendarWidth-1:
+			d.move(1, 0)
+		case row >= 2:
+			if day := d.dayAt(col, row-2); day > 0 {
+				d.SetDate(d.date.AddDate(0, 0, day-d.day().Day()))
+			}
+		}
+		setFocus(d)
+
+		return true, nil
+	}
+}
+
+// monthName returns the name of the month in the current language.
+func monthName(m time.Month) string {
+	names := strings.Fields(lang.SMonths)
+	if len(names) != 12 {
+		return m.String()
+	}
+
+	return names[m-1]
+}
+
+// centered pads the text with spaces to the middle of the given width.
+func centered(text string, width int) string {
+	pad := max(width-tview.TaggedStringWidth(text), 0)
+	return strings.Repeat(" ", pad/2) + text + strings.Repeat(" ", pad-pad/2)
+}
@@ -0,0 +1,132 @@
+package frontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/tview"
+)
+
+func TestDatePickerInput(t *testing.T) {
+	defer func(layout string) { common.DateFormat = layout }(common.DateFormat)
+	common.DateFormat = "2006.01.02."
+	today := time.Now()
+	todayUTC := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	datePtr := func(d time.Time) *time.Time { d = common.ExpiryOf(d); return &d }
+	at := func(d time.Time) *time.Time { return &d }
+
+	tests := []struct {
+		name      string
+		text      string
+		keys      []tcell.Key
+		wantDate  *time.Time
+		wantText  string
+		wantError bool
+		timestamp bool
+	}{
+		{
+			name:     "typed date",
+			text:     "2026.03.15.",
+			wantDate: datePtr(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)),
+			wantText: "2026.03.15.",
+		},
+		{
+			name:      "typed time",
+			text:      "2026.03.15. 14:30",
+			wantDate:  at(time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)),
+			wantText:  "2026.03.15. 14:30",
+			timestamp: true,
+		},
+		{
+			name:      "typed time stored as a day",
+			text:      "2026.03.15. 14:30",
+			wantText:  "2026.03.15. 14:30",
+			wantError: true,
+		},
+		{
+			name:     "end of month resolved on enter",
+			text:     "+eom",
+			keys:     []tcell.Key{tcell.KeyEnter},
+			wantDate: datePtr(todayUTC.AddDate(0, 1, -todayUTC.Day())),
+			wantText: todayUTC.AddDate(0, 1, -todayUTC.Day()).Format(common.DateFormat),
+		},
+		{
+			name:     "duration resolved on enter",
+			text:     "+1w",
+			keys:     []tcell.Key{tcell.KeyEnter},
+			wantDate: datePtr(todayUTC.AddDate(0, 0, 7)),
+			wantText: todayUTC.AddDate(0, 0, 7).Format(common.DateFormat),
+		},
+		{
+			name:     "day and month steps",
+			text:     "2026.01.31.",
+			keys:     []tcell.Key{tcell.KeyDown, tcell.KeyPgDn, tcell.KeyUp},
+			wantDate: datePtr(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)),
+			wantText: "2026.02.28.",
+		},
+		{
+			name:      "invalid",
+			text:      "2026-01-31",
+			wantText:  "2026-01-31",
+			wantError: true,
+		},
+		{
+			name:     "step from invalid starts today",
+			text:     "x",
+			keys:     []tcell.Key{tcell.KeyDown},
+			wantDate: datePtr(todayUTC.AddDate(0, 0, 1)),
+			wantText: todayUTC.AddDate(0, 0, 1).Format(common.DateFormat),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.StoreTimestamps = tt.timestamp
+			defer func() { common.StoreTimestamps = false }()
+
+			var changed []*time.Time
+			d := NewDatePicker().SetChangedFunc(func(date *time.Time) { changed = append(changed, date) })
+
+			d.SetText(tt.text)
+			for _, key := range tt.keys {
+				d.InputHandler()(tcell.NewEventKey(key, 0, tcell.ModNone), func(tview.Primitive) {})
+			}
+
+			assert.Equal(t, tt.wantText, d.GetText())
+			assert.Equal(t, tt.wantDate, d.Date())
+			assert.Equal(t, tt.wantError, d.err != nil)
+			require.NotEmpty(t, changed)
+			assert.Equal(t, tt.wantDate, changed[len(changed)-1])
+		})
+	}
+}
+
+func TestDatePickerCalendar(t *testing.T) {
+	d := NewDatePicker()
+	d.SetDate(common.ExpiryOf(time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))) // starts on Sunday
+
+	lines := d.calendar()
+
+	assert.Equal(t, []string{
+		"<  February 2026   >",
+		"Mo Tu We Th Fr Sa Su",
+		"                   1",
+		" 2  3  4  5  6  7  8",
+		" 9 [::r]10[::-] 11 12 13 14 15",
+		"16 17 18 19 20 21 22",
+		"23 24 25 26 27 28",
+	}, lines)
+	assert.Equal(t, 1, d.dayAt(18, 0))
+	assert.Equal(t, 0, d.dayAt(0, 0))
+	assert.Equal(t, 0, d.dayAt(2, 1))
+	assert.Equal(t, 10, d.dayAt(3, 2))
+	assert.Equal(t, 0, d.dayAt(18, 4))
+
+	d.SetText("bad")
+	assert.Equal(t, "[red]expected eg. "+time.Now().Format(common.DateFormat)+" or +3m[-]", d.calendar()[0])
+}
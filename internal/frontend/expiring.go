@@ -0,0 +1,143 @@
+package frontend
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+	"github.com/vendelin8/tview"
+)
+
+func (f *Frontend) initExpiring() {
+	f.SetOnShow(lang.PageExpiring, func() {})
+
+	f.expiringTbl = tview.NewTable().SetSelectable(true, false).SetFixed(1, 0)
+	f.expiringTbl.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		i := int(event.Rune() - '1')
+		if event.Key() != tcell.KeyRune || i < 0 || i >= len(common.TimedButtons) {
+			return event
+		}
+
+		row, _ := f.expiringTbl.GetSelection()
+		if tc, ok := f.expiringTbl.GetCell(row, 0).GetReference().(util.TimedClaim); ok {
+			window.ShowErrorBuffer(extendClaim(f.s, tc, common.TimedButtons[i][0], time.Now()))
+			f.LayoutExpiring()
+			f.expiringTbl.Select(row, 0)
+		}
+		return nil
+	})
+
+	f.expiringPage = tview.NewFlex().SetDirection(tview.FlexRow).AddItem(f.expiringTbl, 0, 1, true).
+		AddItem(tview.NewTextView().SetText(extendHint()), 1, 0, false)
+}
+
+// LayoutExpiring updates the timed claims of the privileged users, grouped by how soon they expire.
+// Pending changes are highlighted.
+func (f *Frontend) LayoutExpiring() {
+	f.expiringTbl.Clear()
+	for col, text := range []string{lang.SExpires, lang.SLeft, lang.SEmail, lang.SName, lang.SPermission} {
+		f.expiringTbl.SetCell(0, col, tview.NewTableCell(text).SetAttributes(tcell.AttrBold).
+			SetSelectable(false).SetExpansion(col/2))
+	}
+
+	tcs := util.TimedClaims(f.s)
+	if len(tcs) == 0 {
+		f.expiringTbl.SetCell(1, 0, tview.NewTableCell(lang.SNoExpiring).SetSelectable(false))
+		return
+	}
+
+	now, group, row := time.Now(), -1, 1
+	for _, tc := range tcs {
+		if g := util.ExpiryGroup(tc.Date, now); g != group {
+			group = g
+			f.expiringTbl.SetCell(row, 0, tview.NewTableCell(groupTitle(g)).SetTextColor(groupColor(g)).
+				SetAttributes(tcell.AttrBold).SetSelectable(false))
+			row++
+		}
+
+		color := tview.Styles.PrimaryTextColor
+		if _, ok := f.s.Actions[tc.UID][tc.Perm]; ok {
+			color = common.CurrentTheme.Positive
+		}
+
+		c := common.Claim{Date: &tc.Date}
+		texts := []string{c.FormatDate(), c.Remaining(now), tc.Email, tc.Name, common.PermsMap[tc.Perm]}
+		for col, text := range texts {
+			cell := tview.NewTableCell(text).SetTextColor(color)
+			if col == 0 {
+				cell.SetReference(tc)
+			}
+			f.expiringTbl.SetCell(row, col, cell)
+		}
+		row++
+	}
+}
+
+// extendClaim stages a timed claim extended by the duration of a TimedButtons label, see extendedDate.
+func extendClaim(s *global.Session, tc util.TimedClaim, label string, now time.Time) error {
+	if conf.ReadOnly {
+		return firebase.ErrReadOnly
+	}
+	if !conf.CanGrant(tc.Perm) {
+		return fmt.Errorf(lang.ErrForbidden, conf.Operator, firebase.ErrForbidden, common.PermsMap[tc.Perm])
+	}
+
+	date := extendedDate(tc.Date, label, now)
+	util.StageClaim(s, tc.UID, tc.Perm, common.Claim{Date: &date})
+	return nil
+}
+
+// extendedDate returns the expiry extended by the duration of a TimedButtons label. Expired ones and
+// durations with hours are extended from now.
+func extendedDate(date time.Time, label string, now time.Time) time.Time {
+	if util.HasHours(label) || date.Before(now) {
+		date = now
+	}
+
+	return util.AddTimedDate(date, label)
+}
+
+// extendHint returns the keys extending the selected claim by the TimedButtons.
+func extendHint() string {
+	var b strings.Builder
+	b.WriteString(lang.SExtendHint)
+	for i, items := range common.TimedButtons {
+		fmt.Fprintf(&b, "  %d: %s", i+1, items[0])
+	}
+
+	return b.String()
+}
+
+// groupTitle returns the title of a group of timed claims, see util.ExpiryGroup.
+func groupTitle(group int) string {
+	switch group {
+	case util.GroupExpired:
+		return lang.SGroupExpired
+	case util.GroupWeek:
+		return lang.SGroupWeek
+	case util.GroupMonth:
+		return lang.SGroupMonth
+	default:
+		return lang.SGroupLater
+	}
+}
+
+// groupColor returns the color of the title of a group of timed claims.
+func groupColor(group int) tcell.Color {
+	switch group {
+	case util.GroupExpired:
+		return common.CurrentTheme.Negative
+	case util.GroupWeek:
+		return common.CurrentTheme.Warning
+	default:
+		return tview.Styles.SecondaryTextColor
+	}
+}
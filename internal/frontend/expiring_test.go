@@ -0,0 +1,93 @@
+package frontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/util"
+)
+
+func TestExtendClaim(t *testing.T) {
+	defer func(btns [][]string) {
+		common.TimedButtons = btns
+		_ = util.InitializeTimedButtonsMap()
+	}(common.TimedButtons)
+	common.TimedButtons = [][]string{{"One month", "1m"}, {"Twelve hours", "12h"}}
+	assert.NoError(t, util.InitializeTimedButtonsMap())
+	defer func() { conf.ReadOnly, conf.Grantable = false, nil }()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	saved := time.Date(2026, 3, 15, 23, 59, 59, 0, time.UTC)
+	expired := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		label     string
+		readOnly  bool
+		grantable []string
+		want      time.Time
+		wantErr   error
+	}{
+		{
+			name:  "from the expiry",
+			date:  saved,
+			label: "One month",
+			want:  time.Date(2026, 4, 15, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:  "expired from now",
+			date:  expired,
+			label: "One month",
+			want:  time.Date(2026, 4, 10, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:  "hours from now",
+			date:  saved,
+			label: "Twelve hours",
+			want:  time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "read-only",
+			date:     saved,
+			label:    "One month",
+			readOnly: true,
+			wantErr:  firebase.ErrReadOnly,
+		},
+		{
+			name:      "forbidden",
+			date:      saved,
+			label:     "One month",
+			grantable: []string{common.Admin},
+			wantErr:   firebase.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf.ReadOnly, conf.Grantable = tt.readOnly, nil
+			if tt.grantable != nil {
+				conf.SetGrantable(tt.grantable)
+			}
+			s := global.NewSession()
+			s.LocalUsers = map[string]*global.User{
+				"uid1": {UID: "uid1", Claims: common.ClaimsMap{common.Consultant: {Date: &tt.date}}},
+			}
+			tc := util.TimedClaim{UID: "uid1", Perm: common.Consultant, Date: tt.date}
+
+			err := extendClaim(s, tc, tt.label, now)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Empty(t, s.Actions)
+				return
+			}
+			assert.Equal(t, tt.want, *s.Actions["uid1"][common.Consultant].Date)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package frontend
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+	"github.com/vendelin8/tview"
+)
+
+var ErrNoTimed = lang.NewError(&lang.ErrNoTimedS)
+
+// ToggleSelected selects the focused row of the users table, or deselects it if already selected.
+func ToggleSelected(s *global.Session) error {
+	if s.FocusedRow < 0 || s.FocusedRow >= len(s.CrntUsers) {
+		return ErrNoRow
+	}
+
+	uid := s.CrntUsers[s.FocusedRow]
+	if _, ok := s.Selected[uid]; ok {
+		delete(s.Selected, uid)
+	} else {
+		s.Selected[uid] = struct{}{}
+	}
+
+	common.Fe.ReplaceUserNames(s.FocusedRow)
+	return nil
+}
+
+// ShowExtend pops up the chooser extending the timed claims of the selected users in the users
+// table, or of the focused one if none of them is shown.
+func ShowExtend(s *global.Session) error {
+	rows := targetRows(s)
+	if len(rows) == 0 {
+		return ErrNoRow
+	}
+	if len(timedPerms(s, rows)) == 0 {
+		return ErrNoTimed
+	}
+
+	window.PushPopup(lang.PopupExtend)
+	common.Fe.ShowExtendChoser(rows)
+
+	return nil
+}
+
+// ShowExtendChoser asks which timed permissions of the users in the given rows to extend: all of
+// them or a chosen one, then the TimedButtons duration to extend them by.
+func (f *Frontend) ShowExtendChoser(rows []int) {
+	who := f.s.LocalUsers[f.s.CrntUsers[rows[0]]].Email
+	if len(rows) > 1 {
+		who = fmt.Sprintf(lang.SUsersN, len(rows))
+	}
+
+	perms := timedPerms(f.s, rows)
+	labels := make([]string, len(perms)+1)
+	labels[0] = lang.SAllTimed
+	for i, perm := range perms {
+		labels[i+1] = common.PermsMap[perm]
+	}
+
+	m := tview.NewModal().SetText(fmt.Sprintf(lang.SExtendWhich, who)).AddButtons(append(labels, lang.SCancel)).
+		SetDoneFunc(func(index int, _ string) {
+			switch {
+			case index < 0 || index >= len(labels):
+				window.HidePopup(lang.PopupExtend)
+			case index == 0:
+				f.showExtendBy(rows, "", fmt.Sprintf(lang.SExtendAllBy, who))
+			default:
+				f.showExtendBy(rows, perms[index-1], fmt.Sprintf(lang.SExtendBy, labels[index], who))
+			}
+		})
+	f.pages.AddPage(lang.PopupExtend, m, true, true)
+}
+
+// showExtendBy replaces the extend popup with the TimedButtons durations to extend the claims by.
+func (f *Frontend) showExtendBy(rows []int, perm, text string) {
+	labels := make([]string, len(common.TimedButtons))
+	for i, items := range common.TimedButtons {
+		labels[i] = items[0]
+	}
+
+	m := tview.NewModal().SetText(text).AddButtons(append(labels, lang.SCancel)).
+		SetDoneFunc(func(index int, buttonLabel string) {
+			window.HidePopup(lang.PopupExtend)
+			if index >= 0 && index < len(labels) {
+				window.ShowErrorBuffer(extendRows(f.s, rows, perm, buttonLabel, time.Now()))
+			}
+		})
+	f.pages.AddPage(lang.PopupExtend, m, true, true)
+}
+
+// extendRows stages the timed claims of the users in the given rows extended by the duration of
+// a TimedButtons label, see extendedDate. All timed claims are extended if perm is empty, nothing
+// if any of them can't be changed by the operator. Broken permission rules are left in the error
+// buffer.
+func extendRows(s *global.Session, rows []int, perm, label string, now time.Time) error {
+	if conf.ReadOnly {
+		return firebase.ErrReadOnly
+	}
+
+	type extension struct {
+		i    int
+		perm string
+		date time.Time
+	}
+	var exts []extension
+	for _, i := range rows {
+		claims := util.FixedUserClaims(s, s.CrntUsers[i])
+		for _, p := range common.AllPerms {
+			c := claims[p]
+			if (len(perm) > 0 && p != perm) || c == nil || c.Date == nil {
+				continue
+			}
+			if !conf.CanGrant(p) {
+				return fmt.Errorf(lang.ErrForbidden, conf.Operator, firebase.ErrForbidden, common.PermsMap[p])
+			}
+
+			exts = append(exts, extension{i: i, perm: p, date: extendedDate(*c.Date, label, now)})
+		}
+	}
+	if len(exts) == 0 {
+		return ErrNoTimed
+	}
+
+	for _, e := range exts {
+		onActionChange(s, e.i, e.perm, common.Claim{Date: &e.date})
+	}
+
+	for _, i := range rows {
+		uid := s.CrntUsers[i]
+		window.WriteViolations(s.LocalUsers[uid].Email, s.Violations[uid])
+	}
+
+	return nil
+}
+
+// targetRows returns the rows of the selected users shown in the users table, or the focused row
+// if none of them is.
+func targetRows(s *global.Session) []int {
+	var rows []int
+	for i, uid := range s.CrntUsers {
+		if _, ok := s.Selected[uid]; ok {
+			rows = append(rows, i)
+		}
+	}
+
+	if len(rows) == 0 && s.FocusedRow >= 0 && s.FocusedRow < len(s.CrntUsers) {
+		rows = []int{s.FocusedRow}
+	}
+
+	return rows
+}
+
+// timedPerms returns the permissions with a timed claim of any user in the given rows.
+func timedPerms(s *global.Session, rows []int) []string {
+	var perms []string
+	for _, perm := range common.AllPerms {
+		for _, i := range rows {
+			if c := util.FixedUserClaims(s, s.CrntUsers[i])[perm]; c != nil && c.Date != nil {
+				perms = append(perms, perm)
+				break
+			}
+		}
+	}
+
+	return perms
+}
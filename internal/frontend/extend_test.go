@@ -0,0 +1,193 @@
+package frontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+	"github.com/vendelin8/firemage/internal/util"
+)
+
+func TestToggleSelected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	s := global.NewSession()
+	s.CrntUsers = []string{"uid1", "uid2", "uid3"}
+
+	s.FocusedRow = -1
+	assert.Equal(t, ErrNoRow, ToggleSelected(s))
+	assert.Nil(t, targetRows(s))
+
+	s.FocusedRow = 2
+	assert.Equal(t, []int{2}, targetRows(s))
+
+	mockFe.EXPECT().ReplaceUserNames(2).Times(2)
+	mockFe.EXPECT().ReplaceUserNames(0).Times(1)
+	assert.NoError(t, ToggleSelected(s))
+	s.FocusedRow = 0
+	assert.NoError(t, ToggleSelected(s))
+	assert.Equal(t, []int{0, 2}, targetRows(s))
+
+	s.FocusedRow = 2
+	assert.NoError(t, ToggleSelected(s))
+	s.FocusedRow = 1
+	assert.Equal(t, []int{0}, targetRows(s))
+
+	s.CrntUsers = []string{"uid2"} // the selected user is not shown
+	s.FocusedRow = 0
+	assert.Equal(t, []int{0}, targetRows(s))
+}
+
+func TestShowExtend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	date := time.Date(2026, 3, 15, 23, 59, 59, 0, time.UTC)
+	s := global.NewSession()
+	s.CrntUsers = []string{"uid1", "uid2"}
+	s.LocalUsers = map[string]*global.User{
+		"uid1": {UID: "uid1", Claims: common.ClaimsMap{common.Admin: {Checked: true}}},
+		"uid2": {UID: "uid2", Claims: common.ClaimsMap{common.Admin: {Checked: true}, common.Consultant: {Date: &date}}},
+	}
+
+	s.FocusedRow = -1
+	assert.Equal(t, ErrNoRow, ShowExtend(s))
+
+	s.FocusedRow = 0
+	assert.Equal(t, ErrNoTimed, ShowExtend(s))
+
+	s.FocusedRow = 1
+	mockFe.EXPECT().ShowExtendChoser([]int{1}).Times(1)
+	assert.NoError(t, ShowExtend(s))
+	assert.Contains(t, window.Popups(), lang.PopupExtend)
+	assert.Equal(t, []string{common.Consultant}, timedPerms(s, []int{0, 1}))
+
+	window.SetPopups()
+}
+
+func TestExtendRows(t *testing.T) {
+	defer func(btns [][]string) {
+		common.TimedButtons = btns
+		_ = util.InitializeTimedButtonsMap()
+	}(common.TimedButtons)
+	common.TimedButtons = [][]string{{"One month", "1m"}}
+	assert.NoError(t, util.InitializeTimedButtonsMap())
+	defer func() { conf.ReadOnly, conf.Grantable = false, nil }()
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	d1 := time.Date(2026, 3, 15, 23, 59, 59, 0, time.UTC)
+	d2 := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC)
+	staged := time.Date(2026, 5, 1, 23, 59, 59, 0, time.UTC)
+	ext1 := time.Date(2026, 4, 15, 23, 59, 59, 0, time.UTC)
+	ext2 := time.Date(2026, 4, 10, 23, 59, 59, 0, time.UTC) // expired, from now
+	extStaged := time.Date(2026, 6, 1, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		rows        []int
+		perm        string
+		readOnly    bool
+		grantable   []string
+		wantActions map[string]common.ClaimsMap
+		wantErr     error
+	}{
+		{
+			name: "all timed claims of the selected users",
+			rows: []int{0, 1},
+			wantActions: map[string]common.ClaimsMap{
+				"uid1": {common.Consultant: {Date: &ext1}, common.Admin: {Date: &extStaged}},
+				"uid2": {common.Consultant: {Date: &ext2}},
+			},
+		},
+		{
+			name: "one permission across the selected users",
+			rows: []int{0, 1},
+			perm: common.Consultant,
+			wantActions: map[string]common.ClaimsMap{
+				"uid1": {common.Consultant: {Date: &ext1}, common.Admin: {Date: &staged}},
+				"uid2": {common.Consultant: {Date: &ext2}},
+			},
+		},
+		{
+			name:    "no timed claims",
+			rows:    []int{2},
+			wantErr: ErrNoTimed,
+		},
+		{
+			name:     "read-only",
+			rows:     []int{0},
+			readOnly: true,
+			wantErr:  firebase.ErrReadOnly,
+		},
+		{
+			name:      "forbidden stages nothing",
+			rows:      []int{1, 0},
+			grantable: []string{common.Consultant},
+			wantErr:   firebase.ErrForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFe.EXPECT().ReplaceTableItem(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			conf.ReadOnly, conf.Grantable = tt.readOnly, nil
+			if tt.grantable != nil {
+				conf.SetGrantable(tt.grantable)
+			}
+
+			s := global.NewSession()
+			s.CrntUsers = []string{"uid1", "uid2", "uid3"}
+			s.LocalUsers = map[string]*global.User{
+				"uid1": {UID: "uid1", Email: "user1@example.com", Claims: common.ClaimsMap{
+					common.Consultant: {Date: &d1},
+				}},
+				"uid2": {UID: "uid2", Email: "user2@example.com", Claims: common.ClaimsMap{
+					common.Consultant: {Date: &d2},
+				}},
+				"uid3": {UID: "uid3", Email: "user3@example.com", Claims: common.ClaimsMap{
+					common.Admin: {Checked: true},
+				}},
+			}
+			s.Actions = map[string]common.ClaimsMap{"uid1": {common.Admin: {Date: &staged}}}
+
+			err := extendRows(s, tt.rows, tt.perm, "One month", now)
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Equal(t, map[string]common.ClaimsMap{"uid1": {common.Admin: {Date: &staged}}}, s.Actions)
+				return
+			}
+
+			assert.Len(t, s.Actions, len(tt.wantActions))
+			for uid, claims := range tt.wantActions {
+				assert.Len(t, s.Actions[uid], len(claims), uid)
+				for key, want := range claims {
+					assert.Equal(t, *want.Date, *s.Actions[uid][key].Date, uid+" "+key)
+				}
+			}
+			assert.Empty(t, window.GetErrorStr())
+		})
+	}
+}
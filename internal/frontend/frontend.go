@@ -9,6 +9,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/firebase"
 	"github.com/vendelin8/firemage/internal/frontend/window"
 	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/lang"
@@ -29,7 +30,8 @@ const (
 type ClaimsModal struct {
 	*tview.FormModal
 	radio *tview.Radio
-	date  *tview.InputField
+	date  *DatePicker
+	s     *global.Session
 	i     int
 	key   string
 }
@@ -41,14 +43,28 @@ type Frontend struct {
 	progress *tview.Modal
 	pages    *tview.Pages
 	menu     *tview.TextView
-	filler   *tview.Box
 	app      *tview.Application
+	s        *global.Session
 	header   *tview.TextView
+	lastErr  string
 	userHdrs []string
-	userTbl  *tview.Grid
+	userTbl  *usersTable
+	// userCells are the permission cells of the users table by row and AllPerms index, nil for the
+	// rows not shown.
+	userCells [][]tview.Primitive
 
-	listPage   *tview.Flex
-	searchPage *tview.Flex
+	// progressCtx and progressCancel are of the work shown by the progress dialog, nil when it's done.
+	progressCtx    context.Context
+	progressCancel context.CancelFunc
+
+	listPage      *tview.Flex
+	searchPage    *tview.Flex
+	approvalsPage *tview.Flex
+	expiringPage  *tview.Flex
+
+	pendingList *tview.List
+	pendingDiff *tview.TextView
+	expiringTbl *tview.Table
 
 	searchField *tview.InputField
 	searchRadio *tview.Radio
@@ -70,12 +86,21 @@ func (f *Frontend) Quit() {
 	f.app.Stop()
 }
 
+// QueueUpdateDraw runs fn on the GUI goroutine, and redraws the screen. Use it for updates coming
+// from other goroutines.
+func (f *Frontend) QueueUpdateDraw(fn func()) {
+	f.app.QueueUpdateDraw(fn)
+}
+
 func (f *Frontend) SetOnShow(page string, cb func()) {
 	f.onShowPage[page] = cb
 }
 
-func CreateGUI() *Frontend {
-	f := &Frontend{}
+// CreateGUI creates the GUI for the given session. Background goroutines of the session are synced
+// to the GUI goroutine.
+func CreateGUI(s *global.Session) *Frontend {
+	tview.Styles = common.CurrentTheme.Base
+	f := &Frontend{s: s}
 	f.searchRadio = tview.NewRadio(lang.SEmail, lang.SName).SetOnSetValue(func(radioValue int) {
 		if radioValue == 0 {
 			f.searchForEmail()
@@ -84,49 +109,73 @@ func CreateGUI() *Frontend {
 		}
 	}).SetLabel(lang.SSearchThis).SetHorizontal(true)
 	f.onShowPage = map[string]func(){}
-	f.filler = tview.NewBox()
 	f.app = tview.NewApplication()
-	f.header = newText("")
+	s.SetSync(func(fn func()) {
+		done := make(chan struct{})
+		f.app.QueueUpdate(func() {
+			fn()
+			close(done)
+		})
+		<-done
+	})
+	f.header = newText("").SetDynamicColors(true)
 	f.searchField = tview.NewInputField().SetFieldWidth(40)
 	f.searchFieldName = map[int]string{0: "email", 1: "name"}
-	f.userTbl = tview.NewGrid()
+	f.userTbl = newUsersTable()
 	f.menu = tview.NewTextView().SetDynamicColors(true).SetRegions(true).SetWrap(false)
 	f.initUsersList()
 	return f
 }
 
 func (f *Frontend) formatMenuItem(w io.Writer, menuKey, text, shortcut string, isPositive bool) {
-	var color string
+	var color tcell.Color
 	switch {
 	case len(menuKey) > 0:
-		color = "yellow"
+		color = common.CurrentTheme.Accent
 	case isPositive:
-		color = "green"
+		color = common.CurrentTheme.Positive
 	default:
-		color = "red"
+		color = common.CurrentTheme.Negative
 	}
-	fmt.Fprintf(w, ` %s ["%s"][%s::b]%s[white::-][""]  `, shortcut, menuKey, color, text)
+	fmt.Fprintf(w, ` %s ["%s"][%s::b]%s[-::-][""]  `, shortcut, menuKey, common.ColorTag(color), text)
 }
 
-func (f *Frontend) Run() {
+// Run initializes the configuration and the pages, and runs the app until it quits.
+func (f *Frontend) Run() error {
 	err := conf.InitConf(func(menuKey, text, shortcut string, isPositive bool) {
 		f.formatMenuItem(f.menu, menuKey, text, shortcut, isPositive)
 	})
-	log.Must("init configuration", err)
+	if err != nil {
+		return common.Classify(common.ExitConfig, err)
+	}
+
+	if err = firebase.ResolveOperator(); err != nil {
+		return err
+	}
 
 	f.initSearch()
 	f.initList()
+	f.initApprovals()
+	f.initExpiring()
 	f.pages = tview.NewPages().AddPage(lang.PageSearch, f.searchPage, true, false).
-		AddPage(lang.PageList, f.listPage, true, false)
+		AddPage(lang.PageList, f.listPage, true, false).
+		AddPage(lang.PageApprovals, f.approvalsPage, true, false).
+		AddPage(lang.PageExpiring, f.expiringPage, true, false)
 	layout := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(f.header, 1, 0, false).
 		AddItem(f.pages, 0, 1, true).AddItem(f.menu, 1, 0, false)
-	f.app.SetInputCapture(CmdByKey)
+	f.app.SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		if typing(ev, f.app.GetFocus()) {
+			return ev
+		}
+		return CmdByKey(ev)
+	})
 	f.app.SetRoot(layout, true).EnableMouse(true)
 
-	err = ShowPage(lang.PageSearch)
-	log.Must("showing initial page", err)
+	if err = ShowPage(f.s, lang.PageSearch); err != nil {
+		return err
+	}
 
-	log.Must("run app", f.app.Run())
+	return f.app.Run()
 }
 
 func extractMsg(ms ...string) string {
@@ -138,7 +187,7 @@ func extractMsg(ms ...string) string {
 
 // ShowMsg shows the given message as a popup.
 func (f *Frontend) ShowMsg(ms ...string) {
-	window.ActivePopups = append(window.ActivePopups, lang.PopupMsg)
+	window.PushPopup(lang.PopupMsg)
 	m := extractMsg(ms...)
 	if f.msg != nil {
 		f.pages.ShowPage(lang.PopupMsg)
@@ -171,9 +220,9 @@ func (f *Frontend) ClaimButtonSetDisabled(index int, isDisabled bool) {
 	f.claims.GetButton(index).SetDisabled(isDisabled)
 }
 
-func claimsFieldSetChangedFunc(text string) {
-	_, err := time.Parse(common.DateFormat, text)
-	isDisabled := err != nil
+// claimsDateChanged disables the buttons of the claim chooser while the date is invalid.
+func claimsDateChanged(date *time.Time) {
+	isDisabled := date == nil
 	common.Fe.ClaimsBtns(isDisabled)
 	common.Fe.ClaimButtonSetDisabled(len(common.TimedButtons), isDisabled)
 }
@@ -189,22 +238,11 @@ func (f *Frontend) ClaimsDateSetDisabled(isDisabled bool) {
 }
 
 func (f *Frontend) ClaimsSetDate(value time.Time) {
-	f.claims.date.SetText(value.Format(common.DateFormat))
+	f.claims.date.SetDate(value)
 }
 
 func (f *Frontend) ClaimsDate() *time.Time {
-	dateStr := f.claims.date.GetText()
-	if len(dateStr) == 0 {
-		return nil
-	}
-
-	d, err := time.Parse(common.DateFormat, dateStr)
-	if err != nil {
-		log.Lgr.Error("claim date parse", zap.Error(err))
-		return nil
-	}
-
-	return &d
+	return f.claims.date.Date()
 }
 
 const (
@@ -225,7 +263,7 @@ func claimsRadioSetOnSetValue(radioValue int) {
 		common.Fe.ClaimsDateSetDisabled(false)
 		common.Fe.ClaimsBtns(false)
 		if common.Fe.ClaimsDate() == nil {
-			common.Fe.ClaimsSetDate(time.Now())
+			common.Fe.ClaimsSetDate(common.EndOfToday())
 		}
 	}
 }
@@ -233,11 +271,12 @@ func claimsRadioSetOnSetValue(radioValue int) {
 func (c *ClaimsModal) handleOK() {
 	c.processClaimResult()
 	window.HidePopup(lang.PopupClaim)
+	showViolations(c.s, c.i)
 }
 
 func (c *ClaimsModal) resetToOriginal() {
-	uid := global.CrntUsers[c.i]
-	savedClaim := global.LocalUsers[uid].Claims[c.key]
+	uid := c.s.CrntUsers[c.i]
+	savedClaim := c.s.LocalUsers[uid].Claims[c.key]
 
 	if savedClaim == nil {
 		log.Lgr.Warn("no claim found for user", zap.String("uid", uid), zap.String("key", c.key))
@@ -245,48 +284,45 @@ func (c *ClaimsModal) resetToOriginal() {
 	}
 
 	var claimType int
-	var dateStr string
+	date := common.EndOfToday()
 
 	if savedClaim.Date != nil {
 		claimType = ClaimTimed
-		dateStr = savedClaim.Date.Format(common.DateFormat)
+		date = *savedClaim.Date
 	} else if savedClaim.Checked {
 		claimType = ClaimActive
-		dateStr = time.Now().Format(common.DateFormat)
 	} else {
 		claimType = ClaimInactive
-		dateStr = time.Now().Format(common.DateFormat)
 	}
 
-	c.date.SetText(dateStr)
+	c.date.SetDate(date)
 	c.radio.SetValue(claimType)
-	log.Lgr.Debug("resetToOriginal", zap.String("uid", uid), zap.String("key", c.key), zap.Int("claimType", claimType), zap.String("dateStr", dateStr), zap.Any("savedClaim", savedClaim))
+	log.Lgr.Debug("resetToOriginal", zap.String("uid", uid), zap.String("key", c.key), zap.Int("claimType", claimType), zap.Time("date", date), zap.Any("savedClaim", savedClaim))
 }
 
 func (c *ClaimsModal) processClaimResult() {
 	log.Lgr.Debug("processClaimResult", zap.Int("radio", c.radio.Value()))
 	switch c.radio.Value() {
 	case claimActive:
-		onActionChange(c.i, c.key, common.Claim{Checked: true})
+		onActionChange(c.s, c.i, c.key, common.Claim{Checked: true})
 	case claimInactive:
-		onActionChange(c.i, c.key, common.Claim{})
+		onActionChange(c.s, c.i, c.key, common.Claim{})
 	case claimTimed:
-		d, err := time.Parse(common.DateFormat, c.date.GetText())
-		if err != nil {
-			log.Lgr.Error("claim date parse", zap.Error(err))
-			onActionChange(c.i, c.key, common.Claim{})
+		d := c.date.Date()
+		if d == nil {
+			log.Lgr.Error("claim date invalid", zap.String("date", c.date.GetText()))
+			onActionChange(c.s, c.i, c.key, common.Claim{})
 			return
 		}
-		onActionChange(c.i, c.key, common.Claim{Date: &d})
+		onActionChange(c.s, c.i, c.key, common.Claim{Date: d})
 	}
 }
 
 func (f *Frontend) CreateClaimChoser() {
-	width, height := 50, 10
-	// TODO: replace with DatePicker (struct to be created)
-	dateF := tview.NewInputField().SetChangedFunc(claimsFieldSetChangedFunc)
+	width, height := 50, 10+calendarRows
+	dateF := NewDatePicker().SetChangedFunc(claimsDateChanged)
 	radio := tview.NewRadio(lang.SActive, lang.SInactive, lang.STimed).SetHorizontal(true).SetOnSetValue(claimsRadioSetOnSetValue)
-	c := &ClaimsModal{radio: radio, date: dateF}
+	c := &ClaimsModal{s: f.s, radio: radio, date: dateF}
 	c.FormModal = tview.NewFormModal(func(form *tview.Form) {
 		form.AddFormItem(radio)
 		form.AddFormItem(dateF)
@@ -312,7 +348,7 @@ func (f *Frontend) CreateClaimChoser() {
 
 func (c *ClaimsModal) incDate(buttonLabel string) {
 	d := common.Fe.ClaimsDate()
-	if d == nil {
+	if d == nil || util.HasHours(buttonLabel) {
 		now := time.Now()
 		d = &now
 	}
@@ -324,17 +360,9 @@ func (c *ClaimsModal) incDate(buttonLabel string) {
 
 // ShowClaimChoser shows a claim chooser dialog with options for active, inactive, or timed claims.
 func (f *Frontend) ShowClaimChoser(i int, key string, c common.Claim) {
-	var (
-		claimType int
-		dateStr   string
-	)
-
+	claimType, date := ClaimInactive, common.EndOfToday()
 	if c.Date != nil {
-		claimType = ClaimTimed
-		dateStr = c.Date.Format(common.DateFormat)
-	} else {
-		dateStr = time.Now().Format(common.DateFormat)
-		claimType = ClaimInactive
+		claimType, date = ClaimTimed, *c.Date
 	}
 
 	creating := f.claims == nil
@@ -343,7 +371,7 @@ func (f *Frontend) ShowClaimChoser(i int, key string, c common.Claim) {
 	}
 
 	claims := f.claims
-	claims.date.SetText(dateStr)
+	claims.date.SetDate(date)
 	// f.app.SetFocus(claims.SetFocus(0))
 	radio := f.claims.radio
 	radio.SetValue(claimType)
@@ -358,9 +386,15 @@ func (f *Frontend) ShowClaimChoser(i int, key string, c common.Claim) {
 
 // ShowConfirm shows a confirm dialog with a text, and callback functions for OK and Cancel.
 func (f *Frontend) ShowProgress(ctx context.Context, cancelF context.CancelFunc, ms ...string) {
+	f.progressCtx, f.progressCancel = ctx, cancelF
 	go func() {
 		<-ctx.Done()
-		window.HidePopup(lang.PopupProgress)
+		f.app.QueueUpdateDraw(func() {
+			if f.progressCtx == ctx { // not replaced by the next work yet
+				f.progressCtx, f.progressCancel = nil, nil
+			}
+			window.HidePopup(lang.PopupProgress)
+		})
 	}()
 
 	var m string
@@ -390,6 +424,20 @@ func (f *Frontend) ShowProgress(ctx context.Context, cancelF context.CancelFunc,
 	f.app.ForceDraw()
 }
 
+// CancelProgress cancels the work shown by the progress dialog, if it's not done yet.
+func (f *Frontend) CancelProgress() {
+	if f.progressCancel != nil {
+		f.progressCancel()
+	}
+}
+
+// SetProgress updates the message of the progress dialog.
+func (f *Frontend) SetProgress(m string) {
+	if f.progress != nil {
+		f.progress.SetText(m)
+	}
+}
+
 // HidePopup hides the current popup window.
 func (f *Frontend) HidePopup(popup string) {
 	log.Lgr.Debug("Frontend HidePopup", zap.String("popup", popup))
@@ -399,32 +447,105 @@ func (f *Frontend) HidePopup(popup string) {
 func (f *Frontend) SetPage(newPage string) {
 	f.menu.Highlight(newPage).ScrollToHighlight()
 	f.pages.SwitchToPage(newPage)
-	f.header.SetText(fmt.Sprintf("%s - %s", lang.ShortDesc, lang.Titles[newPage]))
+	f.UpdateHeader()
 }
 
-// CmdByKey calls the adequate api function through a keyboard shortcut.
-func CmdByKey(ev *tcell.EventKey) *tcell.EventKey {
-	cmd, ok := common.Shortcuts[ev.Key()]
-	if !ok {
-		return ev
+// SetLastError shows the given error of a Firebase call in the header.
+func (f *Frontend) SetLastError(m string) {
+	f.lastErr = m
+	f.UpdateHeader()
+}
+
+// UpdateHeader shows the title of the current page, the mode, and the last error if any.
+func (f *Frontend) UpdateHeader() {
+	title := fmt.Sprintf("%s - %s", lang.ShortDesc, lang.Titles[f.CurrentPage()])
+	if conf.ReadOnly {
+		title += "  " + badge(lang.SReadOnlyBadge, common.CurrentTheme.Info)
+	}
+	if firebase.IsDryRun() {
+		title += "  " + badge(lang.SDryRunBadge, common.CurrentTheme.Warning)
+	}
+	if len(f.lastErr) > 0 {
+		title += fmt.Sprintf("  [%s]%s: %s[-]", common.ColorTag(common.CurrentTheme.Negative), lang.SLastError,
+			tview.Escape(f.lastErr))
+	}
+	f.header.SetText(title)
+}
+
+// badge returns the given text highlighted with the given background color in the header, reversed
+// if that's the terminal's own.
+func badge(text string, bg tcell.Color) string {
+	attrs := "b"
+	if bg == tcell.ColorDefault {
+		attrs += "r"
+	}
+
+	return fmt.Sprintf("[%s:%s:%s] %s [-:-:-]", common.ColorTag(common.CurrentTheme.BadgeText), common.ColorTag(bg),
+		attrs, text)
+}
+
+// typing checks if the key is a character typed into the focused input field. Shortcuts without
+// modifiers aren't run then, so they can be typed.
+func typing(ev *tcell.EventKey, focus tview.Primitive) bool {
+	if ev.Key() != tcell.KeyRune || ev.Modifiers()&^tcell.ModShift != 0 {
+		return false
+	}
+
+	switch focus.(type) {
+	case *tview.InputField, *DatePicker:
+		return true
 	}
+	return false
+}
+
+// CmdByKey calls the adequate api function through a keyboard shortcut. Shortcuts of the popup on the
+// top or the current page take precedence. While a popup is shown, only its own shortcuts and quit
+// work, the latter hiding it, or cancelling the work of the progress dialog.
+func CmdByKey(ev *tcell.EventKey) *tcell.EventKey {
+	key := common.HotkeyOf(ev)
 
 	if !window.HasPopup() {
-		menuItem, exists := common.MenuItems[cmd]
-		if !exists {
-			return nil
+		if len(common.ScopedShortcuts) > 0 {
+			if cmd, ok := common.ScopedShortcuts[common.Fe.CurrentPage()][key]; ok {
+				return runCmd(cmd)
+			}
 		}
 
-		window.ShowErrorBuffer(menuItem.Function())
+		cmd, ok := common.Shortcuts[key]
+		if !ok {
+			return ev
+		}
 
-		return nil
+		return runCmd(cmd)
 	}
 
-	if cmd != conf.CmdQuit {
+	popup := window.TopPopup()
+	if cmd, ok := common.ScopedShortcuts[popup][key]; ok {
+		return runCmd(cmd)
+	}
+
+	if cmd, ok := common.Shortcuts[key]; !ok || cmd != conf.CmdQuit {
 		return ev
 	}
 
-	window.HidePopup(window.ActivePopups[len(window.ActivePopups)-1]) // hide popup by esc
+	if popup == lang.PopupProgress {
+		common.Fe.CancelProgress() // hidden when the work stops
+		return nil
+	}
+
+	window.HidePopup(popup) // hide popup by esc
+
+	return nil
+}
+
+// runCmd runs the given menu command or action, showing its error if any.
+func runCmd(cmd int) *tcell.EventKey {
+	menuItem, exists := common.MenuItems[cmd]
+	if !exists {
+		return nil
+	}
+
+	window.ShowErrorBuffer(menuItem.Function())
 
 	return nil
 }
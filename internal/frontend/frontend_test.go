@@ -1,6 +1,7 @@
 package frontend
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -213,9 +214,9 @@ func TestCmdByKey(t *testing.T) {
 	originalMenuItems := common.MenuItems
 
 	// Initialize shortcuts for testing
-	common.Shortcuts = map[tcell.Key]int{
-		tcell.KeyEsc: conf.CmdQuit,
-		tcell.KeyF5:  conf.CmdRefresh,
+	common.Shortcuts = map[common.Hotkey]int{
+		{Key: tcell.KeyEsc}: conf.CmdQuit,
+		{Key: tcell.KeyF5}:  conf.CmdRefresh,
 	}
 
 	// Initialize menu items for testing
@@ -272,12 +273,12 @@ func TestCmdByKey(t *testing.T) {
 			common.Fe = mockFe
 
 			if tt.hasPopup {
-				window.ActivePopups = append(window.ActivePopups, lang.PopupConfirm)
+				window.SetPopups(append(window.Popups(), lang.PopupConfirm)...)
 				if tt.key == tcell.KeyEsc {
 					mockFe.EXPECT().HidePopup(lang.PopupConfirm).Times(1)
 				}
 			} else {
-				window.ActivePopups = []string{}
+				window.SetPopups()
 			}
 
 			ev := tcell.NewEventKey(tt.key, 0, tcell.ModNone)
@@ -289,7 +290,7 @@ func TestCmdByKey(t *testing.T) {
 				assert.NotNil(t, result, tt.description)
 			}
 
-			window.ActivePopups = []string{}
+			window.SetPopups()
 		})
 	}
 
@@ -298,6 +299,53 @@ func TestCmdByKey(t *testing.T) {
 	common.MenuItems = originalMenuItems
 }
 
+func TestCmdByKeyCancelsProgress(t *testing.T) {
+	originalShortcuts := common.Shortcuts
+	defer func() {
+		common.Shortcuts = originalShortcuts
+		window.SetPopups()
+	}()
+	common.Shortcuts = map[common.Hotkey]int{{Key: tcell.KeyEsc}: conf.CmdQuit}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFe.EXPECT().ShowProgress(gomock.Any(), gomock.Any()).Times(1)
+	mockFe.EXPECT().CancelProgress().Times(1)
+
+	window.ShowProgress(context.Background(), func() {})
+
+	assert.Nil(t, CmdByKey(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone)))
+	assert.Equal(t, lang.PopupProgress, window.TopPopup(), "hidden when the work stops")
+}
+
+func TestTyping(t *testing.T) {
+	field, table := tview.NewInputField(), tview.NewTable()
+
+	tests := []struct {
+		name  string
+		ev    *tcell.EventKey
+		focus tview.Primitive
+		want  bool
+	}{
+		{"plain rune in an input field", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), field, true},
+		{"shifted rune in an input field", tcell.NewEventKey(tcell.KeyRune, 'X', tcell.ModShift), field, true},
+		{"plain rune in a date picker", tcell.NewEventKey(tcell.KeyRune, '/', tcell.ModNone), NewDatePicker(), true},
+		{"plain rune in the table", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), table, false},
+		{"rune with a modifier in an input field", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModAlt), field, false},
+		{"function key in an input field", tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone), field, false},
+		{"nothing focused", tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, typing(tt.ev, tt.focus))
+		})
+	}
+}
+
 func TestCmdByKeyWithError(t *testing.T) {
 	// Save original state
 	originalShortcuts := common.Shortcuts
@@ -305,12 +353,12 @@ func TestCmdByKeyWithError(t *testing.T) {
 	defer func() {
 		common.Shortcuts = originalShortcuts
 		common.MenuItems = originalMenuItems
-		window.ActivePopups = []string{}
+		window.SetPopups()
 	}()
 
 	// Initialize shortcuts for testing
-	common.Shortcuts = map[tcell.Key]int{
-		tcell.KeyF1: conf.CmdQuit,
+	common.Shortcuts = map[common.Hotkey]int{
+		{Key: tcell.KeyF1}: conf.CmdQuit,
 	}
 
 	// Initialize menu items that return an error
@@ -327,7 +375,7 @@ func TestCmdByKeyWithError(t *testing.T) {
 		mockFe := mock.NewMockFeIf(ctrl)
 		common.Fe = mockFe
 
-		window.ActivePopups = []string{}
+		window.SetPopups()
 		mockFe.EXPECT().ShowMsg(testutil.ErrMock.Error()).Times(1)
 
 		ev := tcell.NewEventKey(tcell.KeyF1, 0, tcell.ModNone)
@@ -377,13 +425,13 @@ func TestCmdByKeyWithMultipleShortcuts(t *testing.T) {
 	defer func() {
 		common.Shortcuts = originalShortcuts
 		common.MenuItems = originalMenuItems
-		window.ActivePopups = []string{}
+		window.SetPopups()
 	}()
 
-	common.Shortcuts = map[tcell.Key]int{
-		tcell.KeyEsc: conf.CmdQuit,
-		tcell.KeyF5:  conf.CmdRefresh,
-		tcell.KeyTab: 2,
+	common.Shortcuts = map[common.Hotkey]int{
+		{Key: tcell.KeyEsc}: conf.CmdQuit,
+		{Key: tcell.KeyF5}:  conf.CmdRefresh,
+		{Key: tcell.KeyTab}: 2,
 	}
 
 	common.MenuItems = map[int]common.MenuItem{
@@ -405,7 +453,7 @@ func TestCmdByKeyWithMultipleShortcuts(t *testing.T) {
 		mockFe := mock.NewMockFeIf(ctrl)
 		common.Fe = mockFe
 
-		window.ActivePopups = []string{}
+		window.SetPopups()
 
 		// Test F5 (refresh)
 		ev := tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone)
@@ -425,11 +473,11 @@ func TestCmdByKeyWithDifferentModifiers(t *testing.T) {
 	defer func() {
 		common.Shortcuts = originalShortcuts
 		common.MenuItems = originalMenuItems
-		window.ActivePopups = []string{}
+		window.SetPopups()
 	}()
 
-	common.Shortcuts = map[tcell.Key]int{
-		tcell.KeyCtrlC: 99,
+	common.Shortcuts = map[common.Hotkey]int{
+		{Key: tcell.KeyCtrlC}: 99,
 	}
 
 	common.MenuItems = map[int]common.MenuItem{
@@ -445,7 +493,7 @@ func TestCmdByKeyWithDifferentModifiers(t *testing.T) {
 		mockFe := mock.NewMockFeIf(ctrl)
 		common.Fe = mockFe
 
-		window.ActivePopups = []string{}
+		window.SetPopups()
 
 		// Test Ctrl+C
 		ev := tcell.NewEventKey(tcell.KeyCtrlC, 0, tcell.ModNone)
@@ -479,11 +527,11 @@ func TestCmdByKeyEdgeCases(t *testing.T) {
 	defer func() {
 		common.Shortcuts = originalShortcuts
 		common.MenuItems = originalMenuItems
-		window.ActivePopups = []string{}
+		window.SetPopups()
 	}()
 
 	t.Run("empty shortcuts map", func(t *testing.T) {
-		common.Shortcuts = map[tcell.Key]int{}
+		common.Shortcuts = map[common.Hotkey]int{}
 		common.MenuItems = map[int]common.MenuItem{}
 
 		ev := tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone)
@@ -498,13 +546,13 @@ func TestCmdByKeyEdgeCases(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
-		common.Shortcuts = map[tcell.Key]int{
-			tcell.KeyEsc: conf.CmdQuit,
+		common.Shortcuts = map[common.Hotkey]int{
+			{Key: tcell.KeyEsc}: conf.CmdQuit,
 		}
 		// Intentionally not adding the menu item
 		common.MenuItems = map[int]common.MenuItem{}
 
-		window.ActivePopups = []string{}
+		window.SetPopups()
 
 		ev := tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone)
 		result := CmdByKey(ev)
@@ -515,6 +563,74 @@ func TestCmdByKeyEdgeCases(t *testing.T) {
 	})
 }
 
+func TestCmdByKeyScoped(t *testing.T) {
+	originalShortcuts := common.Shortcuts
+	originalScoped := common.ScopedShortcuts
+	originalMenuItems := common.MenuItems
+	defer func() {
+		common.Shortcuts, common.ScopedShortcuts = originalShortcuts, originalScoped
+		common.MenuItems = originalMenuItems
+		window.SetPopups()
+	}()
+
+	var ran []int
+	common.MenuItems = map[int]common.MenuItem{}
+	for _, cmd := range []int{conf.CmdQuit, conf.CmdSave, conf.ActNextRow, conf.ActToggleClaim} {
+		common.MenuItems[cmd] = common.MenuItem{Function: func() error { ran = append(ran, cmd); return nil }}
+	}
+	j, _ := common.ParseHotkey("j")
+	ctrlS, _ := common.ParseHotkey("Ctrl-S")
+	common.Shortcuts = map[common.Hotkey]int{{Key: tcell.KeyEsc}: conf.CmdQuit, ctrlS: conf.CmdSave}
+	common.ScopedShortcuts = map[string]map[common.Hotkey]int{
+		lang.PageList:   {j: conf.ActNextRow, ctrlS: conf.ActToggleClaim},
+		lang.PopupClaim: {j: conf.ActToggleClaim},
+	}
+
+	tests := []struct {
+		name    string
+		page    string
+		popup   string
+		ev      *tcell.EventKey
+		wantRan []int
+		wantEv  bool
+	}{
+		{name: "rune key on its page", page: lang.PageList, ev: tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+			wantRan: []int{conf.ActNextRow}},
+		{name: "rune key on another page is typed", page: lang.PageSearch,
+			ev: tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone), wantEv: true},
+		{name: "page binding over global", page: lang.PageList,
+			ev: tcell.NewEventKey(tcell.KeyCtrlS, 's', tcell.ModCtrl), wantRan: []int{conf.ActToggleClaim}},
+		{name: "global binding elsewhere", page: lang.PageSearch,
+			ev: tcell.NewEventKey(tcell.KeyCtrlS, 's', tcell.ModCtrl), wantRan: []int{conf.CmdSave}},
+		{name: "popup binding", popup: lang.PopupClaim, ev: tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone),
+			wantRan: []int{conf.ActToggleClaim}},
+		{name: "global binding in popup is passed", popup: lang.PopupClaim,
+			ev: tcell.NewEventKey(tcell.KeyCtrlS, 's', tcell.ModCtrl), wantEv: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFe.EXPECT().CurrentPage().Return(tt.page).AnyTimes()
+
+			ran = nil
+			if len(tt.popup) > 0 {
+				window.SetPopups(tt.popup)
+			} else {
+				window.SetPopups()
+			}
+
+			result := CmdByKey(tt.ev)
+			assert.Equal(t, tt.wantEv, result != nil)
+			assert.Equal(t, tt.wantRan, ran)
+		})
+	}
+}
+
 // MockTextView is a simple mock for testing purposes
 type MockTextView struct {
 	highlights []string
@@ -545,7 +661,7 @@ func TestFormatMenuItem(t *testing.T) {
 			text:       "Manage Users",
 			shortcut:   "u",
 			isPositive: false,
-			expected:   ` u ["users"][yellow::b]Manage Users[white::-][""]  `,
+			expected:   ` u ["users"][yellow::b]Manage Users[-::-][""]  `,
 		},
 		{
 			name:       "green color when isPositive is true",
@@ -553,7 +669,7 @@ func TestFormatMenuItem(t *testing.T) {
 			text:       "Save",
 			shortcut:   "s",
 			isPositive: true,
-			expected:   ` s [""][green::b]Save[white::-][""]  `,
+			expected:   ` s [""][green::b]Save[-::-][""]  `,
 		},
 		{
 			name:       "red color when menuKey is empty and isPositive is false",
@@ -561,7 +677,7 @@ func TestFormatMenuItem(t *testing.T) {
 			text:       "Delete",
 			shortcut:   "d",
 			isPositive: false,
-			expected:   ` d [""][red::b]Delete[white::-][""]  `,
+			expected:   ` d [""][red::b]Delete[-::-][""]  `,
 		},
 		{
 			name:       "yellow takes precedence over isPositive",
@@ -569,7 +685,7 @@ func TestFormatMenuItem(t *testing.T) {
 			text:       "Settings",
 			shortcut:   "c",
 			isPositive: true,
-			expected:   ` c ["settings"][yellow::b]Settings[white::-][""]  `,
+			expected:   ` c ["settings"][yellow::b]Settings[-::-][""]  `,
 		},
 		{
 			name:       "handles special characters in text",
@@ -577,7 +693,7 @@ func TestFormatMenuItem(t *testing.T) {
 			text:       "Text with & symbols",
 			shortcut:   "x",
 			isPositive: false,
-			expected:   ` x ["special"][yellow::b]Text with & symbols[white::-][""]  `,
+			expected:   ` x ["special"][yellow::b]Text with & symbols[-::-][""]  `,
 		},
 	}
 
@@ -592,8 +708,9 @@ func TestFormatMenuItem(t *testing.T) {
 }
 
 func TestClaimsModalProcessClaimResult(t *testing.T) {
+	s := global.NewSession()
 	const validDateStr = "2026-02-02"
-	validDate, _ := time.Parse("2006-01-02", validDateStr)
+	validDate, _ := time.Parse(time.DateTime, validDateStr+" 23:59:59")
 
 	tests := []struct {
 		name       string
@@ -654,9 +771,9 @@ func TestClaimsModalProcessClaimResult(t *testing.T) {
 			// Setup global state for onActionChange
 			uid := "test_user"
 			// Need at least tt.i + 1 users in CrntUsers
-			global.CrntUsers = make([]string, tt.i+1)
-			global.CrntUsers[tt.i] = uid
-			global.LocalUsers = map[string]*global.User{
+			s.CrntUsers = make([]string, tt.i+1)
+			s.CrntUsers[tt.i] = uid
+			s.LocalUsers = map[string]*global.User{
 				uid: {
 					UID:    uid,
 					Email:  "user@test.com",
@@ -664,16 +781,18 @@ func TestClaimsModalProcessClaimResult(t *testing.T) {
 					Claims: make(common.ClaimsMap),
 				},
 			}
-			global.Actions = make(map[string]common.ClaimsMap)
-			global.Actions[uid] = make(common.ClaimsMap)
+			s.Actions = make(map[string]common.ClaimsMap)
+			s.Actions[uid] = make(common.ClaimsMap)
 
 			// Create radio with proper options
 			radio := tview.NewRadio(lang.SActive, lang.SInactive, lang.STimed)
 			radio.SetValue(tt.radioValue)
 
-			dateField := tview.NewInputField().SetText(tt.dateText)
+			dateField := NewDatePicker()
+			dateField.SetText(tt.dateText)
 
 			c := &ClaimsModal{
+				s:     s,
 				radio: radio,
 				date:  dateField,
 				i:     tt.i,
@@ -683,9 +802,9 @@ func TestClaimsModalProcessClaimResult(t *testing.T) {
 			// Call processClaimResult
 			c.processClaimResult()
 
-			// Verify that onActionChange was called by checking global.Actions
-			actualClaim, exists := global.Actions[uid][tt.key]
-			assert.True(t, exists, "claim should exist in global.Actions")
+			// Verify that onActionChange was called by checking s.Actions
+			actualClaim, exists := s.Actions[uid][tt.key]
+			assert.True(t, exists, "claim should exist in s.Actions")
 
 			// Construct expected claim based on radio value
 			expectedClaim := common.Claim{}
@@ -813,7 +932,7 @@ func TestClaimsModalIncDate(t *testing.T) {
 
 			c := &ClaimsModal{
 				radio: radio,
-				date:  tview.NewInputField(),
+				date:  NewDatePicker(),
 				i:     0,
 				key:   "test_key",
 			}
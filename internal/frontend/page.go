@@ -7,32 +7,38 @@ import (
 	"github.com/vendelin8/firemage/internal/lang"
 )
 
-// initPages initializes pages that need it.
-func initPages(page string) error {
-	if page == lang.PageList {
+// initPages initializes pages that need it. The Expiring page needs the privileged users of the List
+// page.
+func initPages(s *global.Session, page string) error {
+	switch page {
+	case lang.PageList:
 		return common.Fb.DoList()
+	case lang.PageExpiring:
+		if _, ok := s.SavedUsers[lang.PageList]; !ok {
+			return common.Fb.DoList()
+		}
 	}
 	return nil
 }
 
 // ShowPage shows the given page.
-func ShowPage(newPage string) error {
+func ShowPage(s *global.Session, newPage string) error {
 	oldPage := common.Fe.CurrentPage()
 	if newPage == oldPage {
 		return nil
 	}
 
-	global.SavedUsers[oldPage] = global.CrntUsers // saving users to the closing page
+	s.SavedUsers[oldPage] = s.CrntUsers // saving users to the closing page
 	common.Fe.SetPage(newPage)
-	if us, ok := global.SavedUsers[newPage]; ok {
-		global.CrntUsers = us
+	if us, ok := s.SavedUsers[newPage]; ok {
+		s.CrntUsers = us
 	} else {
-		global.CrntUsers = []string{}
-		if err := initPages(newPage); err != nil {
+		s.CrntUsers = []string{}
+		if err := initPages(s, newPage); err != nil {
 			return err
 		}
 	}
-	if len(global.Actions) > 0 && newPage == lang.PageList {
+	if len(s.Actions) > 0 && newPage == lang.PageList {
 		window.ShowWarningOnce(lang.WarnActionInList)
 	}
 	common.Fe.LayoutUsers()
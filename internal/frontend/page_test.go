@@ -14,6 +14,7 @@ import (
 )
 
 func TestShowPage(t *testing.T) {
+	s := global.NewSession()
 	t.Run("ShowPage navigation", func(t *testing.T) {
 		tests := []struct {
 			name           string
@@ -136,9 +137,9 @@ func TestShowPage(t *testing.T) {
 				common.Fb = mockFb
 
 				// Setup global state
-				global.SavedUsers = tt.savedUsers
-				global.CrntUsers = tt.crntUsers
-				global.Actions = testutil.BuildActionsMap(tt.actions)
+				s.SavedUsers = tt.savedUsers
+				s.CrntUsers = tt.crntUsers
+				s.Actions = testutil.BuildActionsMap(tt.actions)
 
 				mockFe.EXPECT().CurrentPage().Return(tt.currentPage).Times(1)
 
@@ -162,13 +163,13 @@ func TestShowPage(t *testing.T) {
 				}
 
 				// Execute
-				err := ShowPage(tt.newPage)
+				err := ShowPage(s, tt.newPage)
 
 				// Assert
 				assert.Equal(t, tt.wantErr, err)
-				assert.Equal(t, tt.wantUsers, global.CrntUsers)
+				assert.Equal(t, tt.wantUsers, s.CrntUsers)
 				if tt.currentPage != tt.newPage {
-					assert.Equal(t, tt.wantSavedUsers, global.SavedUsers)
+					assert.Equal(t, tt.wantSavedUsers, s.SavedUsers)
 				}
 			})
 		}
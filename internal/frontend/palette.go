@@ -0,0 +1,119 @@
+package frontend
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+	"github.com/vendelin8/tview"
+)
+
+const (
+	paletteWidth  = 60
+	paletteHeight = 20
+)
+
+// rowCmds are the commands working on the focused row of the users table. The command palette shows
+// them with its user. bulkCmds work on the selected users instead, if any of them is shown.
+var (
+	rowCmds  = []int{conf.CmdRole, conf.CmdSelect, conf.CmdExtend, conf.ActToggleClaim, conf.ActOpenDetail}
+	bulkCmds = []int{conf.CmdRole, conf.CmdExtend}
+)
+
+// paletteEntry is a menu command or action in the command palette, with its score for the query.
+type paletteEntry struct {
+	cmd   int
+	text  string
+	score int
+}
+
+// ShowPalette pops up the command palette searching all menu commands and actions.
+func ShowPalette() error {
+	window.PushPopup(lang.PopupPalette)
+	common.Fe.ShowPalette()
+
+	return nil
+}
+
+// paletteEntries returns the menu commands and actions matching the query fuzzily, the best ones
+// first, in menu order otherwise.
+func paletteEntries(s *global.Session, query string) []paletteEntry {
+	var entries []paletteEntry
+	for _, cmd := range slices.Sorted(maps.Keys(common.MenuItems)) {
+		if cmd == conf.CmdPalette {
+			continue
+		}
+
+		text := common.MenuItems[cmd].Text
+		if rows := targetRows(s); slices.Contains(bulkCmds, cmd) && len(rows) > 1 {
+			text = fmt.Sprintf("%s: %s", text, fmt.Sprintf(lang.SUsersN, len(rows)))
+		} else if slices.Contains(rowCmds, cmd) && s.FocusedRow >= 0 && s.FocusedRow < len(s.CrntUsers) {
+			text = fmt.Sprintf("%s: %s", text, s.LocalUsers[s.CrntUsers[s.FocusedRow]].Email)
+		}
+
+		if score, ok := util.FuzzyScore(query, text); ok {
+			entries = append(entries, paletteEntry{cmd: cmd, text: text, score: score})
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b paletteEntry) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	return entries
+}
+
+// ShowPalette shows the command palette: a search field and the matching commands with their
+// shortcuts. Enter runs the chosen one like its shortcut.
+func (f *Frontend) ShowPalette() {
+	var entries []paletteEntry
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	input := tview.NewInputField().SetLabel("> ").SetPlaceholder(lang.SPaletteHint)
+
+	fill := func(query string) {
+		entries = paletteEntries(f.s, query)
+		list.Clear()
+		for _, e := range entries {
+			shortcut := common.MenuItems[e.cmd].Shortcut
+			list.AddItem(fmt.Sprintf("%s  [::d]%s[::-]", tview.Escape(e.text), tview.Escape(shortcut)), "", 0, nil)
+		}
+	}
+	run := func(index int) {
+		if index < 0 || index >= len(entries) {
+			return
+		}
+
+		window.HidePopup(lang.PopupPalette)
+		runCmd(entries[index].cmd)
+	}
+
+	input.SetChangedFunc(fill).SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			run(list.GetCurrentItem())
+		}
+	}).SetInputCapture(func(ev *tcell.EventKey) *tcell.EventKey {
+		switch ev.Key() {
+		case tcell.KeyUp:
+			list.SetCurrentItem(max(list.GetCurrentItem()-1, 0))
+		case tcell.KeyDown:
+			list.SetCurrentItem(min(list.GetCurrentItem()+1, list.GetItemCount()-1))
+		default:
+			return ev
+		}
+		return nil
+	})
+	list.SetSelectedFunc(func(index int, _, _ string, _ rune) { run(index) })
+	fill("")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).AddItem(input, 1, 0, true).AddItem(list, 0, 1, false)
+	layout.SetBorder(true).SetTitle(fmt.Sprintf(" %s ", lang.MenuPalette))
+	f.pages.AddPage(lang.PopupPalette, tview.NewCenter(layout, paletteWidth, paletteHeight), true, true)
+	f.app.SetFocus(input)
+}
@@ -0,0 +1,81 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vendelin8/tview"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+)
+
+func TestPaletteEntries(t *testing.T) {
+	originalMenuItems := common.MenuItems
+	defer func() {
+		common.MenuItems = originalMenuItems
+	}()
+
+	common.MenuItems = map[int]common.MenuItem{
+		conf.CmdSearch:     {Text: "Search", Shortcut: "F2"},
+		conf.CmdSave:       {Text: "Save", Shortcut: "F6"},
+		conf.CmdRole:       {Text: "Apply role", Shortcut: "F7"},
+		conf.CmdDryRun:     {Text: "Dry run", Shortcut: "F9"},
+		conf.CmdPalette:    {Text: "Commands", Shortcut: "Ctrl-K"},
+		conf.ActOpenDetail: {Text: "Open detail", Shortcut: "Ctrl-O"},
+	}
+	s := global.NewSession()
+	s.CrntUsers = []string{"uid1"}
+	s.LocalUsers["uid1"] = &global.User{UID: "uid1", Email: "a@example.com"}
+
+	texts := func(entries []paletteEntry) []string {
+		res := make([]string, len(entries))
+		for i, e := range entries {
+			res[i] = e.text
+		}
+		return res
+	}
+
+	s.FocusedRow = -1
+	assert.Equal(t, []string{"Search", "Save", "Apply role", "Dry run", "Open detail"},
+		texts(paletteEntries(s, "")), "all but the palette in menu order")
+	assert.Equal(t, []string{"Apply role", "Search"}, texts(paletteEntries(s, "ar")), "best first")
+	assert.Empty(t, paletteEntries(s, "xyz"))
+
+	s.FocusedRow = 0
+	assert.Equal(t, []string{"Open detail: a@example.com"}, texts(paletteEntries(s, "detail")),
+		"row commands with the focused user")
+	assert.Equal(t, []string{"Apply role: a@example.com", "Open detail: a@example.com"},
+		texts(paletteEntries(s, "example")))
+
+	s.CrntUsers = []string{"uid1", "uid2"}
+	s.LocalUsers["uid2"] = &global.User{UID: "uid2", Email: "b@example.com"}
+	s.Selected["uid1"], s.Selected["uid2"] = struct{}{}, struct{}{}
+	assert.Equal(t, []string{"Apply role: 2 users", "Open detail: a@example.com"},
+		texts(paletteEntries(s, "ol")), "bulk commands with the selected users")
+}
+
+func TestShowPalette(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	defer window.SetPopups()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+	mockFe.EXPECT().ShowPalette().Times(1)
+
+	assert.NoError(t, ShowPalette())
+	assert.Equal(t, lang.PopupPalette, window.TopPopup())
+}
+
+func TestFrontendShowPalette(t *testing.T) {
+	f := &Frontend{s: global.NewSession(), pages: tview.NewPages(), app: tview.NewApplication()}
+	f.ShowPalette()
+
+	assert.True(t, f.pages.HasPage(lang.PopupPalette))
+}
@@ -0,0 +1,78 @@
+package frontend
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/util"
+	"github.com/vendelin8/tview"
+)
+
+var (
+	ErrNoRoles = lang.NewError(&lang.ErrNoRolesS)
+	ErrNoRow   = lang.NewError(&lang.ErrNoRowS)
+)
+
+// ShowRoles pops up the role template chooser for the selected users in the users table, or for the
+// focused one if none of them is shown.
+func ShowRoles(s *global.Session) error {
+	if len(common.Roles) == 0 {
+		return ErrNoRoles
+	}
+
+	rows := targetRows(s)
+	if len(rows) == 0 {
+		return ErrNoRow
+	}
+
+	window.PushPopup(lang.PopupRole)
+	common.Fe.ShowRoleChoser(rows)
+
+	return nil
+}
+
+// ShowRoleChoser shows a dialog with a button for each role template to apply to the users in the
+// given rows.
+func (f *Frontend) ShowRoleChoser(rows []int) {
+	who := f.s.LocalUsers[f.s.CrntUsers[rows[0]]].Email
+	if len(rows) > 1 {
+		who = fmt.Sprintf(lang.SUsersN, len(rows))
+	}
+
+	names := slices.Sorted(maps.Keys(common.Roles))
+	m := tview.NewModal().SetText(fmt.Sprintf(lang.SApplyRole, who)).
+		AddButtons(append(names, lang.SCancel)).
+		SetDoneFunc(func(_ int, buttonLabel string) {
+			window.HidePopup(lang.PopupRole)
+			if _, ok := common.Roles[buttonLabel]; ok {
+				window.ShowErrorBuffer(applyRole(f.s, rows, buttonLabel))
+			}
+		})
+	f.pages.AddPage(lang.PopupRole, m, true, true)
+}
+
+// applyRole stages all claims of the given role template for the users in the given rows. Broken
+// permission rules are left in the error buffer.
+func applyRole(s *global.Session, rows []int, name string) error {
+	claims, err := util.RoleClaims(common.Roles[name], time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, i := range rows {
+		for _, key := range slices.Sorted(maps.Keys(claims)) {
+			onActionChange(s, i, key, *claims[key])
+		}
+
+		uid := s.CrntUsers[i]
+		window.WriteViolations(s.LocalUsers[uid].Email, s.Violations[uid])
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package frontend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/frontend/window"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+	"github.com/vendelin8/firemage/internal/mock"
+)
+
+func TestShowRoles(t *testing.T) {
+	s := global.NewSession()
+	tests := []struct {
+		name       string
+		roles      map[string]common.Role
+		crntUsers  []string
+		focusedRow int
+		selected   []string
+		wantRows   []int
+		wantErr    error
+	}{
+		{
+			name:       "no roles configured",
+			crntUsers:  []string{"uid1"},
+			focusedRow: 0,
+			wantErr:    ErrNoRoles,
+		},
+		{
+			name:       "no focused row",
+			roles:      map[string]common.Role{"auditor": {common.Admin: ""}},
+			crntUsers:  []string{"uid1"},
+			focusedRow: -1,
+			wantErr:    ErrNoRow,
+		},
+		{
+			name:       "focused row out of range",
+			roles:      map[string]common.Role{"auditor": {common.Admin: ""}},
+			crntUsers:  []string{"uid1"},
+			focusedRow: 1,
+			wantErr:    ErrNoRow,
+		},
+		{
+			name:       "shows chooser for focused row",
+			roles:      map[string]common.Role{"auditor": {common.Admin: ""}},
+			crntUsers:  []string{"uid1", "uid2"},
+			focusedRow: 1,
+			wantRows:   []int{1},
+		},
+		{
+			name:       "shows chooser for selected rows",
+			roles:      map[string]common.Role{"auditor": {common.Admin: ""}},
+			crntUsers:  []string{"uid1", "uid2", "uid3"},
+			focusedRow: 1,
+			selected:   []string{"uid1", "uid3"},
+			wantRows:   []int{0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+
+			common.Roles = tt.roles
+			s.CrntUsers = tt.crntUsers
+			s.FocusedRow = tt.focusedRow
+			for _, uid := range tt.selected {
+				s.Selected[uid] = struct{}{}
+			}
+
+			if tt.wantErr == nil {
+				mockFe.EXPECT().ShowRoleChoser(tt.wantRows).Times(1)
+			}
+
+			err := ShowRoles(s)
+
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Contains(t, window.Popups(), lang.PopupRole)
+			}
+
+			window.SetPopups()
+			common.Roles = map[string]common.Role{}
+			s.FocusedRow = -1
+			clear(s.Selected)
+		})
+	}
+}
+
+func TestApplyRole(t *testing.T) {
+	s := global.NewSession()
+	tomorrow := time.Now().AddDate(0, 0, 1)
+
+	tests := []struct {
+		name            string
+		role            common.Role
+		saved           common.ClaimsMap
+		wantLayoutUsers int
+		wantActions     map[string]common.ClaimsMap
+		wantViolations  string
+	}{
+		{
+			name:  "stages all permanent claims",
+			role:  common.Role{common.Admin: "", common.Consultant: ""},
+			saved: common.ClaimsMap{common.Admin: {}, common.Consultant: {}, common.SuperAdmin: {}},
+			wantActions: map[string]common.ClaimsMap{"uid1": {
+				common.Admin:      {Checked: true},
+				common.Consultant: {Checked: true},
+			}},
+			wantViolations: "Permission rules are violated for user1@example.com:\n- Consultant can't be granted for longer than 1y",
+		},
+		{
+			name:            "stages timed claim replacing a permanent one",
+			role:            common.Role{common.Consultant: "1d"},
+			saved:           common.ClaimsMap{common.Admin: {}, common.Consultant: {Checked: true}},
+			wantLayoutUsers: 1,
+			wantActions:     map[string]common.ClaimsMap{"uid1": {common.Consultant: {Date: &tomorrow}}},
+		},
+		{
+			name:        "already granted claims stage nothing",
+			role:        common.Role{common.Admin: ""},
+			saved:       common.ClaimsMap{common.Admin: {Checked: true}},
+			wantActions: map[string]common.ClaimsMap{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			mockFe.EXPECT().ReplaceTableItem(0, gomock.Any(), gomock.Any()).AnyTimes()
+			mockFe.EXPECT().LayoutUsers().Times(tt.wantLayoutUsers)
+
+			common.Roles = map[string]common.Role{"role": tt.role}
+			s.CrntUsers = []string{"uid1"}
+			s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Email: "user1@example.com", Claims: tt.saved}}
+			s.Actions = map[string]common.ClaimsMap{}
+
+			assert.NoError(t, applyRole(s, []int{0}, "role"))
+			assert.Equal(t, tt.wantViolations, window.GetErrorStr())
+
+			assert.Len(t, s.Actions, len(tt.wantActions))
+			for key, want := range tt.wantActions["uid1"] {
+				got := s.Actions["uid1"][key]
+				assert.NotNil(t, got, key)
+				assert.False(t, want.Differs(got), key)
+			}
+
+			common.Roles = map[string]common.Role{}
+		})
+	}
+}
@@ -16,20 +16,33 @@ func (f *Frontend) initSearch() {
 
 	form := tview.NewForm().AddFormItem(f.searchRadio).AddFormItem(f.searchField)
 	form.AddButton(lang.SDoSearch, func() {
-		firebase.Search(f.searchFieldName[f.searchRadio.Value()], f.searchField.GetText())
+		firebase.Search(f.s, f.searchFieldName[f.searchRadio.Value()], f.searchField.GetText())
 		common.Fe.LayoutUsers()
 	})
 
-	f.SetOnShow(lang.PageSearch, func() {
-		f.searchPage.ResizeItemAt(1, len(global.CrntUsers)+1, 0)
-	})
+	f.SetOnShow(lang.PageSearch, func() {})
 	h := 3 // form padding: top+button+bottom
 	for i := 0; i < form.GetFormItemCount(); i++ {
 		h += form.GetFormItem(i).GetFieldHeight()
 		h++
 	}
 	f.searchPage = tview.NewFlex().SetDirection(tview.FlexRow).AddItem(form, h, 0, true).
-		AddItem(f.userTbl, 1, 0, true).AddItem(f.filler, 0, 1, false)
+		AddItem(f.userTbl, 0, 1, true)
+}
+
+// FocusSearch focuses the search field, showing the Search page if needed.
+func FocusSearch(s *global.Session) error {
+	if err := ShowPage(s, lang.PageSearch); err != nil {
+		return err
+	}
+
+	common.Fe.FocusSearch()
+	return nil
+}
+
+// FocusSearch focuses the search field of the Search page.
+func (f *Frontend) FocusSearch() {
+	f.app.SetFocus(f.searchField)
 }
 
 func (f *Frontend) searchForEmail() {
@@ -1,10 +1,14 @@
 package frontend
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/conf"
 	"github.com/vendelin8/firemage/internal/frontend/window"
 	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/lang"
@@ -14,7 +18,10 @@ import (
 	"go.uber.org/zap"
 )
 
-const namedCols = 2 // name and email column
+const (
+	namedCols      = 2 // name and email column
+	remainingWidth = 7 // the remaining time after expiry dates, like " 365d"
+)
 
 func (f *Frontend) initUsersList() {
 	colNum := len(common.AllPerms) + namedCols
@@ -27,23 +34,45 @@ func (f *Frontend) initUsersList() {
 	for i, perm := range common.AllPerms {
 		j := i + namedCols
 		f.userHdrs[j] = common.PermsMap[perm]
-		colSizes[j] = max(len(perm), len(common.DateFormat)) + 1 // checkbox padding
+		colSizes[j] = max(len(perm), dateCellWidth()) + 1 // checkbox padding
 	}
 	for col, text := range f.userHdrs {
-		f.userTbl.AddItem(newText(text), 0, col, 1, 1, 0, 0, false)
+		f.userTbl.grid.AddItem(newText(text), 0, col, 1, 1, 0, 0, false)
+	}
+	f.userTbl.grid.SetColumns(colSizes...)
+	f.userTbl.onResize = func() { f.app.QueueUpdateDraw(f.layoutRows) }
+	f.userTbl.SetInputCapture(f.usersKeys).SetMouseCapture(f.usersMouse)
+}
+
+// dateCellWidth returns the width of the expiry dates in the table, with the remaining time.
+func dateCellWidth() int {
+	width := len(common.DateFormat) + remainingWidth
+	if common.StoreTimestamps {
+		width += len(" " + common.TimeFormat)
 	}
-	f.userTbl.SetColumns(colSizes...)
+
+	return width
 }
 
 // activatePopup is called when an empty checkbox is checked, or a date is clicked. It pops up
 // a dialog to change value to true or a given date.
 func activatePopup(i int, key string, c common.Claim) {
+	if locked(key) {
+		return
+	}
+
 	window.PushPopup(lang.PopupClaim)
 	common.Fe.ShowClaimChoser(i, key, c)
 }
 
+// locked checks if the given permission can't be changed in the table: in read-only mode, or if
+// the operator may not grant or revoke it.
+func locked(perm string) bool {
+	return conf.ReadOnly || !conf.CanGrant(perm)
+}
+
 // tableCB returns a checkbox or date text to the claim table filled with the saved value.
-func tableCB(i int, key string, c common.Claim) tview.Primitive {
+func tableCB(s *global.Session, i int, key string, c common.Claim) tview.Primitive {
 	var (
 		box tview.FormItem
 		bgc = tview.Styles.PrimitiveBackgroundColor
@@ -51,22 +80,22 @@ func tableCB(i int, key string, c common.Claim) tview.Primitive {
 	)
 
 	if i%2 == 1 {
-		bgc = tview.Styles.PrimaryTextColor
-		ftc = tview.Styles.PrimitiveBackgroundColor
+		bgc = common.CurrentTheme.StripeBackground
+		ftc = common.CurrentTheme.StripeText
 	}
 
 	var tv *tview.InputField
 
 	if c.Date == nil {
-		box = createTableCheckbox(i, key, c, ftc, bgc)
+		box = createTableCheckbox(s, i, key, c, ftc, bgc)
 	} else {
-		tv = createTableDateField(i, key, c, ftc, bgc)
+		tv = createTableDateField(s, i, key, c, ftc, bgc)
 		box = tv
 	}
 
 	center := tview.NewCenter(box, box.GetFieldWidth(), box.GetFieldHeight())
 	if i%2 == 1 {
-		center.SetBackgroundColor(tview.Styles.PrimaryTextColor)
+		center.SetBackgroundColor(bgc)
 		// if tv != nil {
 		// 	tv.SetFieldTextColor(tview.Styles.ContrastBackgroundColor)
 		// }
@@ -74,32 +103,37 @@ func tableCB(i int, key string, c common.Claim) tview.Primitive {
 	return center
 }
 
-func createTableCheckbox(i int, key string, c common.Claim, ftc, bgc tcell.Color) *tview.Checkbox {
+func createTableCheckbox(s *global.Session, i int, key string, c common.Claim, ftc, bgc tcell.Color) *tview.Checkbox {
 	manualChange := false
 	cb := tview.NewCheckbox()
 	cb.SetChecked(c.Checked).SetChangedFunc(func(checked bool) {
-		onActionChange(i, key, common.Claim{Checked: checked})
 		if manualChange {
 			return
 		}
 
 		if !checked {
-			return // unchecked, no popup
+			onActionChange(s, i, key, common.Claim{})
+			showViolations(s, i) // unchecked, no popup
+			return
 		}
 
+		// the popup decides about the new value, so revert the checkbox until then
 		manualChange = true // to avoid re-entrance
 		cb.SetChecked(!checked)
 		manualChange = false
 		activatePopup(i, key, common.Claim{Checked: checked})
 	}).SetFieldTextColor(ftc)
 	cb.SetBackgroundColor(bgc)
+	cb.SetFocusFunc(func() { s.FocusedRow, s.FocusedKey = i, key })
+	cb.SetDisabled(locked(key))
 
 	return cb
 }
 
-func createTableDateField(i int, key string, c common.Claim, ftc, bgc tcell.Color) *tview.InputField {
+func createTableDateField(s *global.Session, i int, key string, c common.Claim, ftc, bgc tcell.Color) *tview.InputField {
 	tv := tview.NewInputField().
-		SetText(c.FormatDate()).SetFieldTextColor(ftc).SetFieldWidth(len(common.DateFormat))
+		SetText(c.FormatDate() + " " + c.Remaining(time.Now())).SetFieldTextColor(ftc).
+		SetFieldWidth(dateCellWidth())
 	tv.SetBackgroundColor(bgc).
 		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 			activatePopup(i, key, c)
@@ -114,45 +148,37 @@ func createTableDateField(i int, key string, c common.Claim, ftc, bgc tcell.Colo
 			return tview.MouseConsumed, nil
 		})
 
+	tv.SetFocusFunc(func() { s.FocusedRow, s.FocusedKey = i, key })
 	tv.SetDisabled(true)
 
 	return tv
 }
 
 // onActionChange in called when a user claim is changed by checkbox or date field.
-func onActionChange(i int, key string, c common.Claim) {
-	uid := global.CrntUsers[i]
-	current := global.LocalUsers[uid].Claims[key]
-	currentVisual := util.FixedUserClaims(uid)[key]
-	acts := global.Actions[uid]
+func onActionChange(s *global.Session, i int, key string, c common.Claim) {
+	uid := s.CrntUsers[i]
+	current := s.LocalUsers[uid].Claims[key]
+	currentVisual := util.FixedUserClaims(s, uid)[key]
 	log.Lgr.Debug("onActionChange", zap.Int("i", i), zap.String("key", key), zap.Any("claim", c), zap.Any("current", current), zap.Any("currentVisual", currentVisual))
 
 	defer func() {
-		log.Lgr.Debug("defer", zap.Any("claim", c), zap.Any("current", current), zap.Any("acts", acts))
+		log.Lgr.Debug("defer", zap.Any("claim", c), zap.Any("current", current), zap.Any("acts", s.Actions[uid]))
 		// Check if claim differs from current visual (including when visual is nil)
 		differs := currentVisual == nil || c.Differs(currentVisual)
 		if differs {
-			center := tableCB(i, key, c)
+			center := tableCB(s, i, key, c)
 			common.Fe.ReplaceTableItem(i, key, center)
 		}
 	}()
 
-	if current != nil && !c.Differs(current) {
-		log.Lgr.Debug("deleting action")
-		delete(acts, key)
-		if len(acts) == 0 {
-			delete(global.Actions, uid)
-		}
-		return
-	}
+	util.StageClaim(s, uid, key, c)
 
-	if acts == nil {
-		acts = common.ClaimsMap{}
-		global.Actions[uid] = acts
+	if vs := util.UserViolations(s, uid, time.Now()); len(vs) > 0 {
+		s.Violations[uid] = vs
+	} else {
+		delete(s.Violations, uid)
 	}
 
-	acts[key] = &c
-
 	// Check for type change (boolean <-> date) and trigger layout refresh if needed
 	if current != nil {
 		isCurrentBoolean := current.Date == nil
@@ -164,41 +190,253 @@ func onActionChange(i int, key string, c common.Claim) {
 	}
 }
 
+// showViolations shows the broken permission rules of the i-th user, if any.
+func showViolations(s *global.Session, i int) {
+	uid := s.CrntUsers[i]
+	window.WriteViolations(s.LocalUsers[uid].Email, s.Violations[uid])
+	window.ShowErrorBuffer(nil)
+}
+
 // LayoutUsers updates current users with their permissions as checkboxes.
 func (f *Frontend) LayoutUsers() {
-	f.userTbl.ClearAfter(len(f.userHdrs))
-	rows := make([]int, len(global.CrntUsers))
-	for i, uid := range global.CrntUsers {
-		rows[i] = 1
-		n, e, claims := util.FixedUserDetails(uid)
-		nt, et := newText(n), newText(e)
-		if i%2 == 1 {
-			nt.SetBackgroundColor(tview.Styles.PrimaryTextColor)
-			nt.SetTextColor(tview.Styles.ContrastBackgroundColor)
-			et.SetBackgroundColor(tview.Styles.PrimaryTextColor)
-			et.SetTextColor(tview.Styles.ContrastBackgroundColor)
-		}
-		f.userTbl.AddItem(nt, i+1, 0, 1, 1, 0, 0, false).AddItem(et, i+1, 1, 1, 1, 0, 0, false)
+	f.s.FocusedRow, f.s.FocusedKey = -1, ""
+	f.userCells = make([][]tview.Primitive, len(f.s.CrntUsers))
+	f.layoutRows()
+	f.onShowPage[f.CurrentPage()]()
+}
+
+// layoutRows builds the rows of the users fitting in the table from its top one, and shows which
+// ones they are in the status line. If a cell of the table had the focus, the same permission of
+// the nearest row shown gets it.
+func (f *Frontend) layoutRows() {
+	t := f.userTbl
+	hadFocus := t.HasFocus()
+	t.top = max(min(t.top, len(f.s.CrntUsers)-t.rows), 0)
+	end := min(t.top+t.rows, len(f.s.CrntUsers))
+	t.grid.ClearAfter(len(f.userHdrs))
+	clear(f.userCells)        // cells of the rows scrolled out
+	height := end - t.top + 1 // with the header
+	t.grid.SetRows(slices.Repeat([]int{1}, height)...)
+	t.ResizeItem(t.grid, height, 0)
+	t.status.SetText(usersStatus(t.top, end, len(f.s.CrntUsers)))
+	for i := t.top; i < end; i++ {
+		uid := f.s.CrntUsers[i]
+		f.userCells[i] = make([]tview.Primitive, len(common.AllPerms))
+		n, e, claims := util.FixedUserDetails(f.s, uid)
+		nt, et := f.userTexts(i, uid, n, e)
+		t.grid.AddItem(nt, t.row(i), 0, 1, 1, 0, 0, false).AddItem(et, t.row(i), 1, 1, 1, 0, 0, false)
 		for j, perm := range common.AllPerms {
 			c, ok := claims[perm]
 			if !ok || c == nil {
 				common.Fe.ShowMsg(fmt.Sprintf("%s: %s, %s", lang.ErrWrongDBClaimS, perm, claims))
 				return
 			}
-			f.userTbl.AddItem(tableCB(i, perm, *c), i+1, j+namedCols, 1, 1, 0, 0, true)
+			f.userCells[i][j] = tableCB(f.s, i, perm, *c)
+			t.grid.AddItem(f.userCells[i][j], t.row(i), j+namedCols, 1, 1, 0, 0, true)
 		}
 	}
-	f.onShowPage[f.CurrentPage()]()
-	f.userTbl.SetRows(rows...)
+
+	if hadFocus && end > t.top {
+		f.FocusCell(min(max(f.s.FocusedRow, t.top), end-1), cmp.Or(f.s.FocusedKey, common.AllPerms[0]))
+	}
 }
 
-func (f *Frontend) ReplaceTableItem(i int, key string, p tview.Primitive) {
-	for j, perm := range common.AllPerms {
-		if perm == key {
-			f.userTbl.ReplaceItemAt(p, i+1, j+namedCols)
-			break
+// usersStatus returns the status line of the users table showing the users from top to end.
+func usersStatus(top, end, count int) string {
+	if count == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(lang.SRowsOf, top+1, end, count)
+}
+
+// scrollUsers scrolls the users table to show the users from the given one.
+func (f *Frontend) scrollUsers(top int) {
+	f.userTbl.top = top
+	f.layoutRows()
+}
+
+// usersKeys scrolls the users table by a page with PgUp and PgDn, and to its first or last row
+// with Home and End, moving the focus with it.
+func (f *Frontend) usersKeys(ev *tcell.EventKey) *tcell.EventKey {
+	t, count := f.userTbl, len(f.s.CrntUsers)
+	var delta int
+	switch ev.Key() {
+	case tcell.KeyPgUp:
+		delta = -t.rows
+	case tcell.KeyPgDn:
+		delta = t.rows
+	case tcell.KeyHome:
+		delta = -count
+	case tcell.KeyEnd:
+		delta = count
+	default:
+		return ev
+	}
+
+	if f.s.FocusedRow < 0 {
+		f.scrollUsers(t.top + delta)
+		return nil
+	}
+
+	if err := MoveRow(f.s, delta); err != nil {
+		return ev
+	}
+	return nil
+}
+
+// usersMouse scrolls the users table with the mouse wheel.
+func (f *Frontend) usersMouse(action tview.MouseAction, ev *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	switch action {
+	case tview.MouseScrollUp:
+		f.scrollUsers(f.userTbl.top - wheelRows)
+	case tview.MouseScrollDown:
+		f.scrollUsers(f.userTbl.top + wheelRows)
+	default:
+		return action, ev
+	}
+
+	return tview.MouseConsumed, nil
+}
+
+// userTexts returns the name and email cells of the i-th user, striped, colored by changes of
+// others, and highlighted if selected.
+func (f *Frontend) userTexts(i int, uid, name, email string) (nt, et *tview.TextView) {
+	theme := common.CurrentTheme
+	nt, et = newText(name), newText(email)
+	if i%2 == 1 {
+		nt.SetBackgroundColor(theme.StripeBackground)
+		nt.SetTextColor(theme.StripeText)
+		et.SetBackgroundColor(theme.StripeBackground)
+		et.SetTextColor(theme.StripeText)
+	}
+	if added, ok := f.s.RemoteChanges[uid]; ok { // changed by others
+		color := theme.Negative
+		if added {
+			color = theme.Positive
 		}
+		nt.SetTextColor(color)
+		et.SetTextColor(color)
+	} else if _, ok := f.s.RemoteEdits[uid]; ok {
+		nt.SetTextColor(theme.Warning)
+		et.SetTextColor(theme.Warning)
 	}
+	if _, ok := f.s.Selected[uid]; ok {
+		nt.SetTextColor(theme.SelectedText).SetBackgroundColor(theme.SelectedBackground)
+		et.SetTextColor(theme.SelectedText).SetBackgroundColor(theme.SelectedBackground)
+		if theme.SelectedBackground == tcell.ColorDefault {
+			nt.SetTextStyle(tcell.StyleDefault.Reverse(true))
+			et.SetTextStyle(tcell.StyleDefault.Reverse(true))
+		}
+	}
+
+	return nt, et
+}
+
+// ReplaceUserNames redraws the name and email cells of the i-th user, eg. after (de)selecting it.
+func (f *Frontend) ReplaceUserNames(i int) {
+	if !f.userTbl.shown(i) {
+		return // built with the changes when scrolled in
+	}
+
+	u := f.s.LocalUsers[f.s.CrntUsers[i]]
+	nt, et := f.userTexts(i, u.UID, u.Name, u.Email)
+	f.userTbl.grid.ReplaceItemAt(nt, f.userTbl.row(i), 0).ReplaceItemAt(et, f.userTbl.row(i), 1)
+}
+
+// ReplaceTableItem replaces the cell of the given permission in the i-th row of the users table,
+// if it's shown.
+func (f *Frontend) ReplaceTableItem(i int, key string, p tview.Primitive) {
+	j := slices.Index(common.AllPerms, key)
+	if j < 0 || !f.userTbl.shown(i) || i >= len(f.userCells) {
+		return
+	}
+
+	f.userTbl.grid.ReplaceItemAt(p, f.userTbl.row(i), j+namedCols)
+	f.userCells[i][j] = p
+}
+
+// FocusCell focuses the cell of the given permission in the i-th row of the users table, scrolling
+// it in if needed.
+func (f *Frontend) FocusCell(i int, key string) {
+	j := slices.Index(common.AllPerms, key)
+	if i < 0 || i >= len(f.userCells) || j < 0 {
+		return
+	}
+
+	t := f.userTbl
+	switch {
+	case i < t.top:
+		f.scrollUsers(i)
+	case i >= t.top+t.rows:
+		f.scrollUsers(i - t.rows + 1)
+	}
+	if f.userCells[i] == nil {
+		return
+	}
+
+	f.app.SetFocus(f.userCells[i][j])
+	f.s.FocusedRow, f.s.FocusedKey = i, key
+}
+
+// MoveRow focuses the same permission in the row below the focused one in the users table, or above
+// it with a negative delta. Without a focused row it starts at the first or the last one.
+func MoveRow(s *global.Session, delta int) error {
+	if len(s.CrntUsers) == 0 {
+		return ErrNoRow
+	}
+
+	i := s.FocusedRow + delta
+	if s.FocusedRow < 0 && delta < 0 {
+		i = len(s.CrntUsers) - 1
+	}
+	i = min(max(i, 0), len(s.CrntUsers)-1)
+
+	common.Fe.FocusCell(i, cmp.Or(s.FocusedKey, common.AllPerms[0]))
+	return nil
+}
+
+// focusedClaim returns the claim of the focused cell of the users table as shown.
+func focusedClaim(s *global.Session) (*common.Claim, error) {
+	if s.FocusedRow < 0 || s.FocusedRow >= len(s.CrntUsers) || len(s.FocusedKey) == 0 {
+		return nil, ErrNoRow
+	}
+
+	c := util.FixedUserClaims(s, s.CrntUsers[s.FocusedRow])[s.FocusedKey]
+	if c == nil {
+		c = &common.Claim{}
+	}
+
+	return c, nil
+}
+
+// ToggleClaim grants the focused permission of the users table through the claim chooser, or
+// revokes it if it's granted, like clicking its checkbox.
+func ToggleClaim(s *global.Session) error {
+	c, err := focusedClaim(s)
+	if err != nil || locked(s.FocusedKey) {
+		return err
+	}
+
+	if c.IsZero() {
+		activatePopup(s.FocusedRow, s.FocusedKey, common.Claim{Checked: true})
+		return nil
+	}
+
+	onActionChange(s, s.FocusedRow, s.FocusedKey, common.Claim{})
+	showViolations(s, s.FocusedRow)
+	return nil
+}
+
+// OpenDetail pops up the claim chooser of the focused permission of the users table.
+func OpenDetail(s *global.Session) error {
+	c, err := focusedClaim(s)
+	if err != nil {
+		return err
+	}
+
+	activatePopup(s.FocusedRow, s.FocusedKey, *c)
+	return nil
 }
 
 // newText returns a centered gui element with the given text.
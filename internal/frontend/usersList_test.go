@@ -1,14 +1,17 @@
 package frontend
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/global"
 	"github.com/vendelin8/firemage/internal/log"
 	"github.com/vendelin8/firemage/internal/mock"
+	"github.com/vendelin8/tview"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 )
@@ -26,7 +29,7 @@ func claimsMapFromAny(data map[string]any) common.ClaimsMap {
 		// Handle time.Time values by converting to formatted string first
 		var claimValue any = value
 		if t, ok := value.(time.Time); ok {
-			claimValue = t.Format(common.DateFormat)
+			claimValue = t.Format(common.StoreDateFormat)
 		}
 		if c, err := common.NewClaimFrom(claimValue); err == nil {
 			cm[key] = c
@@ -37,9 +40,10 @@ func claimsMapFromAny(data map[string]any) common.ClaimsMap {
 
 // TestOnActionChange is a comprehensive table-driven test for the onActionChange function
 func TestOnActionChange(t *testing.T) {
-	date1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
-	date2 := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
-	date3 := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	s := global.NewSession()
+	date1 := time.Date(2025, 1, 1, 23, 59, 59, 0, time.UTC)
+	date2 := time.Date(2025, 6, 15, 23, 59, 59, 0, time.UTC)
+	date3 := time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)
 
 	tests := []struct {
 		name            string
@@ -167,8 +171,8 @@ func TestOnActionChange(t *testing.T) {
 			common.Fe = mockFe
 
 			uid := "user1"
-			global.CrntUsers = []string{uid}
-			global.LocalUsers = map[string]*global.User{
+			s.CrntUsers = []string{uid}
+			s.LocalUsers = map[string]*global.User{
 				uid: {
 					UID:    uid,
 					Email:  "user@test.com",
@@ -176,7 +180,7 @@ func TestOnActionChange(t *testing.T) {
 					Claims: claimsMapFromAny(tt.currentClaims),
 				},
 			}
-			global.Actions = map[string]common.ClaimsMap{
+			s.Actions = map[string]common.ClaimsMap{
 				uid: claimsMapFromAny(tt.initialActions),
 			}
 
@@ -186,14 +190,174 @@ func TestOnActionChange(t *testing.T) {
 				Date:    tt.datePtr,
 			}
 
-			onActionChange(0, tt.key, claim)
+			onActionChange(s, 0, tt.key, claim)
 
 			// Convert expected result for comparison
 			var expectedActions common.ClaimsMap
 			if tt.wantActions != nil {
 				expectedActions = claimsMapFromAny(tt.wantActions)
 			}
-			assert.Equal(t, expectedActions, global.Actions[uid])
+			assert.Equal(t, expectedActions, s.Actions[uid])
 		})
 	}
 }
+
+func TestMoveRow(t *testing.T) {
+	s := global.NewSession()
+
+	tests := []struct {
+		name       string
+		users      []string
+		focusedRow int
+		focusedKey string
+		delta      int
+		wantRow    int
+		wantKey    string
+		wantErr    error
+	}{
+		{name: "no users", focusedRow: -1, delta: 1, wantErr: ErrNoRow},
+		{name: "next row", users: []string{"uid1", "uid2"}, focusedRow: 0, focusedKey: common.Admin, delta: 1,
+			wantRow: 1, wantKey: common.Admin},
+		{name: "stays on the last row", users: []string{"uid1", "uid2"}, focusedRow: 1,
+			focusedKey: common.Admin, delta: 1, wantRow: 1, wantKey: common.Admin},
+		{name: "first row without focus", users: []string{"uid1", "uid2"}, focusedRow: -1, delta: 1,
+			wantRow: 0, wantKey: common.AllPerms[0]},
+		{name: "last row without focus", users: []string{"uid1", "uid2"}, focusedRow: -1, delta: -1,
+			wantRow: 1, wantKey: common.AllPerms[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockFe := mock.NewMockFeIf(ctrl)
+			common.Fe = mockFe
+			s.CrntUsers = tt.users
+			s.FocusedRow, s.FocusedKey = tt.focusedRow, tt.focusedKey
+
+			if tt.wantErr == nil {
+				mockFe.EXPECT().FocusCell(tt.wantRow, tt.wantKey).Times(1)
+			}
+
+			assert.Equal(t, tt.wantErr, MoveRow(s, tt.delta))
+		})
+	}
+}
+
+func TestToggleClaim(t *testing.T) {
+	s := global.NewSession()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFe := mock.NewMockFeIf(ctrl)
+	common.Fe = mockFe
+
+	s.CrntUsers = []string{"uid1"}
+	s.LocalUsers["uid1"] = &global.User{UID: "uid1", Claims: common.ClaimsMap{common.Admin: {Checked: true}}}
+
+	s.FocusedRow, s.FocusedKey = -1, ""
+	assert.Equal(t, ErrNoRow, ToggleClaim(s))
+
+	s.FocusedRow, s.FocusedKey = 0, common.Admin
+	mockFe.EXPECT().ReplaceTableItem(0, common.Admin, gomock.Any()).Times(1)
+	mockFe.EXPECT().ShowMsg().AnyTimes()
+	assert.NoError(t, ToggleClaim(s))
+	assert.Equal(t, false, s.Actions["uid1"][common.Admin].Checked, "granted claim is revoked")
+}
+
+// newUsersFrontend returns a frontend showing the given number of users in a table of the given
+// number of rows.
+func newUsersFrontend(t *testing.T, users, rows int) *Frontend {
+	t.Helper()
+
+	s := global.NewSession()
+	for i := range users {
+		uid := fmt.Sprintf("uid%d", i)
+		s.CrntUsers = append(s.CrntUsers, uid)
+		claims := common.ClaimsMap{}
+		for _, perm := range common.AllPerms {
+			claims[perm] = &common.Claim{}
+		}
+		s.LocalUsers[uid] = &global.User{UID: uid, Email: uid + "@example.com", Claims: claims}
+	}
+
+	f := &Frontend{s: s, app: tview.NewApplication(), menu: tview.NewTextView(), userTbl: newUsersTable(),
+		onShowPage: map[string]func(){"": func() {}}}
+	f.initUsersList()
+	f.userTbl.rows = rows
+	common.Fe = f
+	f.LayoutUsers()
+	return f
+}
+
+func TestLayoutUsersVirtualized(t *testing.T) {
+	f := newUsersFrontend(t, 50, 10)
+	assert.Len(t, f.userCells, 50)
+	assert.NotNil(t, f.userCells[9])
+	assert.Nil(t, f.userCells[10], "rows not fitting aren't built")
+	assert.Equal(t, "rows 1-10 of 50", f.userTbl.status.GetText(true))
+
+	f.scrollUsers(45)
+	assert.Equal(t, 40, f.userTbl.top, "scrolled to the last page at most")
+	assert.Nil(t, f.userCells[0], "rows scrolled out are dropped")
+	assert.NotNil(t, f.userCells[49])
+	assert.Equal(t, "rows 41-50 of 50", f.userTbl.status.GetText(true))
+
+	f.ReplaceTableItem(0, common.AllPerms[0], tview.NewBox())
+	assert.Nil(t, f.userCells[0], "rows not shown aren't replaced")
+
+	f.s.CrntUsers = f.s.CrntUsers[:3]
+	f.LayoutUsers()
+	assert.Equal(t, 0, f.userTbl.top)
+	assert.Equal(t, "rows 1-3 of 3", f.userTbl.status.GetText(true))
+
+	f.s.CrntUsers = nil
+	f.LayoutUsers()
+	assert.Empty(t, f.userTbl.status.GetText(true))
+}
+
+func TestFocusCellScrolls(t *testing.T) {
+	f := newUsersFrontend(t, 50, 10)
+	f.FocusCell(30, common.Admin)
+	assert.Equal(t, 21, f.userTbl.top, "scrolled down to show the row at the bottom")
+	assert.Equal(t, 30, f.s.FocusedRow)
+
+	f.FocusCell(5, common.Admin)
+	assert.Equal(t, 5, f.userTbl.top, "scrolled up to show the row at the top")
+	assert.Equal(t, 5, f.s.FocusedRow)
+}
+
+func TestUsersKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		focused int
+		key     tcell.Key
+		wantRow int
+		wantTop int
+	}{
+		{name: "page down", focused: 2, key: tcell.KeyPgDn, wantRow: 12, wantTop: 3},
+		{name: "page up", focused: 2, key: tcell.KeyPgUp, wantRow: 0, wantTop: 0},
+		{name: "end", focused: 2, key: tcell.KeyEnd, wantRow: 49, wantTop: 40},
+		{name: "home", focused: 45, key: tcell.KeyHome, wantRow: 0, wantTop: 0},
+		{name: "page down without focus", focused: -1, key: tcell.KeyPgDn, wantRow: -1, wantTop: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newUsersFrontend(t, 50, 10)
+			if tt.focused >= 0 {
+				f.FocusCell(tt.focused, common.Admin)
+			}
+
+			assert.Nil(t, f.usersKeys(tcell.NewEventKey(tt.key, 0, tcell.ModNone)))
+			assert.Equal(t, tt.wantRow, f.s.FocusedRow)
+			assert.Equal(t, tt.wantTop, f.userTbl.top)
+		})
+	}
+
+	f := newUsersFrontend(t, 50, 10)
+	ev := tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone)
+	assert.Equal(t, ev, f.usersKeys(ev), "other keys pass")
+}
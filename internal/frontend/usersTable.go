@@ -0,0 +1,58 @@
+package frontend
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/vendelin8/tview"
+)
+
+const (
+	defaultUserRows = 20 // rows of the users table before it's first drawn
+	wheelRows       = 3  // rows scrolled by a mouse wheel step
+)
+
+// usersTable is the scrollable users table. Only the rows fitting in its height are built, below a
+// sticky header, and a status line shows which ones they are.
+type usersTable struct {
+	*tview.Flex
+	grid   *tview.Grid
+	status *tview.TextView
+	// top is the index of the first user shown, rows the number of users fitting in the table.
+	top, rows int
+	// onResize is called when the number of fitting rows changes on drawing.
+	onResize func()
+}
+
+// newUsersTable returns an empty users table, the header and the rows are added to its grid.
+func newUsersTable() *usersTable {
+	t := &usersTable{
+		Flex:   tview.NewFlex().SetDirection(tview.FlexRow),
+		grid:   tview.NewGrid(),
+		status: tview.NewTextView().SetTextAlign(tview.AlignRight),
+		rows:   defaultUserRows,
+	}
+	t.AddItem(t.grid, 1, 0, true).AddItem(tview.NewBox(), 0, 1, false).AddItem(t.status, 1, 0, false)
+	return t
+}
+
+// Draw updates the number of fitting rows to the height, then draws the table.
+func (t *usersTable) Draw(screen tcell.Screen) {
+	_, _, _, height := t.GetInnerRect()
+	if rows := max(height-2, 1); rows != t.rows { // header and status line
+		t.rows = rows
+		if t.onResize != nil {
+			t.onResize()
+		}
+	}
+
+	t.Flex.Draw(screen)
+}
+
+// shown checks if the i-th user has a row built in the table.
+func (t *usersTable) shown(i int) bool {
+	return i >= t.top && i < t.top+t.rows
+}
+
+// row returns the grid row of the i-th user, below the header.
+func (t *usersTable) row(i int) int {
+	return i - t.top + 1
+}
@@ -2,9 +2,12 @@ package window
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/lang"
 	"github.com/vendelin8/firemage/internal/log"
 )
 
@@ -15,8 +18,10 @@ const (
 )
 
 var (
-	// b is a global buffer for creating error messages. Because the app is single threaded, all
-	// functionality is sequential, it's safe this way. After every operation it is checked.
+	// bMu guards the buffers below, background goroutines may write them too.
+	bMu sync.Mutex
+
+	// b is a global buffer for creating error messages. After every operation it is checked.
 	// If there's something to show, show it in a modal popup, and clear the buffer.
 	b = &strings.Builder{}
 
@@ -35,6 +40,9 @@ func AppendListError(msg string, inputs []string, msgs ...string) bool {
 		return false
 	}
 
+	bMu.Lock()
+	defer bMu.Unlock()
+
 	optionalNewline()
 
 	for i, input := range inputs {
@@ -61,6 +69,9 @@ func doGetError() string {
 }
 
 func GetError() error {
+	bMu.Lock()
+	defer bMu.Unlock()
+
 	if b.Len() == 0 {
 		return nil
 	}
@@ -85,6 +96,13 @@ func GetError() error {
 
 // GetErrorStr returns current error message if the buffer is not empty as a string.
 func GetErrorStr() string {
+	bMu.Lock()
+	defer bMu.Unlock()
+
+	return getErrorStr()
+}
+
+func getErrorStr() string {
 	// Early return in case of no error
 	if b.Len() == 0 {
 		return ""
@@ -109,6 +127,9 @@ func UseWarn() {
 
 // pushBuffer sets buffer to use.
 func pushBuffer(id int) {
+	bMu.Lock()
+	defer bMu.Unlock()
+
 	if bStack[len(bStack)-1] == id {
 		return
 	}
@@ -127,7 +148,10 @@ func pushBuffer(id int) {
 
 // PopBuffer returns content of the current buffer, and goes back to the previously used one.
 func PopBuffer() string {
-	result := GetErrorStr()
+	bMu.Lock()
+	defer bMu.Unlock()
+
+	result := getErrorStr()
 
 	bStack = bStack[:len(bStack)-1]
 	b = bs[bStack[len(bStack)-1]]
@@ -137,20 +161,46 @@ func PopBuffer() string {
 
 // WriteErrorStr adds a new error line to the error buffer.
 func WriteErrorStr(msg string) {
+	bMu.Lock()
+	defer bMu.Unlock()
+
+	writeErrorStr(msg)
+}
+
+func writeErrorStr(msg string) {
 	optionalNewline()
 	b.WriteString(msg)
 }
 
+// WriteViolations adds the broken permission rules of a user to the error buffer.
+func WriteViolations(email string, vs []string) bool {
+	if len(vs) == 0 {
+		return false
+	}
+
+	bMu.Lock()
+	defer bMu.Unlock()
+
+	writeErrorStr(fmt.Sprintf(lang.WarnRulesS, email))
+	for _, v := range vs {
+		b.WriteString("\n- ")
+		b.WriteString(v)
+	}
+
+	return true
+}
+
 // ShowErrorBuffer displays any buffered errors in a popup and clears the buffer.
 // Call this at the end of any functionality that uses WriteErrorStr to ensure the buffer is cleared.
 func ShowErrorBuffer(err error) {
-	if err == nil && b.Len() == 0 {
-		return
-	}
-
+	bMu.Lock()
 	if err != nil {
-		WriteErrorStr(err.Error())
+		writeErrorStr(err.Error())
 	}
+	msg := getErrorStr()
+	bMu.Unlock()
 
-	common.Fe.ShowMsg(GetErrorStr())
+	if len(msg) > 0 {
+		common.Fe.ShowMsg(msg)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"sync"
 
 	"github.com/vendelin8/firemage/internal/common"
 	"github.com/vendelin8/firemage/internal/global"
@@ -12,7 +13,11 @@ import (
 	"go.uber.org/zap"
 )
 
-var ActivePopups []string
+var (
+	// popupsMu guards activePopups, popups may be shown or hidden from background goroutines too.
+	popupsMu     sync.Mutex
+	activePopups []string
+)
 
 // ShowWarningOnce shows a warning if it wasn't shown yet in this session.
 func ShowWarningOnce(w int) {
@@ -25,8 +30,38 @@ func ShowWarningOnce(w int) {
 }
 
 func PushPopup(popup string) {
-	ActivePopups = append(ActivePopups, popup)
-	log.Lgr.Debug("PushPopup end", zap.String("popup", popup), zap.Strings("ActivePopups", ActivePopups))
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	activePopups = append(activePopups, popup)
+	log.Lgr.Debug("PushPopup end", zap.String("popup", popup), zap.Strings("activePopups", activePopups))
+}
+
+// Popups returns a copy of the active popup windows, the last one is on the top.
+func Popups() []string {
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	return slices.Clone(activePopups)
+}
+
+// SetPopups replaces the active popup windows without showing or hiding anything.
+func SetPopups(popups ...string) {
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	activePopups = slices.Clone(popups)
+}
+
+// TopPopup returns the popup window on the top, or an empty string if there's none.
+func TopPopup() string {
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	if len(activePopups) == 0 {
+		return ""
+	}
+	return activePopups[len(activePopups)-1]
 }
 
 // ShowConfirm shows a confirm dialog with a text, and callback functions for OK and Cancel.
@@ -65,27 +100,31 @@ func ConfirmDoneFunc(okFunc, cancelFunc func()) func(int, string) {
 // HidePopup hides the current popup window.
 func HidePopup(popup string) {
 	common.Fe.HidePopup(popup)
-	for i, p := range ActivePopups {
-		if popup == p {
-			ActivePopups = slices.Delete(ActivePopups, i, i+1)
-			break
-		}
+
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	if i := slices.Index(activePopups, popup); i >= 0 {
+		activePopups = slices.Delete(activePopups, i, i+1)
 	}
-	log.Lgr.Debug("HidePopup end", zap.String("popup", popup), zap.Strings("ActivePopups", ActivePopups))
+	log.Lgr.Debug("HidePopup end", zap.String("popup", popup), zap.Strings("activePopups", activePopups))
 }
 
 // HasPopup returns if there's an active popup window.
 func HasPopup() bool {
-	return len(ActivePopups) > 0
+	popupsMu.Lock()
+	defer popupsMu.Unlock()
+
+	return len(activePopups) > 0
 }
 
 // Quit exists the application.
-func Quit() error {
-	if len(global.Actions) == 0 {
+func Quit(s *global.Session) error {
+	if len(s.Actions) == 0 {
 		common.Fe.Quit()
 		return nil
 	}
 
-	common.Fe.ShowConfirm(func() { common.Fe.Quit() }, nil, fmt.Sprintf(lang.WarnUnsaved, len(global.Actions)))
+	common.Fe.ShowConfirm(func() { common.Fe.Quit() }, nil, fmt.Sprintf(lang.WarnUnsaved, len(s.Actions)))
 	return nil
 }
@@ -1,6 +1,7 @@
 package window
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,11 +34,11 @@ func TestHasPopup(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.activePopup != "" {
-				ActivePopups = append(ActivePopups, tt.activePopup)
+				activePopups = append(activePopups, tt.activePopup)
 			}
 			result := HasPopup()
 			assert.Equal(t, tt.want, result)
-			ActivePopups = []string{}
+			activePopups = []string{}
 		})
 	}
 }
@@ -55,8 +56,8 @@ func TestShowMsg(t *testing.T) {
 
 		ShowWarn(msgText)
 
-		assert.Contains(t, ActivePopups, lang.PopupWarn)
-		ActivePopups = []string{}
+		assert.Contains(t, activePopups, lang.PopupWarn)
+		activePopups = []string{}
 	})
 
 	t.Run("shows empty message", func(t *testing.T) {
@@ -70,8 +71,8 @@ func TestShowMsg(t *testing.T) {
 
 		ShowWarn()
 
-		assert.Contains(t, ActivePopups, lang.PopupWarn)
-		ActivePopups = []string{}
+		assert.Contains(t, activePopups, lang.PopupWarn)
+		activePopups = []string{}
 	})
 }
 
@@ -127,7 +128,7 @@ func TestShowWarningOnce(t *testing.T) {
 
 			// Reset
 			lang.Warns = make(map[int]string)
-			ActivePopups = []string{}
+			activePopups = []string{}
 		})
 	}
 }
@@ -148,8 +149,8 @@ func TestShowConfirm(t *testing.T) {
 
 		ShowConfirm(okFunc, cancelFunc, msgText)
 
-		assert.Contains(t, ActivePopups, lang.PopupConfirm)
-		ActivePopups = []string{}
+		assert.Contains(t, activePopups, lang.PopupConfirm)
+		activePopups = []string{}
 	})
 
 	t.Run("shows confirm with nil cancel function", func(t *testing.T) {
@@ -166,8 +167,8 @@ func TestShowConfirm(t *testing.T) {
 
 		ShowConfirm(okFunc, nil, msgText)
 
-		assert.Contains(t, ActivePopups, lang.PopupConfirm)
-		ActivePopups = []string{}
+		assert.Contains(t, activePopups, lang.PopupConfirm)
+		activePopups = []string{}
 	})
 }
 
@@ -222,13 +223,13 @@ func TestConfirmDoneFunc(t *testing.T) {
 
 			mockFe.EXPECT().HidePopup(lang.PopupConfirm).Times(1)
 
-			ActivePopups = []string{lang.PopupConfirm}
+			activePopups = []string{lang.PopupConfirm}
 			doneFunc := ConfirmDoneFunc(okFunc, cancelFunc)
 			doneFunc(0, tt.buttonLabel)
 
 			assert.Equal(t, tt.okCalled, okCalled, tt.description)
 			assert.Equal(t, tt.cancelCalled, cancelCalled, tt.description)
-			assert.NotContains(t, ActivePopups, lang.PopupConfirm, "popup should be hidden")
+			assert.NotContains(t, activePopups, lang.PopupConfirm, "popup should be hidden")
 		})
 	}
 }
@@ -248,12 +249,12 @@ func TestConfirmDoneFuncWithoutCancelFunc(t *testing.T) {
 
 		mockFe.EXPECT().HidePopup(lang.PopupConfirm).Times(1)
 
-		ActivePopups = []string{lang.PopupConfirm}
+		activePopups = []string{lang.PopupConfirm}
 		doneFunc := ConfirmDoneFunc(okFunc, nil)
 		doneFunc(0, lang.SNo)
 
 		assert.False(t, okCalled, "ok function should not be called")
-		assert.NotContains(t, ActivePopups, lang.PopupConfirm, "popup should be hidden")
+		assert.NotContains(t, activePopups, lang.PopupConfirm, "popup should be hidden")
 	})
 }
 
@@ -265,12 +266,12 @@ func TestHidePopup(t *testing.T) {
 		mockFe := mock.NewMockFeIf(ctrl)
 		common.Fe = mockFe
 
-		ActivePopups = []string{lang.PopupMsg}
+		activePopups = []string{lang.PopupMsg}
 		mockFe.EXPECT().HidePopup(lang.PopupMsg).Times(1)
 
 		HidePopup(lang.PopupMsg)
 
-		assert.NotContains(t, ActivePopups, lang.PopupMsg, "popup should be removed from active popups")
+		assert.NotContains(t, activePopups, lang.PopupMsg, "popup should be removed from active popups")
 	})
 
 	t.Run("hides popup when already empty", func(t *testing.T) {
@@ -280,16 +281,43 @@ func TestHidePopup(t *testing.T) {
 		mockFe := mock.NewMockFeIf(ctrl)
 		common.Fe = mockFe
 
-		ActivePopups = []string{}
+		activePopups = []string{}
 		mockFe.EXPECT().HidePopup(lang.PopupMsg).Times(1)
 
 		HidePopup(lang.PopupMsg)
 
-		assert.Empty(t, ActivePopups)
+		assert.Empty(t, activePopups)
+	})
+
+	t.Run("hides popups from other goroutines", func(t *testing.T) {
+		cleanup := testutil.InitLog()
+		defer cleanup()
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFe := mock.NewMockFeIf(ctrl)
+		common.Fe = mockFe
+
+		popups := []string{lang.PopupMsg, lang.PopupConfirm, lang.PopupProgress, lang.PopupWarn}
+		SetPopups(popups...)
+		mockFe.EXPECT().HidePopup(gomock.Any()).Times(len(popups))
+
+		var wg sync.WaitGroup
+		for _, p := range popups {
+			wg.Go(func() {
+				assert.NotEmpty(t, TopPopup())
+				HidePopup(p)
+			})
+		}
+		wg.Wait()
+
+		assert.False(t, HasPopup())
 	})
 }
 
 func TestQuit(t *testing.T) {
+	s := global.NewSession()
 	defer testutil.InitLog()
 
 	tests := []struct {
@@ -318,7 +346,7 @@ func TestQuit(t *testing.T) {
 			mockFe := mock.NewMockFeIf(ctrl)
 			common.Fe = mockFe
 
-			global.Actions = testutil.BuildActionsMap(tt.setupActions)
+			s.Actions = testutil.BuildActionsMap(tt.setupActions)
 
 			if len(tt.setupActions) == 0 {
 				mockFe.EXPECT().Quit().Times(1)
@@ -326,11 +354,10 @@ func TestQuit(t *testing.T) {
 				mockFe.EXPECT().ShowConfirm(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
 			}
 
-			err := Quit()
+			err := Quit(s)
 			assert.NoError(t, err)
 
-			global.Actions = map[string]common.ClaimsMap{}
-			ActivePopups = []string{}
+			activePopups = []string{}
 		})
 	}
 }
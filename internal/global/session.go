@@ -0,0 +1,163 @@
+package global
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/vendelin8/firemage/internal/common"
+)
+
+// Session is the state of the running app. It's owned by the GUI goroutine, background work may
+// access it only through Sync.
+type Session struct {
+	mu   sync.Mutex // guards sync only, the GUI goroutine serializes the work
+	sync func(func())
+	// inline serializes Sync calls without a GUI goroutine.
+	inline sync.Mutex
+
+	LocalUsers map[string]*User // downloaded users
+	CrntUsers  []string         // currently visible users
+
+	// LocalPrivileged is the downloaded privileged users.
+	LocalPrivileged map[string]struct{}
+
+	// Actions contains pending permission updates to be saved. key is the uid, value is a map of permission
+	// key and a value. True means adding the permission, false means removing it, date means expiry.
+	Actions map[string]common.ClaimsMap
+
+	// SavedUsers contains user id lists for all pages.
+	SavedUsers map[string][]string
+
+	// RemoteChanges contains the privileged users added (true) or removed (false) by others since
+	// loading, as seen by watch mode. They're cleared by a reload.
+	RemoteChanges map[string]bool
+
+	// RemoteEdits contains the users whose claims were saved by others since loading, as seen by
+	// watch mode. They're cleared by a reload.
+	RemoteEdits map[string]struct{}
+
+	// Pending contains the change sets waiting for approval, oldest first.
+	Pending []*common.PendingChange
+
+	// SeenRevisions are the revisions of the claimChanges document at its last snapshot in watch
+	// mode, nil before the first one.
+	SeenRevisions map[string]any
+
+	// FocusedRow is the index of the users table row having the focus, or -1. FocusedKey is the
+	// permission of the focused cell, empty for the name columns.
+	FocusedRow int
+	FocusedKey string
+
+	// Selected has the uids of the users selected in the users table for actions on several users.
+	Selected map[string]struct{}
+
+	// Violations has the broken permission rules of users by uid, updated on every claim change.
+	Violations map[string][]string
+
+	// busy is set while a background job runs, to refuse starting another one. It's shared with the
+	// copies, they can't run a job in parallel either.
+	busy *bool
+}
+
+func NewSession() *Session {
+	return &Session{
+		LocalUsers:      map[string]*User{},
+		LocalPrivileged: map[string]struct{}{},
+		Actions:         map[string]common.ClaimsMap{},
+		SavedUsers:      map[string][]string{},
+		RemoteChanges:   map[string]bool{},
+		RemoteEdits:     map[string]struct{}{},
+		FocusedRow:      -1,
+		Selected:        map[string]struct{}{},
+		Violations:      map[string][]string{},
+		busy:            new(bool),
+	}
+}
+
+// Clone returns a copy of the session to work on without changing this one, eg. in dry-run mode.
+// Background goroutines of the copy are synced the same way.
+func (s *Session) Clone() *Session {
+	c := &Session{
+		sync:            s.syncFunc(),
+		LocalUsers:      make(map[string]*User, len(s.LocalUsers)),
+		CrntUsers:       slices.Clone(s.CrntUsers),
+		LocalPrivileged: maps.Clone(s.LocalPrivileged),
+		Actions:         make(map[string]common.ClaimsMap, len(s.Actions)),
+		SavedUsers:      make(map[string][]string, len(s.SavedUsers)),
+		RemoteChanges:   maps.Clone(s.RemoteChanges),
+		RemoteEdits:     maps.Clone(s.RemoteEdits),
+		Pending:         slices.Clone(s.Pending),
+		SeenRevisions:   maps.Clone(s.SeenRevisions),
+		FocusedRow:      s.FocusedRow,
+		FocusedKey:      s.FocusedKey,
+		Selected:        maps.Clone(s.Selected),
+		Violations:      maps.Clone(s.Violations),
+		busy:            s.busy,
+	}
+
+	for uid, u := range s.LocalUsers {
+		cu := *u
+		cu.Claims = maps.Clone(u.Claims)
+		c.LocalUsers[uid] = &cu
+	}
+
+	for uid, acts := range s.Actions {
+		c.Actions[uid] = maps.Clone(acts)
+	}
+
+	for page, uids := range s.SavedUsers {
+		c.SavedUsers[page] = slices.Clone(uids)
+	}
+
+	return c
+}
+
+// StartJob marks a background job running, unless another one runs already.
+func (s *Session) StartJob() bool {
+	if *s.busy {
+		return false
+	}
+
+	*s.busy = true
+	return true
+}
+
+// EndJob allows starting another background job.
+func (s *Session) EndJob() {
+	*s.busy = false
+}
+
+// Busy checks if a background job runs.
+func (s *Session) Busy() bool {
+	return *s.busy
+}
+
+// SetSync routes Sync calls through f, eg. to run them on the GUI goroutine.
+func (s *Session) SetSync(f func(func())) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sync = f
+}
+
+// syncFunc returns the function Sync calls are routed through.
+func (s *Session) syncFunc() func(func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sync
+}
+
+// Sync runs f with exclusive access to the session, waiting for it to finish. It's for background
+// goroutines only, calling it on the GUI goroutine would block forever. Without a GUI goroutine f
+// runs right away, one at a time.
+func (s *Session) Sync(f func()) {
+	sync := s.syncFunc()
+	if sync == nil {
+		s.inline.Lock()
+		defer s.inline.Unlock()
+		f()
+		return
+	}
+	sync(f)
+}
@@ -0,0 +1,105 @@
+package global
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vendelin8/firemage/internal/common"
+)
+
+func TestSync(t *testing.T) {
+	t.Run("runs inline without sync func", func(t *testing.T) {
+		s := NewSession()
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				s.Sync(func() { s.CrntUsers = append(s.CrntUsers, string(rune('a'+i))) })
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, s.CrntUsers, 10)
+	})
+
+	t.Run("routes through sync func", func(t *testing.T) {
+		s := NewSession()
+		ui := make(chan func())
+		s.SetSync(func(f func()) {
+			done := make(chan struct{})
+			ui <- func() {
+				f()
+				close(done)
+			}
+			<-done
+		})
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Go(func() {
+				s.Sync(func() { s.LocalPrivileged[string(rune('a'+i))] = struct{}{} })
+			})
+		}
+
+		for range 10 {
+			(<-ui)()
+		}
+		wg.Wait()
+
+		assert.Len(t, s.LocalPrivileged, 10)
+	})
+
+	t.Run("GUI goroutine may clone while a sync waits", func(t *testing.T) {
+		s := NewSession()
+		ui, entered := make(chan func()), make(chan struct{})
+		s.SetSync(func(f func()) {
+			close(entered)
+			done := make(chan struct{})
+			ui <- func() {
+				f()
+				close(done)
+			}
+			<-done
+		})
+
+		go s.Sync(func() { s.CrntUsers = []string{"uid1"} })
+
+		<-entered
+		c := s.Clone() // would deadlock if Sync held the lock while waiting
+		(<-ui)()
+		assert.Empty(t, c.CrntUsers)
+		assert.Equal(t, []string{"uid1"}, s.CrntUsers)
+	})
+}
+
+func TestClone(t *testing.T) {
+	s := NewSession()
+	s.LocalUsers = map[string]*User{"uid1": {UID: "uid1", Claims: common.ClaimsMap{common.Admin: {Checked: true}}}}
+	s.Actions = map[string]common.ClaimsMap{"uid1": {common.Admin: {}}}
+	s.LocalPrivileged["uid1"] = struct{}{}
+
+	c := s.Clone()
+	c.LocalUsers["uid1"].Claims[common.Admin] = &common.Claim{}
+	delete(c.Actions["uid1"], common.Admin)
+	delete(c.LocalPrivileged, "uid1")
+
+	assert.True(t, s.LocalUsers["uid1"].Claims[common.Admin].Checked)
+	assert.Contains(t, s.Actions["uid1"], common.Admin)
+	assert.Contains(t, s.LocalPrivileged, "uid1")
+}
+
+func TestJob(t *testing.T) {
+	s := NewSession()
+	c := s.Clone()
+
+	assert.True(t, s.StartJob())
+	assert.False(t, s.StartJob(), "another job runs")
+	assert.False(t, c.StartJob(), "copies share the running job")
+	assert.True(t, c.Busy())
+
+	s.EndJob()
+	assert.False(t, c.Busy())
+	assert.True(t, c.StartJob())
+}
@@ -0,0 +1,146 @@
+package lang
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vendelin8/firemage/i18n"
+)
+
+// Default is the language used when none is chosen, and for the strings missing from the others.
+const Default = "en"
+
+// Current is the code of the loaded language.
+var Current string
+
+func init() {
+	if err := Load(Default); err != nil {
+		panic(err)
+	}
+}
+
+// Languages returns the codes of the supported languages.
+func Languages() []string {
+	dirs, _ := fs.Glob(i18n.Catalogs, "*/lang.yml")
+	codes := make([]string, len(dirs))
+	for i, dir := range dirs {
+		codes[i] = path.Dir(dir)
+	}
+
+	return codes
+}
+
+// catalog reads the message catalog of the given language.
+func catalog(code string) (map[string]string, error) {
+	data, err := i18n.Catalogs.ReadFile(path.Join(code, "lang.yml"))
+	if err != nil {
+		return nil, fmt.Errorf(ErrLang, code, strings.Join(Languages(), ", "))
+	}
+
+	var c map[string]string
+	if err = yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s catalog: %w", code, err)
+	}
+
+	return c, nil
+}
+
+// Load sets the strings of the given language. The ones missing from its catalog stay English.
+func Load(code string) error {
+	c, err := catalog(Default)
+	if err != nil {
+		return err
+	}
+
+	if code != Default {
+		lc, err := catalog(code)
+		if err != nil {
+			return err
+		}
+		maps.Copy(c, lc)
+	}
+
+	for key, s := range strs {
+		*s = c[key]
+	}
+
+	Titles = map[string]string{PageSearch: TitleSearch, PageList: TitleList, PageApprovals: TitleApprovals,
+		PageExpiring: TitleExpiring}
+	Warns = map[int]string{WarnSearchAgain: WarnSearchAgainS, WarnActionInList: WarnActionInListS}
+	Current = code
+	return nil
+}
+
+// FromEnv returns the supported language of the locale set by LC_ALL, LC_MESSAGES or LANG, like
+// "hu" for "hu_HU.UTF-8", or Default if it's not set or not supported.
+func FromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if len(locale) == 0 {
+			continue
+		}
+
+		code, _, _ := strings.Cut(locale, "_")
+		code, _, _ = strings.Cut(code, ".")
+		if slices.Contains(Languages(), code) {
+			return code
+		}
+
+		return Default
+	}
+
+	return Default
+}
+
+// Missing returns the keys missing from the message catalog of every supported language, and the
+// keys of the catalogs not used by the app. Languages without problems are left out.
+func Missing() (map[string][]string, error) {
+	missing := map[string][]string{}
+	for _, code := range Languages() {
+		c, err := catalog(code)
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range strs {
+			if _, ok := c[key]; !ok {
+				missing[code] = append(missing[code], key)
+			}
+		}
+		for key := range c {
+			if _, ok := strs[key]; !ok {
+				missing[code] = append(missing[code], fmt.Sprintf("%s (unused)", key))
+			}
+		}
+		slices.Sort(missing[code])
+	}
+
+	return missing, nil
+}
+
+// Error is an error with a message in the current language, following Load even if it's created
+// before. Its args fill the fmt verbs of the message.
+type Error struct {
+	msg  *string
+	args []any
+}
+
+// NewError returns an error with the given string of this package as message.
+func NewError(msg *string, args ...any) error {
+	return &Error{msg: msg, args: args}
+}
+
+func (e *Error) Error() string {
+	if len(e.args) == 0 {
+		return *e.msg
+	}
+
+	return fmt.Sprintf(*e.msg, e.args...)
+}
@@ -0,0 +1,69 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCatalogs reports the keys missing from the message catalogs, run by "task langcheck".
+func TestCatalogs(t *testing.T) {
+	missing, err := Missing()
+	require.NoError(t, err)
+
+	for code, keys := range missing {
+		t.Errorf("%s: %d missing or unused keys: %v", code, len(keys), keys)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer func() { require.NoError(t, Load(Default)) }()
+	errTest := NewError(&ErrReadOnlyS)
+	errArgs := NewError(&ErrMinLen, 3)
+
+	require.NoError(t, Load("hu"))
+	assert.Equal(t, "hu", Current)
+	assert.Equal(t, "Ment", MenuSave)
+	assert.Equal(t, "Lista", Titles[PageList])
+	assert.Equal(t, ErrReadOnlyS, errTest.Error())
+	assert.Equal(t, fmt.Sprintf(ErrMinLen, 3), errArgs.Error())
+	assert.True(t, errors.Is(fmt.Errorf("wrapped: %w", errTest), errTest))
+
+	require.NoError(t, Load(Default))
+	assert.Equal(t, "Save", MenuSave)
+	assert.Equal(t, "refused in read-only mode", errTest.Error())
+
+	err := Load("xx")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "en, hu")
+	assert.Equal(t, Default, Current)
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantCode string
+	}{
+		{name: "nothing set", wantCode: Default},
+		{name: "LANG", env: map[string]string{"LANG": "hu_HU.UTF-8"}, wantCode: "hu"},
+		{name: "LC_MESSAGES over LANG", env: map[string]string{"LC_MESSAGES": "en_US.UTF-8", "LANG": "hu_HU.UTF-8"},
+			wantCode: "en"},
+		{name: "LC_ALL over all", env: map[string]string{"LC_ALL": "hu", "LC_MESSAGES": "en_US"}, wantCode: "hu"},
+		{name: "unsupported", env: map[string]string{"LANG": "de_DE.UTF-8"}, wantCode: Default},
+		{name: "C locale", env: map[string]string{"LANG": "C.UTF-8"}, wantCode: Default},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			assert.Equal(t, tt.wantCode, FromEnv())
+		})
+	}
+}
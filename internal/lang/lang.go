@@ -1 +0,0 @@
-../../i18n/en/lang.txt
\ No newline at end of file
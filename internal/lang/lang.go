@@ -0,0 +1,541 @@
+package lang
+
+// Strings of the current language, set by Load from the message catalog of the same key.
+var (
+	MenuRefresh string
+	MenuSave    string
+	SCancel     string
+	SReset      string
+	MenuQuit    string
+	ShortDesc   string
+	LongDesc    string
+	DescKey     string
+	DescConf    string
+	DescLog     string
+	DescDebug   string
+	DescEmul    string
+	SName       string
+	SEmail      string
+	SSearchThis string
+	SDoSearch   string
+	SYes        string
+	SNo         string
+	SSaved      string
+	SActive     string
+	SInactive   string
+	STimed      string
+	SWorking    string
+	WarnUnsaved string
+
+	ErrSave    string
+	ErrEmpty   string
+	ErrMinLen  string
+	ErrSearch  string
+	ErrRefresh string
+	ErrChanged string
+	ErrRemoved string
+	ErrManualS string
+	ErrTimeFmt string
+
+	ErrTimeUnit   string
+	ErrTimeNum    string
+	ErrTimeHours  string
+	ErrConfPath   string
+	ErrSetPerms   string
+	ErrTimeoutS   string
+	ErrNoUsersS   string
+	ErrActionsS   string
+	ErrEmptyTime  string
+	ErrConfParse  string
+	ConfirmSaveS  string
+	ErrGetFSUsers string
+	ErrNoChangesS string
+
+	WarnMayRefresh   string
+	ErrCmdNotFound   string
+	ErrKeyNotFound   string
+	ErrGetAuthUsers  string
+	ErrConfInvalidS  string
+	ErrCantRefreshS  string
+	ErrWrongDBClaimS string
+	ErrUpdateFSUsers string
+	ErrNewUsrFrmAuth string
+	ErrPermsChangedS string
+	WarnAddedPemsS   string
+	WarnRemovedPemsS string
+	ErrWrongTimeBtns string
+
+	MenuRole        string
+	DescGrant       string
+	DescRole        string
+	SApplyRole      string
+	ErrNoRolesS     string
+	ErrNoRowS       string
+	ErrRoleNotFound string
+	ErrRolePerm     string
+	ErrRoleTime     string
+
+	ErrRuleRequires  string
+	ErrRuleExcludes  string
+	ErrRuleMaxDur    string
+	ErrRulePermanent string
+	ErrRuleInvalid   string
+	ErrRuleTime      string
+	WarnRulesS       string
+	ConfirmRulesS    string
+
+	DescOperator    string
+	SApprove        string
+	SReject         string
+	SProposed       string
+	SApproved       string
+	SRejected       string
+	SNoPending      string
+	SPendingItem    string
+	ErrPropose      string
+	ErrPending      string
+	ErrPendingStale string
+	ErrApprovalPerm string
+	ErrSelfApproveS string
+	ErrNoPendingS   string
+	ErrNoAllowlistS string
+
+	DescWatch      string
+	ErrWatch       string
+	WarnConflictS  string
+	ConfirmReloadS string
+
+	SScanned     string
+	SBatches     string
+	ErrCanceledS string
+	ErrBusyS     string
+
+	DescRefreshTimeout string
+	DescCheckpoint     string
+	SResumed           string
+	SDiscarded         string
+	ErrCheckpoint      string
+
+	DescTimeout   string
+	DescBatchSize string
+	DescRetries   string
+	DescBackoff   string
+	SLastError    string
+	ErrFlagsS     string
+
+	DescJournal       string
+	DescRepair        string
+	ErrJournal        string
+	ErrCacheNotSavedS string
+	ErrUsersFailedS   string
+	SUserStored       string
+	SUserFailed       string
+	SRepaired         string
+	WarnJournalS      string
+	ErrRepairStaleS   string
+
+	DescDryRun     string
+	MenuDryRun     string
+	SDryRunBadge   string
+	SDryRunReport  string
+	SDryRunClaims  string
+	SDryRunSpecs   string
+	SDryRunDelete  string
+	SDryRunAdded   string
+	SDryRunDeleted string
+	SDryRunNothing string
+
+	DescReadOnly   string
+	DescProfile    string
+	SReadOnlyBadge string
+	ErrReadOnlyS   string
+	ErrProfile     string
+
+	ErrOperatorSource string
+	ErrOperatorPerm   string
+	ErrOperatorS      string
+	ErrOperator       string
+	ErrForbidden      string
+	ErrForbiddenS     string
+
+	DescCredentials    string
+	DescImpersonate    string
+	DescProject        string
+	ErrCredentialsS    string
+	ErrCredentials     string
+	ErrCredentialsMode string
+	ErrImpersonate     string
+	ErrFirebaseInit    string
+
+	DescFirestoreEmu string
+	DescAuthEmu      string
+
+	SDryRunUser    string
+	SDryRunProfile string
+
+	DescEmu         string
+	DescSeed        string
+	DescFixtures    string
+	SSeeded         string
+	ErrNotEmulatorS string
+	ErrFixtures     string
+	ErrFixtureUser  string
+	ErrFixturePerm  string
+	ErrFixtureClaim string
+	ErrSeedUser     string
+	ErrSeedSpecs    string
+
+	HintUsage       string
+	HintConfig      string
+	HintCredentials string
+	HintConnection  string
+	HintDenied      string
+	HintPartial     string
+	SError          string
+
+	ErrLogFile string
+
+	TitleSearch       string
+	TitleList         string
+	TitleApprovals    string
+	WarnSearchAgainS  string
+	WarnActionInListS string
+
+	DescLang string
+	ErrLang  string
+
+	DateLayout     string
+	ErrDateFormat  string
+	ErrDateInput   string
+	SMonths        string
+	SWeekdays      string
+	SCalendarTitle string
+
+	SExpired        string
+	SRemainingDays  string
+	SRemainingHours string
+	SRemainingLess  string
+	ErrTimezone     string
+
+	DescFor string
+
+	TitleExpiring string
+	SGroupExpired string
+	SGroupWeek    string
+	SGroupMonth   string
+	SGroupLater   string
+	SNoExpiring   string
+	SExtendHint   string
+	DescExpiring  string
+	DescWithin    string
+	DescJSON      string
+
+	SExpires    string
+	SLeft       string
+	SPermission string
+
+	MenuSelect   string
+	MenuExtend   string
+	SExtendWhich string
+	SExtendBy    string
+	SExtendAllBy string
+	SAllTimed    string
+	SUsersN      string
+	ErrNoTimedS  string
+
+	ActFocusSearch string
+	ActNextRow     string
+	ActPrevRow     string
+	ActToggleClaim string
+	ActOpenDetail  string
+	SAtLine        string
+	ErrKeyConflict string
+	ErrKeyDefault  string
+	ErrKeyScope    string
+
+	MenuPalette    string
+	SPaletteHint   string
+	ActExport      string
+	SExported      string
+	ErrExport      string
+	ConfirmExportS string
+
+	ErrTheme      string
+	ErrThemeColor string
+	ErrThemeValue string
+
+	SRowsOf string
+)
+
+var (
+	// Titles are the titles of the pages, Warns the warnings shown once, by their identifiers.
+	Titles map[string]string
+	Warns  map[int]string
+)
+
+// strs maps the keys of the message catalogs to the strings they set.
+var strs = map[string]*string{
+	"MenuRefresh": &MenuRefresh,
+	"MenuSave":    &MenuSave,
+	"SCancel":     &SCancel,
+	"SReset":      &SReset,
+	"MenuQuit":    &MenuQuit,
+	"ShortDesc":   &ShortDesc,
+	"LongDesc":    &LongDesc,
+	"DescKey":     &DescKey,
+	"DescConf":    &DescConf,
+	"DescLog":     &DescLog,
+	"DescDebug":   &DescDebug,
+	"DescEmul":    &DescEmul,
+	"SName":       &SName,
+	"SEmail":      &SEmail,
+	"SSearchThis": &SSearchThis,
+	"SDoSearch":   &SDoSearch,
+	"SYes":        &SYes,
+	"SNo":         &SNo,
+	"SSaved":      &SSaved,
+	"SActive":     &SActive,
+	"SInactive":   &SInactive,
+	"STimed":      &STimed,
+	"SWorking":    &SWorking,
+	"WarnUnsaved": &WarnUnsaved,
+
+	"ErrSave":    &ErrSave,
+	"ErrEmpty":   &ErrEmpty,
+	"ErrMinLen":  &ErrMinLen,
+	"ErrSearch":  &ErrSearch,
+	"ErrRefresh": &ErrRefresh,
+	"ErrChanged": &ErrChanged,
+	"ErrRemoved": &ErrRemoved,
+	"ErrManualS": &ErrManualS,
+	"ErrTimeFmt": &ErrTimeFmt,
+
+	"ErrTimeUnit":   &ErrTimeUnit,
+	"ErrTimeNum":    &ErrTimeNum,
+	"ErrTimeHours":  &ErrTimeHours,
+	"ErrConfPath":   &ErrConfPath,
+	"ErrSetPerms":   &ErrSetPerms,
+	"ErrTimeoutS":   &ErrTimeoutS,
+	"ErrNoUsersS":   &ErrNoUsersS,
+	"ErrActionsS":   &ErrActionsS,
+	"ErrEmptyTime":  &ErrEmptyTime,
+	"ErrConfParse":  &ErrConfParse,
+	"ConfirmSaveS":  &ConfirmSaveS,
+	"ErrGetFSUsers": &ErrGetFSUsers,
+	"ErrNoChangesS": &ErrNoChangesS,
+
+	"WarnMayRefresh":   &WarnMayRefresh,
+	"ErrCmdNotFound":   &ErrCmdNotFound,
+	"ErrKeyNotFound":   &ErrKeyNotFound,
+	"ErrGetAuthUsers":  &ErrGetAuthUsers,
+	"ErrConfInvalidS":  &ErrConfInvalidS,
+	"ErrCantRefreshS":  &ErrCantRefreshS,
+	"ErrWrongDBClaimS": &ErrWrongDBClaimS,
+	"ErrUpdateFSUsers": &ErrUpdateFSUsers,
+	"ErrNewUsrFrmAuth": &ErrNewUsrFrmAuth,
+	"ErrPermsChangedS": &ErrPermsChangedS,
+	"WarnAddedPemsS":   &WarnAddedPemsS,
+	"WarnRemovedPemsS": &WarnRemovedPemsS,
+	"ErrWrongTimeBtns": &ErrWrongTimeBtns,
+
+	"MenuRole":        &MenuRole,
+	"DescGrant":       &DescGrant,
+	"DescRole":        &DescRole,
+	"SApplyRole":      &SApplyRole,
+	"ErrNoRolesS":     &ErrNoRolesS,
+	"ErrNoRowS":       &ErrNoRowS,
+	"ErrRoleNotFound": &ErrRoleNotFound,
+	"ErrRolePerm":     &ErrRolePerm,
+	"ErrRoleTime":     &ErrRoleTime,
+
+	"ErrRuleRequires":  &ErrRuleRequires,
+	"ErrRuleExcludes":  &ErrRuleExcludes,
+	"ErrRuleMaxDur":    &ErrRuleMaxDur,
+	"ErrRulePermanent": &ErrRulePermanent,
+	"ErrRuleInvalid":   &ErrRuleInvalid,
+	"ErrRuleTime":      &ErrRuleTime,
+	"WarnRulesS":       &WarnRulesS,
+	"ConfirmRulesS":    &ConfirmRulesS,
+
+	"DescOperator":    &DescOperator,
+	"SApprove":        &SApprove,
+	"SReject":         &SReject,
+	"SProposed":       &SProposed,
+	"SApproved":       &SApproved,
+	"SRejected":       &SRejected,
+	"SNoPending":      &SNoPending,
+	"SPendingItem":    &SPendingItem,
+	"ErrPropose":      &ErrPropose,
+	"ErrPending":      &ErrPending,
+	"ErrPendingStale": &ErrPendingStale,
+	"ErrApprovalPerm": &ErrApprovalPerm,
+	"ErrSelfApproveS": &ErrSelfApproveS,
+	"ErrNoPendingS":   &ErrNoPendingS,
+	"ErrNoAllowlistS": &ErrNoAllowlistS,
+
+	"DescWatch":      &DescWatch,
+	"ErrWatch":       &ErrWatch,
+	"WarnConflictS":  &WarnConflictS,
+	"ConfirmReloadS": &ConfirmReloadS,
+
+	"SScanned":     &SScanned,
+	"SBatches":     &SBatches,
+	"ErrCanceledS": &ErrCanceledS,
+	"ErrBusyS":     &ErrBusyS,
+
+	"DescRefreshTimeout": &DescRefreshTimeout,
+	"DescCheckpoint":     &DescCheckpoint,
+	"SResumed":           &SResumed,
+	"SDiscarded":         &SDiscarded,
+	"ErrCheckpoint":      &ErrCheckpoint,
+
+	"DescTimeout":   &DescTimeout,
+	"DescBatchSize": &DescBatchSize,
+	"DescRetries":   &DescRetries,
+	"DescBackoff":   &DescBackoff,
+	"SLastError":    &SLastError,
+	"ErrFlagsS":     &ErrFlagsS,
+
+	"DescJournal":       &DescJournal,
+	"DescRepair":        &DescRepair,
+	"ErrJournal":        &ErrJournal,
+	"ErrCacheNotSavedS": &ErrCacheNotSavedS,
+	"ErrUsersFailedS":   &ErrUsersFailedS,
+	"SUserStored":       &SUserStored,
+	"SUserFailed":       &SUserFailed,
+	"SRepaired":         &SRepaired,
+	"WarnJournalS":      &WarnJournalS,
+	"ErrRepairStaleS":   &ErrRepairStaleS,
+
+	"DescDryRun":     &DescDryRun,
+	"MenuDryRun":     &MenuDryRun,
+	"SDryRunBadge":   &SDryRunBadge,
+	"SDryRunReport":  &SDryRunReport,
+	"SDryRunClaims":  &SDryRunClaims,
+	"SDryRunSpecs":   &SDryRunSpecs,
+	"SDryRunDelete":  &SDryRunDelete,
+	"SDryRunAdded":   &SDryRunAdded,
+	"SDryRunDeleted": &SDryRunDeleted,
+	"SDryRunNothing": &SDryRunNothing,
+
+	"DescReadOnly":   &DescReadOnly,
+	"DescProfile":    &DescProfile,
+	"SReadOnlyBadge": &SReadOnlyBadge,
+	"ErrReadOnlyS":   &ErrReadOnlyS,
+	"ErrProfile":     &ErrProfile,
+
+	"ErrOperatorSource": &ErrOperatorSource,
+	"ErrOperatorPerm":   &ErrOperatorPerm,
+	"ErrOperatorS":      &ErrOperatorS,
+	"ErrOperator":       &ErrOperator,
+	"ErrForbidden":      &ErrForbidden,
+	"ErrForbiddenS":     &ErrForbiddenS,
+
+	"DescCredentials":    &DescCredentials,
+	"DescImpersonate":    &DescImpersonate,
+	"DescProject":        &DescProject,
+	"ErrCredentialsS":    &ErrCredentialsS,
+	"ErrCredentials":     &ErrCredentials,
+	"ErrCredentialsMode": &ErrCredentialsMode,
+	"ErrImpersonate":     &ErrImpersonate,
+	"ErrFirebaseInit":    &ErrFirebaseInit,
+
+	"DescFirestoreEmu": &DescFirestoreEmu,
+	"DescAuthEmu":      &DescAuthEmu,
+
+	"SDryRunUser":    &SDryRunUser,
+	"SDryRunProfile": &SDryRunProfile,
+
+	"DescEmu":         &DescEmu,
+	"DescSeed":        &DescSeed,
+	"DescFixtures":    &DescFixtures,
+	"SSeeded":         &SSeeded,
+	"ErrNotEmulatorS": &ErrNotEmulatorS,
+	"ErrFixtures":     &ErrFixtures,
+	"ErrFixtureUser":  &ErrFixtureUser,
+	"ErrFixturePerm":  &ErrFixturePerm,
+	"ErrFixtureClaim": &ErrFixtureClaim,
+	"ErrSeedUser":     &ErrSeedUser,
+	"ErrSeedSpecs":    &ErrSeedSpecs,
+
+	"HintUsage":       &HintUsage,
+	"HintConfig":      &HintConfig,
+	"HintCredentials": &HintCredentials,
+	"HintConnection":  &HintConnection,
+	"HintDenied":      &HintDenied,
+	"HintPartial":     &HintPartial,
+	"SError":          &SError,
+
+	"ErrLogFile": &ErrLogFile,
+
+	"TitleSearch":       &TitleSearch,
+	"TitleList":         &TitleList,
+	"TitleApprovals":    &TitleApprovals,
+	"WarnSearchAgainS":  &WarnSearchAgainS,
+	"WarnActionInListS": &WarnActionInListS,
+
+	"DescLang": &DescLang,
+	"ErrLang":  &ErrLang,
+
+	"DateLayout":     &DateLayout,
+	"ErrDateFormat":  &ErrDateFormat,
+	"ErrDateInput":   &ErrDateInput,
+	"SMonths":        &SMonths,
+	"SWeekdays":      &SWeekdays,
+	"SCalendarTitle": &SCalendarTitle,
+
+	"SExpired":        &SExpired,
+	"SRemainingDays":  &SRemainingDays,
+	"SRemainingHours": &SRemainingHours,
+	"SRemainingLess":  &SRemainingLess,
+	"ErrTimezone":     &ErrTimezone,
+
+	"DescFor": &DescFor,
+
+	"TitleExpiring": &TitleExpiring,
+	"SGroupExpired": &SGroupExpired,
+	"SGroupWeek":    &SGroupWeek,
+	"SGroupMonth":   &SGroupMonth,
+	"SGroupLater":   &SGroupLater,
+	"SNoExpiring":   &SNoExpiring,
+	"SExtendHint":   &SExtendHint,
+	"DescExpiring":  &DescExpiring,
+	"DescWithin":    &DescWithin,
+	"DescJSON":      &DescJSON,
+
+	"SExpires":    &SExpires,
+	"SLeft":       &SLeft,
+	"SPermission": &SPermission,
+
+	"MenuSelect":   &MenuSelect,
+	"MenuExtend":   &MenuExtend,
+	"SExtendWhich": &SExtendWhich,
+	"SExtendBy":    &SExtendBy,
+	"SExtendAllBy": &SExtendAllBy,
+	"SAllTimed":    &SAllTimed,
+	"SUsersN":      &SUsersN,
+	"ErrNoTimedS":  &ErrNoTimedS,
+
+	"ActFocusSearch": &ActFocusSearch,
+	"ActNextRow":     &ActNextRow,
+	"ActPrevRow":     &ActPrevRow,
+	"ActToggleClaim": &ActToggleClaim,
+	"ActOpenDetail":  &ActOpenDetail,
+	"SAtLine":        &SAtLine,
+	"ErrKeyConflict": &ErrKeyConflict,
+	"ErrKeyDefault":  &ErrKeyDefault,
+	"ErrKeyScope":    &ErrKeyScope,
+
+	"MenuPalette":    &MenuPalette,
+	"SPaletteHint":   &SPaletteHint,
+	"ActExport":      &ActExport,
+	"SExported":      &SExported,
+	"ErrExport":      &ErrExport,
+	"ConfirmExportS": &ConfirmExportS,
+
+	"ErrTheme":      &ErrTheme,
+	"ErrThemeColor": &ErrThemeColor,
+	"ErrThemeValue": &ErrThemeValue,
+
+	"SRowsOf": &SRowsOf,
+}
@@ -7,10 +7,15 @@ const (
 	PopupWarn     = "warn"
 	PopupProgress = "progress"
 	PopupClaim    = "claim"
+	PopupRole     = "role"
+	PopupExtend   = "extend"
+	PopupPalette  = "palette"
 
 	// page identifiers
-	PageSearch = "search"
-	PageList   = "list"
+	PageSearch    = "search"
+	PageList      = "list"
+	PageApprovals = "approvals"
+	PageExpiring  = "expiring"
 )
 
 const (
@@ -7,6 +7,8 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/vendelin8/firemage/internal/lang"
 )
 
 var (
@@ -16,10 +18,12 @@ var (
 )
 
 // Init intializes global logger, and returns with the cleanup functon.
-func Init() func() {
+func Init() (func(), error) {
 	// Open the log file once, to be shared by both loggers
 	logFile, err := os.OpenFile(LogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-	Must("open log file", err)
+	if err != nil {
+		return nil, fmt.Errorf(lang.ErrLogFile, err)
+	}
 
 	// Configure zap to use the same file handle
 	cfg := zap.NewDevelopmentConfig()
@@ -41,9 +45,9 @@ func Init() func() {
 	log.SetOutput(logFile)
 
 	return func() {
-		Must("log sync", Lgr.Sync())
-		Must("close log file", logFile.Close())
-	}
+		_ = Lgr.Sync() // nothing to do about it at exit
+		_ = logFile.Close()
+	}, nil
 }
 
 // Must panics if the given error is not nil, with the given description. For initializations only.
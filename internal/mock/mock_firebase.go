@@ -15,6 +15,7 @@ import (
 
 	firestore "cloud.google.com/go/firestore"
 	auth "firebase.google.com/go/auth"
+	common "github.com/vendelin8/firemage/internal/common"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,6 +43,48 @@ func (m *MockFbIf) EXPECT() *MockFbIfMockRecorder {
 	return m.recorder
 }
 
+// AddPending mocks base method.
+func (m *MockFbIf) AddPending(ctx context.Context, p *common.PendingChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPending", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPending indicates an expected call of AddPending.
+func (mr *MockFbIfMockRecorder) AddPending(ctx, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPending", reflect.TypeOf((*MockFbIf)(nil).AddPending), ctx, p)
+}
+
+// CreateUser mocks base method.
+func (m *MockFbIf) CreateUser(ctx context.Context, uid, email, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, uid, email, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockFbIfMockRecorder) CreateUser(ctx, uid, email, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockFbIf)(nil).CreateUser), ctx, uid, email, name)
+}
+
+// DeletePending mocks base method.
+func (m *MockFbIf) DeletePending(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePending", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePending indicates an expected call of DeletePending.
+func (mr *MockFbIfMockRecorder) DeletePending(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePending", reflect.TypeOf((*MockFbIf)(nil).DeletePending), ctx, id)
+}
+
 // DoList mocks base method.
 func (m *MockFbIf) DoList() error {
 	m.ctrl.T.Helper()
@@ -56,6 +99,36 @@ func (mr *MockFbIfMockRecorder) DoList() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoList", reflect.TypeOf((*MockFbIf)(nil).DoList))
 }
 
+// GetOperator mocks base method.
+func (m *MockFbIf) GetOperator(ctx context.Context, name string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOperator", ctx, name)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOperator indicates an expected call of GetOperator.
+func (mr *MockFbIfMockRecorder) GetOperator(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOperator", reflect.TypeOf((*MockFbIf)(nil).GetOperator), ctx, name)
+}
+
+// GetPending mocks base method.
+func (m *MockFbIf) GetPending(ctx context.Context) ([]*common.PendingChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", ctx)
+	ret0, _ := ret[0].([]*common.PendingChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending.
+func (mr *MockFbIfMockRecorder) GetPending(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockFbIf)(nil).GetPending), ctx)
+}
+
 // GetSpecs mocks base method.
 func (m *MockFbIf) GetSpecs(ctx context.Context) (map[string]any, error) {
 	m.ctrl.T.Helper()
@@ -87,17 +160,31 @@ func (mr *MockFbIfMockRecorder) GetUsers(ctx, uids any) *gomock.Call {
 }
 
 // IterUsers mocks base method.
-func (m *MockFbIf) IterUsers(cb func(*auth.UserRecord) error) error {
+func (m *MockFbIf) IterUsers(ctx context.Context, token string, cb func(*auth.UserRecord) error, onPage func(string) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IterUsers", cb)
+	ret := m.ctrl.Call(m, "IterUsers", ctx, token, cb, onPage)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // IterUsers indicates an expected call of IterUsers.
-func (mr *MockFbIfMockRecorder) IterUsers(cb any) *gomock.Call {
+func (mr *MockFbIfMockRecorder) IterUsers(ctx, token, cb, onPage any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterUsers", reflect.TypeOf((*MockFbIf)(nil).IterUsers), cb)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterUsers", reflect.TypeOf((*MockFbIf)(nil).IterUsers), ctx, token, cb, onPage)
+}
+
+// ProjectID mocks base method.
+func (m *MockFbIf) ProjectID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProjectID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ProjectID indicates an expected call of ProjectID.
+func (mr *MockFbIfMockRecorder) ProjectID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProjectID", reflect.TypeOf((*MockFbIf)(nil).ProjectID))
 }
 
 // RunTransaction mocks base method.
@@ -128,6 +215,34 @@ func (mr *MockFbIfMockRecorder) Search(ctx, key, value, cb any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockFbIf)(nil).Search), ctx, key, value, cb)
 }
 
+// SetProfile mocks base method.
+func (m *MockFbIf) SetProfile(ctx context.Context, uid string, profile map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProfile", ctx, uid, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProfile indicates an expected call of SetProfile.
+func (mr *MockFbIfMockRecorder) SetProfile(ctx, uid, profile any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProfile", reflect.TypeOf((*MockFbIf)(nil).SetProfile), ctx, uid, profile)
+}
+
+// SetSpecs mocks base method.
+func (m *MockFbIf) SetSpecs(ctx context.Context, privileged map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSpecs", ctx, privileged)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSpecs indicates an expected call of SetSpecs.
+func (mr *MockFbIfMockRecorder) SetSpecs(ctx, privileged any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSpecs", reflect.TypeOf((*MockFbIf)(nil).SetSpecs), ctx, privileged)
+}
+
 // StoreAuthClaims mocks base method.
 func (m *MockFbIf) StoreAuthClaims(ctx context.Context, uid string, newClaims map[string]any) error {
 	m.ctrl.T.Helper()
@@ -155,3 +270,31 @@ func (mr *MockFbIfMockRecorder) UpdateSpecs(tr, updates any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSpecs", reflect.TypeOf((*MockFbIf)(nil).UpdateSpecs), tr, updates)
 }
+
+// WatchChanges mocks base method.
+func (m *MockFbIf) WatchChanges(ctx context.Context, cb func(map[string]any)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchChanges", ctx, cb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchChanges indicates an expected call of WatchChanges.
+func (mr *MockFbIfMockRecorder) WatchChanges(ctx, cb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchChanges", reflect.TypeOf((*MockFbIf)(nil).WatchChanges), ctx, cb)
+}
+
+// WatchSpecs mocks base method.
+func (m *MockFbIf) WatchSpecs(ctx context.Context, cb func(map[string]any)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchSpecs", ctx, cb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchSpecs indicates an expected call of WatchSpecs.
+func (mr *MockFbIfMockRecorder) WatchSpecs(ctx, cb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchSpecs", reflect.TypeOf((*MockFbIf)(nil).WatchSpecs), ctx, cb)
+}
@@ -43,6 +43,18 @@ func (m *MockFeIf) EXPECT() *MockFeIfMockRecorder {
 	return m.recorder
 }
 
+// CancelProgress mocks base method.
+func (m *MockFeIf) CancelProgress() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CancelProgress")
+}
+
+// CancelProgress indicates an expected call of CancelProgress.
+func (mr *MockFeIfMockRecorder) CancelProgress() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelProgress", reflect.TypeOf((*MockFeIf)(nil).CancelProgress))
+}
+
 // ClaimButtonSetDisabled mocks base method.
 func (m *MockFeIf) ClaimButtonSetDisabled(index int, isDisabled bool) {
 	m.ctrl.T.Helper()
@@ -131,6 +143,30 @@ func (mr *MockFeIfMockRecorder) CurrentPage() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentPage", reflect.TypeOf((*MockFeIf)(nil).CurrentPage))
 }
 
+// FocusCell mocks base method.
+func (m *MockFeIf) FocusCell(i int, key string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "FocusCell", i, key)
+}
+
+// FocusCell indicates an expected call of FocusCell.
+func (mr *MockFeIfMockRecorder) FocusCell(i, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FocusCell", reflect.TypeOf((*MockFeIf)(nil).FocusCell), i, key)
+}
+
+// FocusSearch mocks base method.
+func (m *MockFeIf) FocusSearch() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "FocusSearch")
+}
+
+// FocusSearch indicates an expected call of FocusSearch.
+func (mr *MockFeIfMockRecorder) FocusSearch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FocusSearch", reflect.TypeOf((*MockFeIf)(nil).FocusSearch))
+}
+
 // HidePopup mocks base method.
 func (m *MockFeIf) HidePopup(popup string) {
 	m.ctrl.T.Helper()
@@ -143,6 +179,30 @@ func (mr *MockFeIfMockRecorder) HidePopup(popup any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HidePopup", reflect.TypeOf((*MockFeIf)(nil).HidePopup), popup)
 }
 
+// LayoutApprovals mocks base method.
+func (m *MockFeIf) LayoutApprovals() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LayoutApprovals")
+}
+
+// LayoutApprovals indicates an expected call of LayoutApprovals.
+func (mr *MockFeIfMockRecorder) LayoutApprovals() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LayoutApprovals", reflect.TypeOf((*MockFeIf)(nil).LayoutApprovals))
+}
+
+// LayoutExpiring mocks base method.
+func (m *MockFeIf) LayoutExpiring() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LayoutExpiring")
+}
+
+// LayoutExpiring indicates an expected call of LayoutExpiring.
+func (mr *MockFeIfMockRecorder) LayoutExpiring() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LayoutExpiring", reflect.TypeOf((*MockFeIf)(nil).LayoutExpiring))
+}
+
 // LayoutUsers mocks base method.
 func (m *MockFeIf) LayoutUsers() {
 	m.ctrl.T.Helper()
@@ -155,6 +215,18 @@ func (mr *MockFeIfMockRecorder) LayoutUsers() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LayoutUsers", reflect.TypeOf((*MockFeIf)(nil).LayoutUsers))
 }
 
+// QueueUpdateDraw mocks base method.
+func (m *MockFeIf) QueueUpdateDraw(f func()) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueueUpdateDraw", f)
+}
+
+// QueueUpdateDraw indicates an expected call of QueueUpdateDraw.
+func (mr *MockFeIfMockRecorder) QueueUpdateDraw(f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueUpdateDraw", reflect.TypeOf((*MockFeIf)(nil).QueueUpdateDraw), f)
+}
+
 // Quit mocks base method.
 func (m *MockFeIf) Quit() {
 	m.ctrl.T.Helper()
@@ -179,10 +251,24 @@ func (mr *MockFeIfMockRecorder) ReplaceTableItem(i, key, p any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceTableItem", reflect.TypeOf((*MockFeIf)(nil).ReplaceTableItem), i, key, p)
 }
 
+// ReplaceUserNames mocks base method.
+func (m *MockFeIf) ReplaceUserNames(i int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReplaceUserNames", i)
+}
+
+// ReplaceUserNames indicates an expected call of ReplaceUserNames.
+func (mr *MockFeIfMockRecorder) ReplaceUserNames(i any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUserNames", reflect.TypeOf((*MockFeIf)(nil).ReplaceUserNames), i)
+}
+
 // Run mocks base method.
-func (m *MockFeIf) Run() {
+func (m *MockFeIf) Run() error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "Run")
+	ret := m.ctrl.Call(m, "Run")
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // Run indicates an expected call of Run.
@@ -191,6 +277,18 @@ func (mr *MockFeIfMockRecorder) Run() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockFeIf)(nil).Run))
 }
 
+// SetLastError mocks base method.
+func (m_2 *MockFeIf) SetLastError(m string) {
+	m_2.ctrl.T.Helper()
+	m_2.ctrl.Call(m_2, "SetLastError", m)
+}
+
+// SetLastError indicates an expected call of SetLastError.
+func (mr *MockFeIfMockRecorder) SetLastError(m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLastError", reflect.TypeOf((*MockFeIf)(nil).SetLastError), m)
+}
+
 // SetOnShow mocks base method.
 func (m *MockFeIf) SetOnShow(arg0 string, arg1 func()) {
 	m.ctrl.T.Helper()
@@ -215,6 +313,18 @@ func (mr *MockFeIfMockRecorder) SetPage(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPage", reflect.TypeOf((*MockFeIf)(nil).SetPage), arg0)
 }
 
+// SetProgress mocks base method.
+func (m_2 *MockFeIf) SetProgress(m string) {
+	m_2.ctrl.T.Helper()
+	m_2.ctrl.Call(m_2, "SetProgress", m)
+}
+
+// SetProgress indicates an expected call of SetProgress.
+func (mr *MockFeIfMockRecorder) SetProgress(m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProgress", reflect.TypeOf((*MockFeIf)(nil).SetProgress), m)
+}
+
 // ShowClaimChoser mocks base method.
 func (m *MockFeIf) ShowClaimChoser(i int, key string, c common.Claim) {
 	m.ctrl.T.Helper()
@@ -244,6 +354,18 @@ func (mr *MockFeIfMockRecorder) ShowConfirm(onYes, onNo any, ms ...any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowConfirm", reflect.TypeOf((*MockFeIf)(nil).ShowConfirm), varargs...)
 }
 
+// ShowExtendChoser mocks base method.
+func (m *MockFeIf) ShowExtendChoser(rows []int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ShowExtendChoser", rows)
+}
+
+// ShowExtendChoser indicates an expected call of ShowExtendChoser.
+func (mr *MockFeIfMockRecorder) ShowExtendChoser(rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowExtendChoser", reflect.TypeOf((*MockFeIf)(nil).ShowExtendChoser), rows)
+}
+
 // ShowMsg mocks base method.
 func (m *MockFeIf) ShowMsg(ms ...string) {
 	m.ctrl.T.Helper()
@@ -260,6 +382,18 @@ func (mr *MockFeIfMockRecorder) ShowMsg(ms ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowMsg", reflect.TypeOf((*MockFeIf)(nil).ShowMsg), ms...)
 }
 
+// ShowPalette mocks base method.
+func (m *MockFeIf) ShowPalette() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ShowPalette")
+}
+
+// ShowPalette indicates an expected call of ShowPalette.
+func (mr *MockFeIfMockRecorder) ShowPalette() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowPalette", reflect.TypeOf((*MockFeIf)(nil).ShowPalette))
+}
+
 // ShowProgress mocks base method.
 func (m *MockFeIf) ShowProgress(ctx context.Context, cancelFunc context.CancelFunc, ms ...string) {
 	m.ctrl.T.Helper()
@@ -276,3 +410,27 @@ func (mr *MockFeIfMockRecorder) ShowProgress(ctx, cancelFunc any, ms ...any) *go
 	varargs := append([]any{ctx, cancelFunc}, ms...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowProgress", reflect.TypeOf((*MockFeIf)(nil).ShowProgress), varargs...)
 }
+
+// ShowRoleChoser mocks base method.
+func (m *MockFeIf) ShowRoleChoser(rows []int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ShowRoleChoser", rows)
+}
+
+// ShowRoleChoser indicates an expected call of ShowRoleChoser.
+func (mr *MockFeIfMockRecorder) ShowRoleChoser(rows any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowRoleChoser", reflect.TypeOf((*MockFeIf)(nil).ShowRoleChoser), rows)
+}
+
+// UpdateHeader mocks base method.
+func (m *MockFeIf) UpdateHeader() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateHeader")
+}
+
+// UpdateHeader indicates an expected call of UpdateHeader.
+func (mr *MockFeIfMockRecorder) UpdateHeader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHeader", reflect.TypeOf((*MockFeIf)(nil).UpdateHeader))
+}
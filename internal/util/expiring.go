@@ -0,0 +1,63 @@
+package util
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/global"
+)
+
+// Groups of timed claims by how soon they expire, see ExpiryGroup.
+const (
+	GroupExpired = iota
+	GroupWeek
+	GroupMonth
+	GroupLater
+)
+
+// TimedClaim is a timed permission of a user.
+type TimedClaim struct {
+	UID   string
+	Email string
+	Name  string
+	Perm  string
+	Date  time.Time
+}
+
+// TimedClaims returns the timed claims of the privileged users and the ones with pending actions,
+// with the actions applied. They're sorted by expiry, then by email and permission.
+func TimedClaims(s *global.Session) []TimedClaim {
+	var tcs []TimedClaim
+	for uid, u := range s.LocalUsers {
+		_, privileged := s.LocalPrivileged[uid]
+		if _, ok := s.Actions[uid]; !privileged && !ok {
+			continue
+		}
+
+		for perm, c := range fixedUserClaims(s, u) {
+			if c != nil && c.Date != nil {
+				tcs = append(tcs, TimedClaim{UID: uid, Email: u.Email, Name: u.Name, Perm: perm, Date: *c.Date})
+			}
+		}
+	}
+
+	slices.SortFunc(tcs, func(a, b TimedClaim) int {
+		return cmp.Or(a.Date.Compare(b.Date), cmp.Compare(a.Email, b.Email), cmp.Compare(a.Perm, b.Perm))
+	})
+	return tcs
+}
+
+// ExpiryGroup returns the group of a timed claim expiring at the given date.
+func ExpiryGroup(date, now time.Time) int {
+	switch {
+	case !date.After(now):
+		return GroupExpired
+	case date.Before(now.AddDate(0, 0, 7)):
+		return GroupWeek
+	case date.Before(now.AddDate(0, 0, 30)):
+		return GroupMonth
+	default:
+		return GroupLater
+	}
+}
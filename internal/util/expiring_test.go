@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestTimedClaims(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+	d1 := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC)
+	d2 := time.Date(2026, 4, 1, 23, 59, 59, 0, time.UTC)
+	d3 := time.Date(2026, 5, 1, 23, 59, 59, 0, time.UTC)
+
+	s.LocalUsers = map[string]*global.User{
+		"uid1": {UID: "uid1", Email: "b@example.com", Claims: common.ClaimsMap{
+			common.Admin:      {Checked: true},
+			common.Consultant: {Date: &d2},
+		}},
+		"uid2": {UID: "uid2", Email: "a@example.com", Claims: common.ClaimsMap{
+			common.Consultant: {Date: &d2},
+			common.SuperAdmin: {Date: &d1},
+		}},
+		"uid3": {UID: "uid3", Email: "found@example.com", Claims: common.ClaimsMap{}},
+		"uid4": {UID: "uid4", Email: "unprivileged@example.com", Claims: common.ClaimsMap{
+			common.Consultant: {Date: &d1},
+		}},
+	}
+	s.LocalPrivileged = map[string]struct{}{"uid1": {}, "uid2": {}}
+	s.Actions = map[string]common.ClaimsMap{
+		"uid2": {common.SuperAdmin: {Date: &d3}},
+		"uid3": {common.Consultant: {Date: &d1}},
+	}
+
+	assert.Equal(t, []TimedClaim{
+		{UID: "uid3", Email: "found@example.com", Perm: common.Consultant, Date: d1},
+		{UID: "uid2", Email: "a@example.com", Perm: common.Consultant, Date: d2},
+		{UID: "uid1", Email: "b@example.com", Perm: common.Consultant, Date: d2},
+		{UID: "uid2", Email: "a@example.com", Perm: common.SuperAdmin, Date: d3},
+	}, TimedClaims(s))
+}
+
+func TestExpiryGroup(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		date time.Time
+		want int
+	}{
+		{"expired", now.Add(-time.Hour), GroupExpired},
+		{"expiring right now", now, GroupExpired},
+		{"tomorrow", now.AddDate(0, 0, 1), GroupWeek},
+		{"in a week", now.AddDate(0, 0, 7), GroupMonth},
+		{"in 29 days", now.AddDate(0, 0, 29), GroupMonth},
+		{"in 30 days", now.AddDate(0, 0, 30), GroupLater},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExpiryGroup(tt.date, now))
+		})
+	}
+}
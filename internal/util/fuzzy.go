@@ -0,0 +1,48 @@
+package util
+
+import (
+	"unicode"
+)
+
+// Scores of fuzzy matches, see FuzzyScore.
+const (
+	fuzzyMatch       = 1  // every matching rune
+	fuzzyConsecutive = 4  // right after the previous match
+	fuzzyWordStart   = 6  // at the start of a word
+	fuzzyFirst       = 8  // at the start of the text
+	fuzzyGap         = -1 // every skipped rune between matches
+)
+
+// FuzzyScore checks if the runes of the pattern appear in the text in the same order, ignoring case.
+// The score is higher for runes at word starts and following each other, so "ar" ranks "Apply role"
+// over "Search". An empty pattern matches everything with 0.
+func FuzzyScore(pattern, text string) (int, bool) {
+	ps := []rune(pattern)
+	if len(ps) == 0 {
+		return 0, true
+	}
+
+	score, pi, last := 0, 0, -1
+	prev := ' '
+	for ti, r := range []rune(text) {
+		if pi < len(ps) && unicode.ToLower(r) == unicode.ToLower(ps[pi]) {
+			score += fuzzyMatch
+			switch {
+			case ti == 0:
+				score += fuzzyFirst
+			case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+				score += fuzzyWordStart
+			case last == ti-1:
+				score += fuzzyConsecutive
+			}
+			if last >= 0 {
+				score += fuzzyGap * (ti - last - 1)
+			}
+			last = ti
+			pi++
+		}
+		prev = r
+	}
+
+	return score, pi == len(ps)
+}
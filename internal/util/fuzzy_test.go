@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		text    string
+		wantOK  bool
+	}{
+		{name: "empty pattern", pattern: "", text: "Save", wantOK: true},
+		{name: "prefix", pattern: "sa", text: "Save", wantOK: true},
+		{name: "case insensitive", pattern: "SAVE", text: "Save", wantOK: true},
+		{name: "subsequence", pattern: "ar", text: "Apply role", wantOK: true},
+		{name: "out of order", pattern: "ra", text: "Apply role"},
+		{name: "missing rune", pattern: "sx", text: "Save"},
+		{name: "longer than text", pattern: "saves", text: "Save"},
+		{name: "accented", pattern: "hossz", text: "Hosszabbít", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := FuzzyScore(tt.pattern, tt.text)
+			assert.Equal(t, tt.wantOK, ok)
+		})
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	tests := []struct {
+		name          string
+		pattern       string
+		better, worse string
+	}{
+		{name: "word starts", pattern: "ar", better: "Apply role", worse: "Search"},
+		{name: "consecutive", pattern: "ext", better: "Extend", worse: "Next row"},
+		{name: "text start", pattern: "s", better: "Save", worse: "Focus search"},
+		{name: "fewer gaps", pattern: "nr", better: "Next row", worse: "Next long row"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, ok := FuzzyScore(tt.pattern, tt.better)
+			assert.True(t, ok)
+			worse, ok := FuzzyScore(tt.pattern, tt.worse)
+			assert.True(t, ok)
+			assert.Greater(t, better, worse)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	"github.com/vendelin8/firemage/internal/lang"
+)
+
+// ValidateRules checks common.Rules for unknown kinds, permissions and durations. Durations with hours
+// need timestamps, so it runs after the config file is loaded.
+func ValidateRules() error {
+	for _, r := range common.Rules {
+		if _, ok := common.PermsMap[r.Perm]; !ok {
+			return fmt.Errorf(lang.ErrRuleInvalid, r)
+		}
+
+		switch r.Kind {
+		case common.RuleRequires, common.RuleExcludes:
+			if _, ok := common.PermsMap[r.Other]; !ok || r.Other == r.Perm {
+				return fmt.Errorf(lang.ErrRuleInvalid, r)
+			}
+		case common.RuleMaxDuration:
+			if _, err := Expiry(time.Now(), r.Value); err != nil {
+				return fmt.Errorf(lang.ErrRuleTime, r, err)
+			}
+		case common.RuleNotPermanent:
+		default:
+			return fmt.Errorf(lang.ErrRuleInvalid, r)
+		}
+	}
+
+	return nil
+}
+
+// Violations returns the broken rules of the given claims in human readable form.
+// Timed claims are limited relative to now.
+func Violations(claims common.ClaimsMap, now time.Time) []string {
+	granted := func(perm string) bool {
+		c := claims[perm]
+		return c != nil && !c.IsZero()
+	}
+
+	var vs []string
+	for _, r := range common.Rules {
+		if !granted(r.Perm) {
+			continue
+		}
+
+		c, name := claims[r.Perm], common.PermsMap[r.Perm]
+		switch r.Kind {
+		case common.RuleRequires:
+			if !granted(r.Other) {
+				vs = append(vs, fmt.Sprintf(lang.ErrRuleRequires, name, common.PermsMap[r.Other]))
+			}
+		case common.RuleExcludes:
+			if granted(r.Other) {
+				vs = append(vs, fmt.Sprintf(lang.ErrRuleExcludes, name, common.PermsMap[r.Other]))
+			}
+		case common.RuleMaxDuration:
+			limit, err := Expiry(now, r.Value) // an unusable limit can't be kept
+			if err != nil || c.Date == nil || c.Date.After(limit) {
+				vs = append(vs, fmt.Sprintf(lang.ErrRuleMaxDur, name, r.Value))
+			}
+		case common.RuleNotPermanent:
+			if c.Date == nil {
+				vs = append(vs, fmt.Sprintf(lang.ErrRulePermanent, name))
+			}
+		}
+	}
+
+	return vs
+}
+
+// UserViolations returns the broken rules of a user's claims with pending actions applied.
+func UserViolations(s *global.Session, uid string, now time.Time) []string {
+	claims := s.Actions[uid]
+	if u, ok := s.LocalUsers[uid]; ok {
+		claims = fixedUserClaims(s, u)
+	}
+
+	return Violations(claims, now)
+}
@@ -0,0 +1,167 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vendelin8/firemage/internal/common"
+	"github.com/vendelin8/firemage/internal/global"
+	testutil "github.com/vendelin8/firemage/internal/util/test"
+)
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		rules      []common.Rule
+		timestamps bool
+		wantErr    bool
+	}{
+		{
+			name: "valid rules",
+			rules: []common.Rule{
+				{Kind: common.RuleRequires, Perm: common.SuperAdmin, Other: common.Admin},
+				{Kind: common.RuleExcludes, Perm: common.Consultant, Other: common.SuperAdmin},
+				{Kind: common.RuleMaxDuration, Perm: common.Consultant, Value: "6m"},
+				{Kind: common.RuleNotPermanent, Perm: common.Consultant},
+			},
+		},
+		{
+			name:    "unknown permission",
+			rules:   []common.Rule{{Kind: common.RuleNotPermanent, Perm: "janitor"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown other permission",
+			rules:   []common.Rule{{Kind: common.RuleRequires, Perm: common.Admin, Other: "janitor"}},
+			wantErr: true,
+		},
+		{
+			name:    "requires itself",
+			rules:   []common.Rule{{Kind: common.RuleExcludes, Perm: common.Admin, Other: common.Admin}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			rules:   []common.Rule{{Kind: common.RuleMaxDuration, Perm: common.Admin, Value: "1x"}},
+			wantErr: true,
+		},
+		{
+			name:    "hours without timestamps",
+			rules:   []common.Rule{{Kind: common.RuleMaxDuration, Perm: common.Admin, Value: "12h"}},
+			wantErr: true,
+		},
+		{
+			name:       "hours with timestamps",
+			rules:      []common.Rule{{Kind: common.RuleMaxDuration, Perm: common.Admin, Value: "12h"}},
+			timestamps: true,
+		},
+		{
+			name:    "unknown kind",
+			rules:   []common.Rule{{Kind: 42, Perm: common.Admin}},
+			wantErr: true,
+		},
+	}
+
+	defer func(rules []common.Rule) { common.Rules = rules }(common.Rules)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.Rules = tt.rules
+			common.StoreTimestamps = tt.timestamps
+			defer func() { common.StoreTimestamps = false }()
+			err := ValidateRules()
+			require.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestViolations(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	inLimit := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	overLimit := time.Date(2025, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	defer func(rules []common.Rule) { common.Rules = rules }(common.Rules)
+	common.Rules = []common.Rule{
+		{Kind: common.RuleRequires, Perm: common.SuperAdmin, Other: common.Admin},
+		{Kind: common.RuleExcludes, Perm: common.Consultant, Other: common.SuperAdmin},
+		{Kind: common.RuleMaxDuration, Perm: common.Consultant, Value: "6m"},
+		{Kind: common.RuleNotPermanent, Perm: common.Admin},
+	}
+
+	tests := []struct {
+		name   string
+		claims common.ClaimsMap
+		want   []string
+	}{
+		{
+			name:   "nothing granted",
+			claims: common.ClaimsMap{common.Admin: {}, common.SuperAdmin: {}, common.Consultant: {}},
+		},
+		{
+			name:   "requires missing permission",
+			claims: common.ClaimsMap{common.Admin: {}, common.SuperAdmin: {Checked: true}},
+			want:   []string{"SuperAdmin can be granted only together with Admin"},
+		},
+		{
+			name:   "requires timed permission",
+			claims: common.ClaimsMap{common.Admin: {Date: &inLimit}, common.SuperAdmin: {Checked: true}},
+		},
+		{
+			name:   "excluded permissions together",
+			claims: common.ClaimsMap{common.Consultant: {Date: &inLimit}, common.SuperAdmin: {Checked: true}, common.Admin: {Date: &inLimit}},
+			want:   []string{"Consultant can't be granted together with SuperAdmin"},
+		},
+		{
+			name:   "timed within limit",
+			claims: common.ClaimsMap{common.Consultant: {Date: &inLimit}},
+		},
+		{
+			name:   "timed over limit",
+			claims: common.ClaimsMap{common.Consultant: {Date: &overLimit}},
+			want:   []string{"Consultant can't be granted for longer than 6m"},
+		},
+		{
+			name:   "permanent exceeds max duration",
+			claims: common.ClaimsMap{common.Consultant: {Checked: true}},
+			want:   []string{"Consultant can't be granted for longer than 6m"},
+		},
+		{
+			name:   "permanent but should be timed",
+			claims: common.ClaimsMap{common.Admin: {Checked: true}},
+			want:   []string{"Admin can be granted only as timed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Violations(tt.claims, now))
+		})
+	}
+
+	common.Rules = []common.Rule{{Kind: common.RuleMaxDuration, Perm: common.Consultant, Value: "12h"}}
+	require.Equal(t, []string{"Consultant can't be granted for longer than 12h"},
+		Violations(common.ClaimsMap{common.Consultant: {Date: &now}}, now), "unusable limits are broken")
+}
+
+func TestUserViolations(t *testing.T) {
+	s := global.NewSession()
+	cleanup := testutil.InitLog()
+	defer cleanup()
+
+	defer func(rules []common.Rule) { common.Rules = rules }(common.Rules)
+	common.Rules = []common.Rule{{Kind: common.RuleRequires, Perm: common.SuperAdmin, Other: common.Admin}}
+
+	s.LocalUsers = map[string]*global.User{"uid1": {UID: "uid1", Claims: common.ClaimsMap{
+		common.Admin:      {Checked: true},
+		common.SuperAdmin: {Checked: true},
+	}}}
+	s.Actions = map[string]common.ClaimsMap{
+		"uid1": {common.Admin: {}},
+		"uid2": {common.SuperAdmin: {Checked: true}},
+	}
+
+	want := []string{"SuperAdmin can be granted only together with Admin"}
+	require.Equal(t, want, UserViolations(s, "uid1", time.Now()), "pending actions applied")
+	require.Equal(t, want, UserViolations(s, "uid2", time.Now()), "not downloaded user")
+}
@@ -1,7 +1,6 @@
 package util
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -11,13 +10,19 @@ import (
 	"github.com/vendelin8/firemage/internal/lang"
 )
 
-// timedButtonsMap stores parsed time values for each TimedButtons entry.
-// Key is the label from TimedButtons, value is [years, months, days].
-var timedButtonsMap map[string][]int
+// timedButtonsMap stores the parsed duration of each TimedButtons entry by its label.
+var timedButtonsMap map[string]duration
 
 var (
-	ErrEmpty = errors.New(lang.ErrEmptyTime)
-	ErrFmt   = errors.New(lang.ErrTimeFmt)
+	ErrEmpty = lang.NewError(&lang.ErrEmptyTime)
+	ErrFmt   = lang.NewError(&lang.ErrTimeFmt)
+	ErrHours = lang.NewError(&lang.ErrTimeHours)
+)
+
+// Anchors move a date to the end of a period, after adding the other parts of a duration.
+const (
+	anchorEOM = "eom" // end of the month
+	anchorEOQ = "eoq" // end of the quarter
 )
 
 type ErrInvalidUnit string
@@ -32,73 +37,226 @@ func (e *ErrInvalidUnit) Error() string {
 	return fmt.Sprintf(lang.ErrTimeUnit, *str)
 }
 
-// InitializeTimedButtonsMap parses all TimedButtons entries and returns a map of [year, month, day] triplets.
+// duration is a parsed human-readable duration, see parseTimedFormat.
+type duration struct {
+	years, months, days, hours int
+	anchor                     string
+}
+
+// addTo returns the given date moved by the duration. With an anchor the months are added to the
+// first day of the month, so the anchor applies to the month they lead to.
+func (d duration) addTo(date time.Time) time.Time {
+	if len(d.anchor) > 0 {
+		date = addMonths(date, d.years, d.months).AddDate(0, 0, d.days)
+	} else {
+		date = date.AddDate(d.years, d.months, d.days)
+	}
+	date = date.Add(time.Duration(d.hours) * time.Hour)
+
+	month := date.Month()
+	switch d.anchor {
+	case anchorEOM:
+	case anchorEOQ:
+		month = (month-1)/3*3 + 3
+	default:
+		return date
+	}
+
+	return time.Date(date.Year(), month+1, 0, date.Hour(), date.Minute(), date.Second(),
+		date.Nanosecond(), date.Location())
+}
+
+// addMonths returns the given date moved by years and months. The day is kept within the resulting
+// month instead of rolling over into the next one, eg. a month from January 31 is February 28 or 29.
+func addMonths(date time.Time, years, months int) time.Time {
+	first := time.Date(date.Year()+years, date.Month()+time.Month(months), 1, date.Hour(), date.Minute(),
+		date.Second(), date.Nanosecond(), date.Location())
+	last := first.AddDate(0, 1, -1).Day()
+
+	return first.AddDate(0, 0, min(date.Day(), last)-1)
+}
+
+// expiry returns when claims granted for the duration from the given time expire. It's the end of
+// the resulting day in common.ExpiryLocation, unless the duration has hours.
+func (d duration) expiry(from time.Time) time.Time {
+	date := d.addTo(from.In(common.ExpiryLocation))
+	if d.hours > 0 {
+		return date
+	}
+
+	return common.ExpiryOf(date)
+}
+
+// InitializeTimedButtonsMap parses all TimedButtons entries into timedButtonsMap.
 func InitializeTimedButtonsMap() error {
-	timedButtonsMap = make(map[string][]int, len(common.TimedButtons))
+	timedButtonsMap = make(map[string]duration, len(common.TimedButtons))
 	for _, items := range common.TimedButtons {
 		if len(items) != 2 {
 			return fmt.Errorf("%s: %v", lang.ErrWrongTimeBtns, items)
 		}
 
 		label, timeStr := items[0], items[1]
-		years, months, days, err := parseTimedFormat(timeStr)
+		d, err := parseTimedFormat(timeStr)
 		if err != nil {
 			return fmt.Errorf("%s '%s': '%s' - %w", lang.ErrWrongTimeBtns, label, timeStr, err)
 		}
-		timedButtonsMap[label] = []int{years, months, days}
+		timedButtonsMap[label] = d
 	}
 	return nil
 }
 
-// parseTimedFormat parses a human-readable time format string and returns the years, months, and days.
-// Supported formats:
+// CheckTimedButtons checks that no TimedButtons entry has hours, unless expiries are stored as
+// timestamps. Only the day would be kept otherwise. It runs after the config file is loaded.
+func CheckTimedButtons() error {
+	if common.StoreTimestamps {
+		return nil
+	}
+
+	for _, items := range common.TimedButtons {
+		if label := items[0]; timedButtonsMap[label].hours > 0 {
+			return fmt.Errorf("%s '%s': '%s' - %w", lang.ErrWrongTimeBtns, label, items[1], ErrHours)
+		}
+	}
+	return nil
+}
+
+// parseTimedFormat parses a human-readable duration. It's a sequence of numbers with units,
+// optionally closed by an anchor:
+//   - "12h" for 12 hours
 //   - "1d" for 1 day
 //   - "1w" for 1 week (7 days)
 //   - "1m" for 1 month
 //   - "1y" for 1 year
-func parseTimedFormat(timeStr string) (years, months, days int, err error) {
-	timeStr = strings.TrimSpace(timeStr)
-	if timeStr == "" {
-		return 0, 0, 0, ErrEmpty
+//   - "eom" for the end of the month, "eoq" for the end of the quarter
+//
+// They can be combined, eg. "1y6m", "2w3d" or "1meom" for the end of the next month.
+func parseTimedFormat(timeStr string) (d duration, err error) {
+	rest := strings.ToLower(strings.TrimSpace(timeStr))
+	if rest == "" {
+		return d, ErrEmpty
 	}
 
-	// Extract the numeric part and the unit
-	i := 0
-	for i < len(timeStr) && timeStr[i] >= '0' && timeStr[i] <= '9' {
-		i++
+	for len(rest) > 0 {
+		if rest == anchorEOM || rest == anchorEOQ {
+			d.anchor = rest
+			break
+		}
+
+		// Extract the numeric part and the unit
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+
+		if i == 0 || i == len(rest) {
+			return duration{}, ErrFmt
+		}
+
+		num, parseErr := strconv.Atoi(rest[:i])
+		if parseErr != nil {
+			// the digits were checked above, so it's too large
+			return duration{}, fmt.Errorf(lang.ErrTimeNum, rest[:i])
+		}
+
+		switch rest[i] {
+		case 'h':
+			d.hours += num
+		case 'd':
+			d.days += num
+		case 'w':
+			d.days += num * 7
+		case 'm':
+			d.months += num
+		case 'y':
+			d.years += num
+		default:
+			j := i
+			for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+				j++
+			}
+			return duration{}, NewErrInvalidUnit(rest[i:j])
+		}
+		rest = rest[i+1:]
 	}
 
-	if i == 0 || i == len(timeStr) {
-		return 0, 0, 0, ErrFmt
+	return d, nil
+}
+
+// HasHours tells if the duration of a TimedButtons label has hours. Those are added to now instead of
+// the date in the field, as they're for short access.
+func HasHours(label string) bool {
+	return timedButtonsMap[label].hours > 0
+}
+
+// AddTimedDate returns when claims granted for the duration of a TimedButtons label from the given
+// date expire. Uses precomputed values from timedButtonsMap for efficient lookups.
+func AddTimedDate(date time.Time, label string) time.Time {
+	return timedButtonsMap[label].expiry(date)
+}
+
+// AddTimed adds a human-readable time duration, eg. "3m" to a given date and returns the modified date.
+func AddTimed(date time.Time, timeStr string) (time.Time, error) {
+	d, err := parseTimedFormat(timeStr)
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	numStr := timeStr[:i]
-	unit := strings.ToLower(timeStr[i:])
+	return d.addTo(date), nil
+}
 
-	num, parseErr := strconv.Atoi(numStr)
-	if parseErr != nil {
-		// this shouldn't happen, as it was tested to be between 0-9 inclusive above
-		return 0, 0, 0, fmt.Errorf("invalid number: %s", numStr)
+// Expiry returns when claims granted for a human-readable time duration from the given time expire.
+// It's the end of the resulting day in common.ExpiryLocation, unless the duration has hours. Those
+// are refused unless expiries are stored as timestamps.
+func Expiry(from time.Time, timeStr string) (time.Time, error) {
+	d, err := parseTimedFormat(timeStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.hours > 0 && !common.StoreTimestamps {
+		return time.Time{}, ErrHours
 	}
 
-	switch unit {
-	case "d":
-		return 0, 0, num, nil
-	case "w":
-		return 0, 0, num * 7, nil
-	case "m":
-		return 0, num, 0, nil
-	case "y":
-		return num, 0, 0, nil
-	default:
-		return 0, 0, 0, NewErrInvalidUnit(unit)
+	return d.expiry(from), nil
+}
+
+// ParseDate parses an expiry typed in common.DateFormat with an optional time like "15:04", or a
+// duration from the given time like "+3m". A day expires at its end in common.ExpiryLocation. Times
+// and hours are refused unless expiries are stored as timestamps.
+func ParseDate(text string, from time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if timeStr, ok := strings.CutPrefix(text, "+"); ok {
+		return Expiry(from, timeStr)
+	}
+
+	if d, err := time.Parse(common.DateFormat, text); err == nil {
+		return common.ExpiryOf(d), nil
 	}
+	d, err := time.ParseInLocation(common.DateFormat+" "+common.TimeFormat, text, common.ExpiryLocation)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(lang.ErrDateInput, from.In(common.ExpiryLocation).Format(common.DateFormat))
+	}
+	if !common.StoreTimestamps {
+		return time.Time{}, ErrHours
+	}
+
+	return d, nil
 }
 
-// AddTimedDate adds a human-readable time duration to a given date and returns the modified date.
-// Uses precomputed values from timedButtonsMap for efficient lookups.
-func AddTimedDate(date time.Time, label string) time.Time {
-	triplet := timedButtonsMap[label]
-	years, months, days := triplet[0], triplet[1], triplet[2]
-	return date.AddDate(years, months, days)
+// RoleClaims returns the claims of a role template. Timed ones expire relative to the given date.
+func RoleClaims(r common.Role, from time.Time) (common.ClaimsMap, error) {
+	claims := make(common.ClaimsMap, len(r))
+	for perm, timeStr := range r {
+		if len(timeStr) == 0 {
+			claims[perm] = &common.Claim{Checked: true}
+			continue
+		}
+
+		d, err := Expiry(from, timeStr)
+		if err != nil {
+			return nil, err
+		}
+		claims[perm] = &common.Claim{Date: &d}
+	}
+
+	return claims, nil
 }
@@ -17,6 +17,7 @@ func TestAddTimedDate(t *testing.T) {
 		{"One month", "1m"},
 		{"Three months", "3m"},
 		{"One year", "1y"},
+		{"Twelve hours", "12h"},
 	}
 	InitializeTimedButtonsMap()
 
@@ -31,25 +32,31 @@ func TestAddTimedDate(t *testing.T) {
 			name:  "one month",
 			date:  baseDate,
 			label: "One month",
-			want:  time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC),
+			want:  time.Date(2025, 2, 1, 23, 59, 59, 0, time.UTC),
 		},
 		{
 			name:  "three months",
 			date:  baseDate,
 			label: "Three months",
-			want:  time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+			want:  time.Date(2025, 4, 1, 23, 59, 59, 0, time.UTC),
 		},
 		{
 			name:  "one year",
 			date:  baseDate,
 			label: "One year",
-			want:  time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 1, 1, 23, 59, 59, 0, time.UTC),
 		},
 		{
 			name:  "add month from Dec 31 to next year",
 			date:  time.Date(2025, 12, 31, 12, 0, 0, 0, time.UTC),
 			label: "One month",
-			want:  time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC),
+			want:  time.Date(2026, 1, 31, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name:  "hours aren't rounded to the end of the day",
+			date:  baseDate,
+			label: "Twelve hours",
+			want:  time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
@@ -59,6 +66,9 @@ func TestAddTimedDate(t *testing.T) {
 			require.Equal(t, got, tt.want)
 		})
 	}
+
+	require.True(t, HasHours("Twelve hours"))
+	require.False(t, HasHours("One month"))
 }
 
 func TestInitializeTimedButtonsMap(t *testing.T) {
@@ -66,7 +76,7 @@ func TestInitializeTimedButtonsMap(t *testing.T) {
 		name        string
 		timedBtns   [][]string
 		wantErr     error
-		wantEntries map[string][]int
+		wantEntries map[string]duration
 	}{
 		{
 			name: "valid entries",
@@ -78,19 +88,19 @@ func TestInitializeTimedButtonsMap(t *testing.T) {
 				{"Seven weeks", "7w"},
 			},
 			wantErr: nil,
-			wantEntries: map[string][]int{
-				"One day":     {0, 0, 1},
-				"One week":    {0, 0, 7},
-				"One month":   {0, 1, 0},
-				"One year":    {1, 0, 0},
-				"Seven weeks": {0, 0, 49},
+			wantEntries: map[string]duration{
+				"One day":     {days: 1},
+				"One week":    {days: 7},
+				"One month":   {months: 1},
+				"One year":    {years: 1},
+				"Seven weeks": {days: 49},
 			},
 		},
 		{
 			name:        "empty map",
 			timedBtns:   [][]string{},
 			wantErr:     nil,
-			wantEntries: map[string][]int{},
+			wantEntries: map[string]duration{},
 		},
 		{
 			name: "invalid format - no number",
@@ -136,10 +146,10 @@ func TestInitializeTimedButtonsMap(t *testing.T) {
 				{"Large year", "10y"},
 			},
 			wantErr: nil,
-			wantEntries: map[string][]int{
-				"Large day":   {0, 0, 365},
-				"Large month": {0, 12, 0},
-				"Large year":  {10, 0, 0},
+			wantEntries: map[string]duration{
+				"Large day":   {days: 365},
+				"Large month": {months: 12},
+				"Large year":  {years: 10},
 			},
 		},
 	}
@@ -160,46 +170,198 @@ func TestInitializeTimedButtonsMap(t *testing.T) {
 	}
 }
 
+func TestCheckTimedButtons(t *testing.T) {
+	defer func(btns [][]string) { common.TimedButtons = btns; InitializeTimedButtonsMap() }(common.TimedButtons)
+	common.TimedButtons = [][]string{{"One month", "1m"}, {"Twelve hours", "12h"}}
+	require.NoError(t, InitializeTimedButtonsMap())
+
+	tests := []struct {
+		name      string
+		timestamp bool
+		wantErr   error
+	}{
+		{"hours stored as a day", false, fmt.Errorf("%s '%s': '%s' - %w", lang.ErrWrongTimeBtns, "Twelve hours", "12h", ErrHours)},
+		{"hours stored as a timestamp", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.StoreTimestamps = tt.timestamp
+			defer func() { common.StoreTimestamps = false }()
+
+			require.Equal(t, tt.wantErr, CheckTimedButtons())
+		})
+	}
+}
+
 func TestParseTimedFormat(t *testing.T) {
 	tests := []struct {
-		name       string
-		timeStr    string
-		wantYears  int
-		wantMonths int
-		wantDays   int
-		wantErr    error
+		name    string
+		timeStr string
+		want    duration
+		wantErr error
 	}{
 		// Valid cases
-		{"1 day", "1d", 0, 0, 1, nil},
-		{"10 days", "10d", 0, 0, 10, nil},
-		{"1 week", "1w", 0, 0, 7, nil},
-		{"4 weeks", "4w", 0, 0, 28, nil},
-		{"1 month", "1m", 0, 1, 0, nil},
-		{"3 months", "3m", 0, 3, 0, nil},
-		{"1 year", "1y", 1, 0, 0, nil},
-		{"2 years", "2y", 2, 0, 0, nil},
-		{"whitespace", "  1d  ", 0, 0, 1, nil},
-		{"uppercase", "1D", 0, 0, 1, nil},
+		{"1 day", "1d", duration{days: 1}, nil},
+		{"10 days", "10d", duration{days: 10}, nil},
+		{"1 week", "1w", duration{days: 7}, nil},
+		{"4 weeks", "4w", duration{days: 28}, nil},
+		{"1 month", "1m", duration{months: 1}, nil},
+		{"3 months", "3m", duration{months: 3}, nil},
+		{"1 year", "1y", duration{years: 1}, nil},
+		{"2 years", "2y", duration{years: 2}, nil},
+		{"12 hours", "12h", duration{hours: 12}, nil},
+		{"whitespace", "  1d  ", duration{days: 1}, nil},
+		{"uppercase", "1D", duration{days: 1}, nil},
+		{"compound", "1y6m", duration{years: 1, months: 6}, nil},
+		{"weeks and days", "2w3d", duration{days: 17}, nil},
+		{"repeated unit", "1d2d", duration{days: 3}, nil},
+		{"end of month", "eom", duration{anchor: anchorEOM}, nil},
+		{"end of next quarter", "3mEOQ", duration{months: 3, anchor: anchorEOQ}, nil},
 
 		// Error cases
-		{"empty string", "", 0, 0, 0, ErrEmpty},
-		{"no unit", "5", 0, 0, 0, ErrFmt},
-		{"no number", "d", 0, 0, 0, ErrFmt},
-		{"invalid unit", "5x", 0, 0, 0, NewErrInvalidUnit("x")},
-		{"negative", "-5d", 0, 0, 0, ErrFmt},
-		{"zero", "0d", 0, 0, 0, nil},
-		{"invalid number", "abcd", 0, 0, 0, ErrFmt},
-		{"whitespace only", "   ", 0, 0, 0, ErrEmpty},
+		{"empty string", "", duration{}, ErrEmpty},
+		{"no unit", "5", duration{}, ErrFmt},
+		{"no number", "d", duration{}, ErrFmt},
+		{"invalid unit", "5x", duration{}, NewErrInvalidUnit("x")},
+		{"invalid unit after valid", "1y2xy3d", duration{}, NewErrInvalidUnit("xy")},
+		{"compound without unit", "1y6", duration{}, ErrFmt},
+		{"anchor not last", "eom1d", duration{}, ErrFmt},
+		{"negative", "-5d", duration{}, ErrFmt},
+		{"zero", "0d", duration{}, nil},
+		{"invalid number", "abcd", duration{}, ErrFmt},
+		{"too large number", "99999999999999999999d", duration{}, fmt.Errorf(lang.ErrTimeNum, "99999999999999999999")},
+		{"whitespace only", "   ", duration{}, ErrEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimedFormat(tt.timeStr)
+
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDurationAddTo(t *testing.T) {
+	jan31 := time.Date(2025, 1, 31, 18, 30, 0, 0, time.UTC)
+	aug31 := time.Date(2025, 8, 31, 18, 30, 0, 0, time.UTC)
+	nov30 := time.Date(2025, 11, 30, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		timeStr string
+		want    time.Time
+	}{
+		{"compound", jan31, "1y6m", time.Date(2026, 7, 31, 18, 30, 0, 0, time.UTC)},
+		{"hours", jan31, "12h", time.Date(2025, 2, 1, 6, 30, 0, 0, time.UTC)},
+		{"month rolls over without anchor", jan31, "1m", time.Date(2025, 3, 3, 18, 30, 0, 0, time.UTC)},
+		{"end of month", jan31, "eom", time.Date(2025, 1, 31, 18, 30, 0, 0, time.UTC)},
+		{"end of next month", jan31, "1meom", time.Date(2025, 2, 28, 18, 30, 0, 0, time.UTC)},
+		{"end of next month in leap year", jan31.AddDate(-1, 0, 0), "1meom", time.Date(2024, 2, 29, 18, 30, 0, 0, time.UTC)},
+		{"end of quarter", jan31, "eoq", time.Date(2025, 3, 31, 18, 30, 0, 0, time.UTC)},
+		{"end of quarter at its end", jan31, "2meoq", time.Date(2025, 3, 31, 18, 30, 0, 0, time.UTC)},
+		{"end of the year", jan31, "9m2weoq", time.Date(2025, 12, 31, 18, 30, 0, 0, time.UTC)},
+		{"end of shorter next month", aug31, "1meom", time.Date(2025, 9, 30, 18, 30, 0, 0, time.UTC)},
+		{"end of quarter of shorter month", aug31, "1meoq", time.Date(2025, 9, 30, 18, 30, 0, 0, time.UTC)},
+		{"end of next quarter", aug31, "3meoq", time.Date(2025, 12, 31, 18, 30, 0, 0, time.UTC)},
+		{"end of February next year", nov30, "3meom", time.Date(2026, 2, 28, 18, 30, 0, 0, time.UTC)},
+		{"end of quarter next year", nov30, "3meoq", time.Date(2026, 3, 31, 18, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AddTimed(tt.from, tt.timeStr)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	defer func(layout string) { common.DateFormat = layout }(common.DateFormat)
+	common.DateFormat = "2006.01.02."
+	from := time.Date(2025, 1, 31, 18, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		text      string
+		timestamp bool
+		want      time.Time
+		wantErr   error
+	}{
+		{"date", "2025.03.01.", false, time.Date(2025, 3, 1, 23, 59, 59, 0, time.UTC), nil},
+		{"date and time", "2025.03.01. 14:30", true, time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC), nil},
+		{"date and time stored as a day", "2025.03.01. 14:30", false, time.Time{}, ErrHours},
+		{"relative", "+1m", false, time.Date(2025, 3, 3, 23, 59, 59, 0, time.UTC), nil},
+		{"relative with spaces", " +2w ", false, time.Date(2025, 2, 14, 23, 59, 59, 0, time.UTC), nil},
+		{"relative with hours", "+1d12h", true, from.Add(36 * time.Hour).In(time.UTC), nil},
+		{"relative with hours stored as a day", "+12h", false, time.Time{}, ErrHours},
+		{"end of month", "+eom", false, time.Date(2025, 1, 31, 23, 59, 59, 0, time.UTC), nil},
+		{"relative invalid unit", "+2x", false, time.Time{}, NewErrInvalidUnit("x")},
+		{"relative without number", "+", false, time.Time{}, ErrEmpty},
+		{"storage format", "2025-03-01", false, time.Time{}, fmt.Errorf(lang.ErrDateInput, "2025.01.31.")},
+		{"empty", "", false, time.Time{}, fmt.Errorf(lang.ErrDateInput, "2025.01.31.")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.StoreTimestamps = tt.timestamp
+			defer func() { common.StoreTimestamps = false }()
+
+			got, err := ParseDate(tt.text, from)
+
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRoleClaims(t *testing.T) {
+	from := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	threeMonths := time.Date(2025, 5, 1, 23, 59, 59, 0, time.UTC)
+	twoWeeks := time.Date(2025, 2, 14, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		role    common.Role
+		want    common.ClaimsMap
+		wantErr error
+	}{
+		{
+			name: "permanent and timed permissions",
+			role: common.Role{common.Admin: "", common.Consultant: "3m"},
+			want: common.ClaimsMap{
+				common.Admin:      {Checked: true},
+				common.Consultant: {Date: &threeMonths},
+			},
+		},
+		{
+			name: "weeks",
+			role: common.Role{common.SuperAdmin: "2w"},
+			want: common.ClaimsMap{common.SuperAdmin: {Date: &twoWeeks}},
+		},
+		{
+			name: "empty role",
+			role: common.Role{},
+			want: common.ClaimsMap{},
+		},
+		{
+			name:    "invalid duration",
+			role:    common.Role{common.Admin: "3x"},
+			wantErr: NewErrInvalidUnit("x"),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			years, months, days, err := parseTimedFormat(tt.timeStr)
+			got, err := RoleClaims(tt.role, from)
 
-			require.Equal(t, err, tt.wantErr)
-			require.Equal(t, years, tt.wantYears)
-			require.Equal(t, months, tt.wantMonths)
-			require.Equal(t, days, tt.wantDays)
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.want, got)
 		})
 	}
 }
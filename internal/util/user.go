@@ -11,9 +11,9 @@ import (
 )
 
 // fixedUserClaims returns user claims with applied actions.
-func fixedUserClaims(u *global.User) common.ClaimsMap {
+func fixedUserClaims(s *global.Session, u *global.User) common.ClaimsMap {
 	claims := u.Claims
-	if ac, ok := global.Actions[u.UID]; ok {
+	if ac, ok := s.Actions[u.UID]; ok {
 		log.Lgr.Debug("fixedUserClaims", zap.String("uid", u.UID), zap.Any("actions", ac))
 		claims = maps.Clone(u.Claims)
 		maps.Copy(claims, ac)
@@ -22,20 +22,42 @@ func fixedUserClaims(u *global.User) common.ClaimsMap {
 }
 
 // FixedUserDetails returns user name, email and claims with applied actions.
-func FixedUserDetails(uid string) (string, string, common.ClaimsMap) {
-	u := global.LocalUsers[uid]
-	return u.Name, u.Email, fixedUserClaims(u)
+func FixedUserDetails(s *global.Session, uid string) (string, string, common.ClaimsMap) {
+	u := s.LocalUsers[uid]
+	return u.Name, u.Email, fixedUserClaims(s, u)
 }
 
 // FixedUserClaims returns user name, email and claims with applied actions.
-func FixedUserClaims(uid string) common.ClaimsMap {
-	u := global.LocalUsers[uid]
-	return fixedUserClaims(u)
+func FixedUserClaims(s *global.Session, uid string) common.ClaimsMap {
+	u := s.LocalUsers[uid]
+	return fixedUserClaims(s, u)
 }
 
-func SortByNameThenEmail(x []string) {
+// StageClaim stores a pending claim change of a user in the session's Actions. If it matches the saved claim,
+// the pending change gets removed instead.
+func StageClaim(s *global.Session, uid, key string, c common.Claim) {
+	current := s.LocalUsers[uid].Claims[key]
+	acts := s.Actions[uid]
+
+	if current != nil && !c.Differs(current) {
+		delete(acts, key)
+		if len(acts) == 0 {
+			delete(s.Actions, uid)
+		}
+		return
+	}
+
+	if acts == nil {
+		acts = common.ClaimsMap{}
+		s.Actions[uid] = acts
+	}
+
+	acts[key] = &c
+}
+
+func SortByNameThenEmail(s *global.Session, x []string) {
 	sort.Slice(x, func(i, j int) bool {
-		ui, uj := global.LocalUsers[x[i]], global.LocalUsers[x[j]]
+		ui, uj := s.LocalUsers[x[i]], s.LocalUsers[x[j]]
 		uin, ujn := ui.Name, uj.Name
 		uiz := len(uin) == 0
 		if uiz != (len(ujn) == 0) {